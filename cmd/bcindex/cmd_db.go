@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// handleDB implements the db subcommand
+func handleDB(cfg *config.Config, args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, `USAGE:
+    bcindex db <action> [options]
+
+DESCRIPTION:
+    Maintain the index database of the current repository.
+
+ACTIONS:
+    migrate    Apply pending schema migrations
+    status     Show the schema version and migration history
+
+EXAMPLES:
+    # Show which migrations would be applied
+    bcindex db migrate -dry-run
+
+    # Apply pending migrations
+    bcindex db migrate
+
+    # Show migration history
+    bcindex db status
+`)
+	}
+
+	if len(args) < 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "migrate":
+		handleDBMigrate(cfg, args[1:])
+	case "status":
+		handleDBStatus(cfg, args[1:])
+	case "-h", "-help", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown db action: %s\n\n", args[0])
+		usage()
+		os.Exit(1)
+	}
+}
+
+// handleDBMigrate applies (or with -dry-run, lists) pending migrations
+func handleDBMigrate(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("db migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "List pending migrations without applying them")
+
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Failed to parse arguments: %v", err)
+	}
+
+	db, err := store.OpenNoMigrate(cfg.Database.Path)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		log.Fatalf("Failed to read schema version: %v", err)
+	}
+
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		log.Fatalf("Failed to check migrations: %v", err)
+	}
+
+	fmt.Printf("Database: %s\n", cfg.Database.Path)
+	fmt.Printf("Schema version: %d (latest: %d)\n", version, store.CurrentSchemaVersion)
+
+	if len(pending) == 0 {
+		fmt.Println("✅ Database is up to date")
+		return
+	}
+
+	if *dryRun {
+		fmt.Printf("\nPending migrations (%d):\n", len(pending))
+		for _, m := range pending {
+			fmt.Printf("   %04d_%s\n", m.Version, m.Name)
+		}
+		fmt.Println("\nDry run: no changes applied")
+		return
+	}
+
+	applied, err := db.Migrate()
+	for _, m := range applied {
+		fmt.Printf("   applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	fmt.Printf("✅ Migrated to schema version %d\n", store.CurrentSchemaVersion)
+}
+
+// handleDBStatus prints the applied migration history
+func handleDBStatus(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("db status", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Failed to parse arguments: %v", err)
+	}
+
+	db, err := store.OpenNoMigrate(cfg.Database.Path)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		log.Fatalf("Failed to read schema version: %v", err)
+	}
+
+	history, err := db.MigrationHistory()
+	if err != nil {
+		log.Fatalf("Failed to read migration history: %v", err)
+	}
+
+	fmt.Printf("Database: %s\n", cfg.Database.Path)
+	fmt.Printf("Schema version: %d (latest: %d)\n", version, store.CurrentSchemaVersion)
+
+	if version > store.CurrentSchemaVersion {
+		fmt.Println("⚠️  Database was written by a newer bcindex; upgrade this binary")
+	}
+
+	if len(history) == 0 {
+		fmt.Println("\nNo migrations applied")
+		return
+	}
+
+	fmt.Println("\nApplied migrations:")
+	for _, record := range history {
+		name := record.Name
+		if name == "" {
+			name = "(legacy)"
+		}
+		fmt.Printf("   %04d_%-20s %s\n", record.Version, name, record.AppliedAt.UTC().Format(time.RFC3339))
+	}
+}
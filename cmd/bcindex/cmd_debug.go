@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/indexer"
+)
+
+// handleDebug implements the debug subcommand
+func handleDebug(cfg *config.Config, args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, `USAGE:
+    bcindex debug <action> [options]
+
+DESCRIPTION:
+    Inspect how the index was built.
+
+ACTIONS:
+    embed-text <symbol-id>    Print the text embedded for a symbol
+
+EXAMPLES:
+    # Show what was embedded for a method
+    bcindex debug embed-text "github.com/user/repo/internal/store:method:VectorStore.Search"
+`)
+	}
+
+	if len(args) < 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "embed-text":
+		handleDebugEmbedText(cfg, args[1:])
+	case "-h", "-help", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown debug action: %s\n\n", args[0])
+		usage()
+		os.Exit(1)
+	}
+}
+
+// handleDebugEmbedText prints the embedding text of a symbol. The text goes to
+// stdout unchanged; notes about the stored vector go to stderr.
+func handleDebugEmbedText(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("debug embed-text", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `USAGE:
+    bcindex debug embed-text <symbol-id>
+
+DESCRIPTION:
+    Render the embedding text of an indexed symbol with the configured
+    embedding.text_template, exactly as it is sent to the embedding model.
+    Symbol IDs are shown by "bcindex search -json".
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Failed to parse arguments: %v", err)
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	idx, err := indexer.NewIndexer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create indexer: %v", err)
+	}
+	defer idx.Close()
+
+	info, err := idx.EmbeddingText(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to build embedding text: %v", err)
+	}
+
+	fmt.Println(info.Text)
+
+	switch {
+	case !info.Stored:
+		fmt.Fprintln(os.Stderr, "\n(no embedding stored for this symbol)")
+	case !info.UpToDate:
+		fmt.Fprintln(os.Stderr, "\n(stored embedding was generated from a different text; run `bcindex index` to refresh)")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/DreamCats/bcindex/internal/config"
 	"github.com/DreamCats/bcindex/internal/indexer"
 	"github.com/DreamCats/bcindex/internal/retrieval"
+	"github.com/DreamCats/bcindex/internal/store"
 )
 
 // handleEvidence implements the evidence subcommand
@@ -93,6 +94,7 @@ EXAMPLES:
 		idx.GetEmbedService(),
 		expander,
 	)
+	configureReranker(cfg, idx, retriever)
 
 	// Configure evidence builder
 	evidenceBuilder := retriever.GetEvidenceBuilder()
@@ -104,6 +106,14 @@ EXAMPLES:
 	// Perform search and build evidence pack
 	ctx := context.Background()
 	opts := retrieval.DefaultSearchOptions()
+	opts.RepoPath = cfg.Repo.Path
+	opts.Fusion = cfg.Search.Fusion
+	opts.RRFK = cfg.Search.RRFK
+	opts.KeywordColumns = store.BM25Weights{
+		Name:         cfg.Search.BM25NameWeight,
+		SemanticText: cfg.Search.BM25TextWeight,
+		Tokens:       cfg.Search.BM25TokensWeight,
+	}
 
 	pack, err := retriever.SearchAsEvidencePack(ctx, query, opts)
 	if err != nil {
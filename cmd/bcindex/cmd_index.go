@@ -16,7 +16,7 @@ import (
 // handleIndex implements the index subcommand
 func handleIndex(cfg *config.Config, args []string) {
 	fs := flag.NewFlagSet("index", flag.ExitOnError)
-	force := fs.Bool("force", false, "Force rebuild index")
+	force := fs.Bool("force", false, "Force rebuild index (cached embeddings are reused)")
 	verbose := fs.Bool("v", false, "Verbose output")
 
 	fs.Usage = func() {
@@ -77,6 +77,7 @@ EXAMPLES:
 		log.Fatalf("Failed to create indexer: %v", err)
 	}
 	defer idx.Close()
+	idx.SetForce(*force)
 
 	// Start indexing
 	startTime := time.Now()
@@ -90,8 +91,6 @@ EXAMPLES:
 		log.Fatalf("Indexing failed: %v", err)
 	}
 
-	_ = *force // TODO: implement force rebuild
-
 	duration := time.Since(startTime)
 
 	// Print statistics
@@ -109,4 +108,5 @@ EXAMPLES:
 	fmt.Printf("   Symbols:    %6d\n", symbolCount)
 	fmt.Printf("   Relations:  %6d\n", edgeCount)
 	fmt.Printf("   Embeddings: %6d\n", vectorCount)
+	printEmbeddingCacheStats(idx.GetEmbeddingCache(), "   ")
 }
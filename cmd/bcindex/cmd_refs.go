@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/indexer"
+	"github.com/DreamCats/bcindex/internal/retrieval"
+)
+
+// handleRefs implements the refs subcommand
+func handleRefs(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("refs", flag.ExitOnError)
+
+	var topK int
+	var edgeType, direction, packagePath string
+	var jsonOutput bool
+
+	fs.IntVar(&topK, "k", 20, "Maximum number of edges to return (0 for all)")
+	fs.StringVar(&edgeType, "type", "", "Only return edges of this type (calls, references, implements, imports, embeds, dispatches, constrains, tests)")
+	fs.StringVar(&direction, "direction", retrieval.DirectionIncoming, "Edge direction: incoming, outgoing or both")
+	fs.StringVar(&packagePath, "package", "", "Only match symbols of this package (when looking up by name)")
+	fs.BoolVar(&jsonOutput, "json", false, "Output results as JSON")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `USAGE:
+    bcindex refs [options] <symbol-id|name>
+
+DESCRIPTION:
+    List the relationships of a symbol with the exact call and reference
+    sites (file:line:column) and a one-line source preview of each.
+    Edges are ordered by their number of sites.
+
+    A symbol is looked up by ID when the argument contains ":", otherwise
+    by exact name.
+
+OPTIONS:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+EXAMPLES:
+    # Where is Search called?
+    bcindex refs -type calls Search
+
+    # Functions called by a method
+    bcindex refs -type calls -direction outgoing "github.com/acme/app/internal/store:method:VectorStore.Search"
+
+    # Types implementing an interface, as JSON
+    bcindex refs -type implements -json Store
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Failed to parse arguments: %v", err)
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Error: symbol ID or name is required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	query := retrieval.RefQuery{
+		PackagePath: packagePath,
+		EdgeType:    edgeType,
+		Direction:   direction,
+		Limit:       topK,
+	}
+	if arg := fs.Arg(0); strings.Contains(arg, ":") {
+		query.SymbolID = arg
+	} else {
+		query.SymbolName = arg
+	}
+
+	idx, err := indexer.NewIndexer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create indexer: %v", err)
+	}
+	defer idx.Close()
+
+	symbolStore, _, edgeStore, _ := idx.GetStores()
+	finder := retrieval.NewRefFinder(symbolStore, edgeStore, cfg.Repo.Path)
+
+	result, err := finder.Find(query)
+	if err != nil {
+		log.Fatalf("Failed to find references: %v", err)
+	}
+
+	if jsonOutput {
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal references: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return
+	}
+
+	outputRefs(result, fs.Arg(0))
+}
+
+// outputRefs outputs references as human-readable text, one block per edge
+func outputRefs(result *retrieval.RefResult, target string) {
+	if len(result.Symbols) == 0 {
+		fmt.Printf("No symbol found for: %s\n", target)
+		return
+	}
+	if len(result.Refs) == 0 {
+		fmt.Printf("No %s edges found for: %s\n", result.Direction, target)
+		return
+	}
+
+	fmt.Printf("Found %d %s edge(s) for: %s\n\n", len(result.Refs), result.Direction, target)
+
+	for i, ref := range result.Refs {
+		fmt.Printf("%d. %s --%s--> %s", i+1, ref.From.ID, ref.Edge.EdgeType, ref.To.ID)
+		if ref.Edge.Count > 1 {
+			fmt.Printf(" (%d sites)", ref.Edge.Count)
+		}
+		if ref.Edge.Provenance != "" && ref.Edge.Provenance != "static" {
+			fmt.Printf(" [%s]", ref.Edge.Provenance)
+		}
+		fmt.Println()
+
+		for _, site := range ref.Sites {
+			fmt.Printf("   %s:%d:%d", site.FilePath, site.Line, site.Column)
+			if site.Preview != "" {
+				fmt.Printf("  %s", site.Preview)
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/DreamCats/bcindex/cmd/bcindex/internal"
 	"github.com/DreamCats/bcindex/internal/config"
 	"github.com/DreamCats/bcindex/internal/indexer"
+	"github.com/DreamCats/bcindex/internal/rerank"
 	"github.com/DreamCats/bcindex/internal/retrieval"
+	"github.com/DreamCats/bcindex/internal/store"
 )
 
 // handleSearch implements the search subcommand
@@ -20,6 +24,8 @@ func handleSearch(cfg *config.Config, args []string) {
 	var topK int
 	var vectorOnly, keywordOnly, jsonOutput, verbose bool
 	var includeUnexported bool
+	var packagePath string
+	var kinds, contexts internal.StringList
 
 	fs.IntVar(&topK, "k", 10, "Number of results to return")
 	fs.BoolVar(&vectorOnly, "vector-only", false, "Use vector search only")
@@ -27,6 +33,9 @@ func handleSearch(cfg *config.Config, args []string) {
 	fs.BoolVar(&jsonOutput, "json", false, "Output results as JSON")
 	fs.BoolVar(&verbose, "v", false, "Verbose output (show scores and reasons)")
 	fs.BoolVar(&includeUnexported, "all", false, "Include unexported symbols")
+	fs.StringVar(&packagePath, "package", "", "Only search this package and its sub-packages (e.g. internal/store)")
+	fs.Var(&kinds, "kind", "Only return symbols of this kind (repeatable: func, method, struct, interface, type, const, var, field, test)")
+	fs.Var(&contexts, "context", "Only return symbols compiled in this build context (repeatable, see indexer.build_contexts)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `USAGE:
@@ -36,6 +45,17 @@ DESCRIPTION:
     Search for code using natural language queries.
     Supports hybrid search combining vector similarity and keyword matching.
 
+QUERY SYNTAX:
+    Qualifiers in the query narrow the results; the remaining words are
+    searched. A query of qualifiers only lists the matching symbols.
+
+    kind:method,func        Symbol kinds (-kind: excludes)
+    recv:*VectorStore       Methods of a receiver type (* for pointer receivers)
+    pkg:internal/store/...  Package and sub-packages (-pkg: excludes)
+    file:*_test.go          File name or path glob, dir/... for a tree (-file: excludes)
+    calls:EmbedBatch        Callers of a symbol (Name, Type.Method, pkg.Name)
+    implements:pkg.Iface    Implementations of an interface
+
 OPTIONS:
 `)
 		fs.PrintDefaults()
@@ -45,19 +65,33 @@ EXAMPLES:
     bcindex search "function to create order"
 
     # Keyword-only search
-    bcindex search "CreateOrder" -keyword-only
+    bcindex search -keyword-only "CreateOrder"
 
     # Get top 20 results
-    bcindex search "database connection" -k 20
+    bcindex search -k 20 "database connection"
 
     # JSON output for scripting
-    bcindex search "error handling" -json
+    bcindex search -json "error handling"
 
     # Verbose output with scores
-    bcindex search "order status" -v
+    bcindex search -v "order status"
 
     # Include unexported symbols
-    bcindex search "outputJSON" -all
+    bcindex search -all "outputJSON"
+
+    # Restrict to a package subtree and symbol kind
+    bcindex search -package internal/store -kind method "insert vectors"
+
+    # Constants, variables and struct fields
+    bcindex search -kind const -kind var -kind field "default batch size"
+
+    # Symbols compiled on Windows (requires indexer.build_contexts)
+    bcindex search -context windows "open file"
+
+    # Structured queries
+    bcindex search "kind:method recv:*VectorStore search"
+    bcindex search "calls:EmbedBatch -pkg:cmd/..."
+    bcindex search "implements:embedding.Client"
 `)
 	}
 
@@ -72,7 +106,20 @@ EXAMPLES:
 		os.Exit(1)
 	}
 
+	// Flags after the query are not parsed; reject them instead of
+	// searching for them
+	if fs.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "Error: unexpected arguments after the query: %s\n", strings.Join(fs.Args()[1:], " "))
+		fmt.Fprintf(os.Stderr, "Options must come before the query; quote queries of several words\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
 	query := fs.Arg(0)
+	parsed, err := retrieval.ParseQuery(query)
+	if err != nil {
+		log.Fatalf("Invalid query: %v", err)
+	}
 
 	// Create indexer and retriever
 	idx, err := indexer.NewIndexer(cfg)
@@ -95,13 +142,26 @@ EXAMPLES:
 		idx.GetEmbedService(),
 		expander,
 	)
+	configureReranker(cfg, idx, retriever)
 
 	// Configure search options
 	opts := retrieval.DefaultSearchOptions()
 	opts.TopK = topK
+	opts.RepoPath = cfg.Repo.Path
+	opts.Fusion = cfg.Search.Fusion
+	opts.RRFK = cfg.Search.RRFK
+	opts.KeywordColumns = store.BM25Weights{
+		Name:         cfg.Search.BM25NameWeight,
+		SemanticText: cfg.Search.BM25TextWeight,
+		Tokens:       cfg.Search.BM25TokensWeight,
+	}
+	opts.PackagePath = packagePath
+	opts.Kinds = kinds
+	opts.BuildContexts = contexts
 	if includeUnexported {
 		opts.ExportedOnly = false
 	}
+	parsed.Apply(&opts)
 
 	if vectorOnly {
 		opts.VectorWeight = 1.0
@@ -115,7 +175,7 @@ EXAMPLES:
 
 	// Perform search
 	ctx := context.Background()
-	results, err := retriever.Search(ctx, query, opts)
+	results, err := retriever.Search(ctx, parsed.Text, opts)
 	if err != nil {
 		log.Fatalf("Search failed: %v", err)
 	}
@@ -128,6 +188,29 @@ EXAMPLES:
 	}
 }
 
+// configureReranker enables the rerank stage of the retriever when a rerank
+// provider is configured
+func configureReranker(cfg *config.Config, idx *indexer.Indexer, retriever *retrieval.HybridRetriever) {
+	if cfg.Search.Rerank.Provider == "" {
+		return
+	}
+
+	client, err := rerank.NewClient(&cfg.Search.Rerank)
+	if err != nil {
+		log.Printf("Warning: rerank disabled: %v", err)
+		return
+	}
+
+	var cache retrieval.RerankCache
+	if !cfg.Search.Rerank.DisableCache {
+		cache = idx.GetRerankCache()
+	}
+	retriever.SetReranker(client, cache, retrieval.RerankOptions{
+		TopN:   cfg.Search.Rerank.TopN,
+		Budget: cfg.Search.Rerank.Timeout,
+	})
+}
+
 // outputText outputs search results as human-readable text
 func outputText(results []retrieval.SearchResult, query string, verbose bool) {
 	if len(results) == 0 {
@@ -142,6 +225,12 @@ func outputText(results []retrieval.SearchResult, query string, verbose bool) {
 		fmt.Printf("   Kind:    %s\n", result.Symbol.Kind)
 		fmt.Printf("   Package: %s\n", result.Symbol.PackagePath)
 		fmt.Printf("   File:    %s:%d\n", result.Symbol.FilePath, result.Symbol.LineStart)
+		if result.MatchedLines != nil {
+			fmt.Printf("   Match:   %s:%d-%d\n", result.Symbol.FilePath, result.MatchedLines.Start, result.MatchedLines.End)
+		}
+		if len(result.Symbol.BuildContexts) > 0 {
+			fmt.Printf("   Contexts: %s\n", strings.Join(result.Symbol.BuildContexts, ", "))
+		}
 
 		if verbose {
 			if result.VectorScore > 0 {
@@ -153,6 +242,9 @@ func outputText(results []retrieval.SearchResult, query string, verbose bool) {
 			if result.GraphScore > 0 {
 				fmt.Printf("   Graph:   %.3f\n", result.GraphScore)
 			}
+			if result.RerankScore > 0 {
+				fmt.Printf("   Rerank:  %.3f\n", result.RerankScore)
+			}
 			fmt.Printf("   Score:   %.3f\n", result.CombinedScore)
 
 			if len(result.Reason) > 0 {
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DreamCats/bcindex/cmd/bcindex/internal"
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/indexer"
+	"github.com/DreamCats/bcindex/internal/retrieval"
+)
+
+// fileLinePattern matches a file:line argument
+var fileLinePattern = regexp.MustCompile(`^(.+\.go):(\d+)$`)
+
+// handleSimilar implements the similar subcommand
+func handleSimilar(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("similar", flag.ExitOnError)
+
+	var topK int
+	var otherPackages, jsonOutput bool
+	var snippet, snippetFile string
+	var kinds internal.StringList
+
+	fs.IntVar(&topK, "k", 10, "Number of neighbors to return")
+	fs.BoolVar(&otherPackages, "other-packages", false, "Skip neighbors in the symbol's own package")
+	fs.BoolVar(&jsonOutput, "json", false, "Output results as JSON")
+	fs.StringVar(&snippet, "snippet", "", "Find code similar to this snippet instead of a symbol")
+	fs.StringVar(&snippetFile, "snippet-file", "", "Read the snippet from a file (- for stdin)")
+	fs.Var(&kinds, "kind", "Only return neighbors of this kind (repeatable; default: kinds comparable to the symbol)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `USAGE:
+    bcindex similar [options] <symbol-id|file:line>
+    bcindex similar [options] -snippet "<code>"
+
+DESCRIPTION:
+    Find code similar to an indexed symbol or to a snippet, ranked by the
+    cosine similarity of their embeddings. The symbol itself is never listed.
+
+    A file:line argument selects the innermost declaration at that line.
+    Functions are compared with functions and methods, types with types.
+
+OPTIONS:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+EXAMPLES:
+    # Other implementations like a method, outside its package
+    bcindex similar -other-packages "github.com/acme/app/internal/embedding:method:VolcEngineClient.EmbedBatch"
+
+    # The declaration at a line
+    bcindex similar internal/store/vectors.go:215
+
+    # Duplicates of a pasted block
+    pbpaste | bcindex similar -snippet-file -
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Failed to parse arguments: %v", err)
+	}
+
+	if snippetFile != "" {
+		content, err := readSnippet(snippetFile)
+		if err != nil {
+			log.Fatalf("Failed to read snippet: %v", err)
+		}
+		snippet = content
+	}
+	if fs.NArg() < 1 && strings.TrimSpace(snippet) == "" {
+		fmt.Fprintf(os.Stderr, "Error: symbol ID, file:line or snippet is required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	query := retrieval.SimilarQuery{
+		Snippet:            snippet,
+		RepoPath:           cfg.Repo.Path,
+		Kinds:              kinds,
+		ExcludeSamePackage: otherPackages,
+		Limit:              topK,
+	}
+	target := "snippet"
+	if fs.NArg() > 0 {
+		target = fs.Arg(0)
+		if m := fileLinePattern.FindStringSubmatch(target); m != nil {
+			query.FilePath = repoRelative(cfg.Repo.Path, m[1])
+			query.Line, _ = strconv.Atoi(m[2])
+		} else {
+			query.SymbolID = target
+		}
+	}
+
+	idx, err := indexer.NewIndexer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create indexer: %v", err)
+	}
+	defer idx.Close()
+
+	symbolStore, _, _, vectorStore := idx.GetStores()
+	finder := retrieval.NewSimilarFinder(vectorStore, symbolStore, idx.GetEmbedService())
+
+	result, err := finder.Find(context.Background(), query)
+	if err != nil {
+		log.Fatalf("Failed to find similar code: %v", err)
+	}
+
+	if jsonOutput {
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal results: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return
+	}
+
+	outputSimilar(result, target)
+}
+
+// readSnippet reads a snippet from a file, or from stdin for "-"
+func readSnippet(path string) (string, error) {
+	if path == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		return string(content), err
+	}
+	content, err := os.ReadFile(path)
+	return string(content), err
+}
+
+// repoRelative returns a file path relative to the repository root, as
+// symbols store it
+func repoRelative(repoPath, path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(repoPath, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// outputSimilar outputs the neighbors of a symbol or snippet as
+// human-readable text
+func outputSimilar(result *retrieval.SimilarResult, target string) {
+	if result.Source != nil {
+		target = result.Source.ID
+	}
+	if len(result.Neighbors) == 0 {
+		fmt.Printf("No similar code found for: %s\n", target)
+		return
+	}
+
+	fmt.Printf("Found %d symbol(s) similar to: %s\n\n", len(result.Neighbors), target)
+
+	for i, neighbor := range result.Neighbors {
+		sym := neighbor.Symbol
+		fmt.Printf("%d. %s  (%.3f)\n", i+1, sym.Name, neighbor.Similarity)
+		fmt.Printf("   Kind:    %s\n", sym.Kind)
+		fmt.Printf("   Package: %s\n", sym.PackagePath)
+		fmt.Printf("   File:    %s:%d\n", sym.FilePath, sym.LineStart)
+		if sym.Signature != "" {
+			fmt.Printf("   %s\n", sym.Signature)
+		}
+		fmt.Println()
+	}
+}
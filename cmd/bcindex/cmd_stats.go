@@ -9,6 +9,7 @@ import (
 
 	"github.com/DreamCats/bcindex/internal/config"
 	"github.com/DreamCats/bcindex/internal/indexer"
+	"github.com/DreamCats/bcindex/internal/store"
 )
 
 // handleStats implements the stats subcommand
@@ -55,6 +56,14 @@ EXAMPLES:
 	packageCount, _ := packageStore.Count()
 	edgeCount, _ := edgeStore.Count()
 	vectorCount, _ := vectorStore.Count()
+	chunkCount, _ := vectorStore.CountChunks()
+
+	var cacheStats *store.EmbeddingCacheStats
+	if cache := idx.GetEmbeddingCache(); cache != nil {
+		if stats, err := cache.Stats(); err == nil {
+			cacheStats = &stats
+		}
+	}
 
 	if jsonOutput {
 		stats := map[string]interface{}{
@@ -62,6 +71,15 @@ EXAMPLES:
 			"packages":   packageCount,
 			"edges":      edgeCount,
 			"embeddings": vectorCount,
+			"chunks":     chunkCount,
+		}
+		if cacheStats != nil {
+			stats["embedding_cache"] = map[string]interface{}{
+				"entries":   cacheStats.Entries,
+				"hits":      cacheStats.Hits,
+				"misses":    cacheStats.Misses,
+				"hit_ratio": cacheStats.HitRatio(),
+			}
 		}
 		jsonData, _ := json.MarshalIndent(stats, "", "  ")
 		fmt.Println(string(jsonData))
@@ -72,5 +90,29 @@ EXAMPLES:
 		fmt.Printf("Symbols:    %6d\n", symbolCount)
 		fmt.Printf("Edges:      %6d\n", edgeCount)
 		fmt.Printf("Embeddings: %6d\n", vectorCount)
+		if chunkCount > 0 {
+			fmt.Printf("Chunks:     %6d\n", chunkCount)
+		}
+		if cacheStats != nil {
+			fmt.Println(formatEmbeddingCacheStats(*cacheStats))
+		}
 	}
 }
+
+// printEmbeddingCacheStats prints the embedding cache size and hit ratio
+func printEmbeddingCacheStats(cache *store.EmbeddingCache, indent string) {
+	if cache == nil {
+		return
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		log.Printf("Warning: failed to read embedding cache stats: %v", err)
+		return
+	}
+	fmt.Println(indent + formatEmbeddingCacheStats(stats))
+}
+
+func formatEmbeddingCacheStats(stats store.EmbeddingCacheStats) string {
+	return fmt.Sprintf("Embedding cache: %d vectors, hit ratio %.1f%% (%d hits / %d lookups)",
+		stats.Entries, stats.HitRatio()*100, stats.Hits, stats.Hits+stats.Misses)
+}
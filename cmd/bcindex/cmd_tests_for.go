@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/indexer"
+	"github.com/DreamCats/bcindex/internal/retrieval"
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// handleTestsFor implements the tests-for subcommand
+func handleTestsFor(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("tests-for", flag.ExitOnError)
+
+	var topK int
+	var packagePath string
+	var jsonOutput bool
+
+	fs.IntVar(&topK, "k", 0, "Maximum number of tests to return (0 for all)")
+	fs.StringVar(&packagePath, "package", "", "Only match symbols of this package (when looking up by name)")
+	fs.BoolVar(&jsonOutput, "json", false, "Output results as JSON")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `USAGE:
+    bcindex tests-for [options] <symbol-id|name>
+
+DESCRIPTION:
+    List the tests, benchmarks, fuzz tests and examples that call a symbol,
+    directly or through test helpers, with the sites of the calls.
+    Requires an index built with indexer.tests enabled.
+
+    A symbol is looked up by ID when the argument contains ":", otherwise
+    by exact name.
+
+OPTIONS:
+`)
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+EXAMPLES:
+    # Which tests cover Search?
+    bcindex tests-for Search
+
+    # Tests of a method, as JSON
+    bcindex tests-for -json "github.com/acme/app/internal/store:method:VectorStore.Search"
+`)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Failed to parse arguments: %v", err)
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Error: symbol ID or name is required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	query := retrieval.RefQuery{
+		PackagePath: packagePath,
+		EdgeType:    store.EdgeTypeTests,
+		Direction:   retrieval.DirectionIncoming,
+		Limit:       topK,
+	}
+	if arg := fs.Arg(0); strings.Contains(arg, ":") {
+		query.SymbolID = arg
+	} else {
+		query.SymbolName = arg
+	}
+
+	idx, err := indexer.NewIndexer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create indexer: %v", err)
+	}
+	defer idx.Close()
+
+	symbolStore, _, edgeStore, _ := idx.GetStores()
+	finder := retrieval.NewRefFinder(symbolStore, edgeStore, cfg.Repo.Path)
+
+	result, err := finder.Find(query)
+	if err != nil {
+		log.Fatalf("Failed to find tests: %v", err)
+	}
+
+	if jsonOutput {
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal tests: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return
+	}
+
+	outputTests(result, fs.Arg(0))
+}
+
+// outputTests outputs the tests of a symbol as human-readable text
+func outputTests(result *retrieval.RefResult, target string) {
+	if len(result.Symbols) == 0 {
+		fmt.Printf("No symbol found for: %s\n", target)
+		return
+	}
+	if len(result.Refs) == 0 {
+		fmt.Printf("No tests found for: %s\n", target)
+		return
+	}
+
+	fmt.Printf("Found %d test(s) for: %s\n\n", len(result.Refs), target)
+
+	for i, ref := range result.Refs {
+		fmt.Printf("%d. %s", i+1, ref.From.Name)
+		if ref.From.FilePath != "" {
+			fmt.Printf("  %s:%d", ref.From.FilePath, ref.From.LineStart)
+		}
+		if len(result.Symbols) > 1 {
+			fmt.Printf("  -> %s", ref.To.ID)
+		}
+		fmt.Println()
+
+		for _, site := range ref.Sites {
+			fmt.Printf("   %s:%d:%d", site.FilePath, site.Line, site.Column)
+			if site.Preview != "" {
+				fmt.Printf("  %s", site.Preview)
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+}
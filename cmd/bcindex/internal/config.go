@@ -26,7 +26,7 @@ func PrintConfigExample() {
 
 # Embedding service configuration (required)
 embedding:
-  # Provider: "volcengine" | "openai"
+  # Provider: "volcengine" | "openai" | "openai_compatible" | "local"
   provider: volcengine
 
   # VolcEngine configuration
@@ -38,6 +38,8 @@ embedding:
   dimensions: 2048              # 1024 or 2048
   batch_size: 10                # Batch size for embedding requests
   encoding_format: float        # "float" or "base64"
+  # max_retries: 5              # Retries on 429/5xx/network errors
+  # requests_per_minute: 0      # Client-side rate limit (0 = unlimited)
 
 # Database configuration
 # Database is stored per-repository under ~/.bcindex/data/
@@ -49,6 +51,17 @@ embedding:
 #   openai_model: text-embedding-3-small
 #   dimensions: 1536
 
+# For a self-hosted OpenAI-compatible server (Ollama, vLLM, ...), use:
+# embedding:
+#   provider: openai_compatible
+#   base_url: http://localhost:11434/v1
+#   model: nomic-embed-text
+
+# For offline use without any API, use:
+# embedding:
+#   provider: local
+#   dimensions: 1024
+
 Usage:
   1. Create the config file
   2. Navigate to your Go project: cd /path/to/project
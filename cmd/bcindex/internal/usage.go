@@ -41,6 +41,15 @@ COMMANDS:
     evidence
         Search and return LLM-friendly evidence pack (JSON)
 
+    refs
+        List callers, references and other edges of a symbol with their sites
+
+    tests-for
+        List the tests covering a symbol (requires indexer.tests)
+
+    similar
+        Find code similar to a symbol, a file:line or a snippet
+
     stats
         Show index statistics
 
@@ -50,6 +59,12 @@ COMMANDS:
     docgen
         Generate documentation for Go code using LLM
 
+    db
+        Maintain the index database (migrate, status)
+
+    debug
+        Inspect the index (embed-text: show the text embedded for a symbol)
+
 EXAMPLES:
     # Index current directory
     bcindex index
@@ -61,11 +76,20 @@ EXAMPLES:
     bcindex search "order status change"
 
     # Search with vector-only mode
-    bcindex search "database connection" -vector-only
+    bcindex search -vector-only "database connection"
 
     # Get evidence pack for LLM
     bcindex evidence "implement idempotent API" -output evidence.json
 
+    # Where is a function called?
+    bcindex refs -type calls Search
+
+    # Which tests cover a function?
+    bcindex tests-for Search
+
+    # Code similar to the declaration at a line
+    bcindex similar internal/store/vectors.go:215
+
     # Show statistics
     bcindex stats
 
@@ -75,6 +99,12 @@ EXAMPLES:
     # Generate documentation (dry run)
     bcindex docgen --dry-run
 
+    # Preview pending database migrations
+    bcindex db migrate -dry-run
+
+    # Show the text embedded for a symbol
+    bcindex debug embed-text "<symbol-id>"
+
 For detailed help on each command, use:
     bcindex <command> -help
 `, Version)
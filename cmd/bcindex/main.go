@@ -37,12 +37,17 @@ func main() {
 
 	// Find the subcommand (first non-flag argument that is a valid subcommand)
 	validSubcommands := map[string]bool{
-		"index":    true,
-		"search":   true,
-		"evidence": true,
-		"stats":    true,
-		"mcp":      true,
-		"docgen":   true,
+		"index":     true,
+		"search":    true,
+		"evidence":  true,
+		"refs":      true,
+		"tests-for": true,
+		"similar":   true,
+		"stats":     true,
+		"mcp":       true,
+		"docgen":    true,
+		"db":        true,
+		"debug":     true,
 	}
 
 	subcommandIndex := -1
@@ -151,12 +156,22 @@ func main() {
 		handleSearch(cfg, subcommandArgs)
 	case "evidence":
 		handleEvidence(cfg, subcommandArgs)
+	case "refs":
+		handleRefs(cfg, subcommandArgs)
+	case "tests-for":
+		handleTestsFor(cfg, subcommandArgs)
+	case "similar":
+		handleSimilar(cfg, subcommandArgs)
 	case "stats":
 		handleStats(cfg, subcommandArgs)
 	case "mcp":
 		handleMCP(cfg, repoRoot, subcommandArgs)
 	case "docgen":
 		handleDocGen(cfg, repoRoot, subcommandArgs)
+	case "db":
+		handleDB(cfg, subcommandArgs)
+	case "debug":
+		handleDebug(cfg, subcommandArgs)
 	default:
 		fmt.Printf("Unknown subcommand: %s\n\n", subcommand)
 		internal.PrintUsage()
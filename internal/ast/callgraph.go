@@ -0,0 +1,158 @@
+package ast
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Call graph modes
+const (
+	CallGraphAST = ""    // per-package walk of call expressions
+	CallGraphCHA = "cha" // class hierarchy analysis over SSA
+	CallGraphVTA = "vta" // variable type analysis over SSA
+)
+
+// Edge provenance: how an edge was found
+const (
+	ProvenanceStatic = "static" // from the source or the type checker
+	ProvenanceCHA    = "cha"
+	ProvenanceVTA    = "vta"
+)
+
+// CallGraphBuilder adds the call edges of a whole-program call graph to the
+// ones found by walking call expressions. It resolves calls through function
+// values, method values, closures and interfaces across packages.
+type CallGraphBuilder struct {
+	pkgs     []*packages.Package
+	repoPath string
+	symbols  map[string]*ExtractedSymbol
+	mode     string
+	fset     *token.FileSet
+
+	// Changed limits the edges to those with a side in one of these
+	// packages; nil keeps every edge
+	Changed map[string]bool
+}
+
+// NewCallGraphBuilder creates a builder over the loaded packages; symbols must
+// hold the extracted symbols of all of them
+func NewCallGraphBuilder(pkgs []*packages.Package, repoPath string, symbols []*ExtractedSymbol, mode string) *CallGraphBuilder {
+	return &CallGraphBuilder{
+		pkgs:     pkgs,
+		repoPath: repoPath,
+		symbols:  symbolMap(symbols),
+		mode:     mode,
+	}
+}
+
+// Build returns the call edges of sites not already in known. Edges of call
+// sites with a single static callee get static provenance, the others the
+// mode's.
+func (b *CallGraphBuilder) Build(known []*Edge) (edges []*Edge, err error) {
+	var provenance string
+	switch b.mode {
+	case CallGraphCHA:
+		provenance = ProvenanceCHA
+	case CallGraphVTA:
+		provenance = ProvenanceVTA
+	default:
+		return nil, fmt.Errorf("unknown call graph mode: %s", b.mode)
+	}
+
+	// The SSA builder panics on syntax it does not support (e.g. from a
+	// newer Go release than golang.org/x/tools)
+	defer func() {
+		if r := recover(); r != nil {
+			edges, err = nil, fmt.Errorf("failed to build SSA: %v", r)
+		}
+	}()
+
+	// Only function bodies of the repository are built; dependencies are
+	// known by their types. Building serially lets the recover above see
+	// builder panics.
+	prog, _ := ssautil.Packages(b.pkgs, ssa.InstantiateGenerics|ssa.BuildSerially)
+	prog.Build()
+	b.fset = prog.Fset
+
+	graph := cha.CallGraph(prog)
+	if b.mode == CallGraphVTA {
+		graph = vta.CallGraph(ssautil.AllFunctions(prog), graph)
+	}
+
+	// Sites are matched by line: SSA positions a call at its parenthesis,
+	// the AST walk at the called name
+	seen := make(map[string]bool)
+	for _, edge := range known {
+		if edge.EdgeType == "calls" {
+			seen[fmt.Sprintf("%s|%s|%s:%d", edge.FromID, edge.ToID, edge.FilePath, edge.Line)] = true
+		}
+	}
+
+	err = callgraph.GraphVisitEdges(graph, func(e *callgraph.Edge) error {
+		if e.Site == nil {
+			return nil
+		}
+
+		fromSym := b.symbolOf(e.Caller.Func)
+		toSym := b.symbolOf(e.Callee.Func)
+		if fromSym == nil || toSym == nil || fromSym.ID == toSym.ID {
+			return nil
+		}
+		if b.Changed != nil && !b.Changed[fromSym.PackagePath] && !b.Changed[toSym.PackagePath] {
+			return nil
+		}
+
+		edge := &Edge{
+			FromID:     fromSym.ID,
+			ToID:       toSym.ID,
+			EdgeType:   "calls",
+			Weight:     5, // Medium weight for calls
+			Provenance: ProvenanceStatic,
+		}
+		if pos := prog.Fset.Position(e.Site.Pos()); pos.IsValid() {
+			edge.FilePath = toRelPath(b.repoPath, pos.Filename)
+			edge.Line = pos.Line
+			edge.Column = pos.Column
+		}
+
+		key := fmt.Sprintf("%s|%s|%s:%d", edge.FromID, edge.ToID, edge.FilePath, edge.Line)
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+		if e.Site.Common().StaticCallee() == nil {
+			edge.Weight = 3 // Inferred calls may over-approximate
+			edge.Provenance = provenance
+		}
+		edges = append(edges, edge)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk call graph: %w", err)
+	}
+
+	return edges, nil
+}
+
+// symbolOf returns the symbol of a function, attributing closures to the
+// declared function they appear in and instances to their generic origin
+func (b *CallGraphBuilder) symbolOf(fn *ssa.Function) *ExtractedSymbol {
+	for fn != nil {
+		if origin := fn.Origin(); origin != nil {
+			fn = origin
+		}
+		if obj, ok := fn.Object().(*types.Func); ok {
+			return b.symbols[declSymbolID(obj, b.fset, b.repoPath)]
+		}
+		fn = fn.Parent()
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package ast
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const callGraphFixture = `package app
+
+type Store interface {
+	Save(key string) error
+}
+
+type memStore struct{}
+
+func (m *memStore) Save(key string) error { return nil }
+
+type Service struct {
+	store  Store
+	notify func(string)
+}
+
+func NewService() *Service {
+	return &Service{store: &memStore{}, notify: logEvent}
+}
+
+func logEvent(msg string) {}
+
+func flush() {}
+
+// Interface call
+func (s *Service) Put(key string) error {
+	return s.store.Save(key)
+}
+
+// Closure
+func (s *Service) Each(keys []string) {
+	visit := func(k string) {
+		validate(k)
+	}
+	for _, k := range keys {
+		visit(k)
+	}
+}
+
+func validate(k string) {}
+
+// Method value
+func (s *Service) Handler() func(string) error {
+	return s.Put
+}
+
+func Run(s *Service) {
+	put := s.Handler()
+	_ = put("k")
+
+	// Function-typed field
+	s.notify("started")
+
+	// Goroutine launch
+	go flush()
+}
+`
+
+func extractCallGraphFixture(t *testing.T, mode string) map[string]*Edge {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module testrepo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "app"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "app", "app.go"), []byte(callGraphFixture), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	p := NewPipeline()
+	p.SetCallGraph(mode)
+	_, edges, err := p.ExtractRepositoryWithRelations(tmpDir)
+	if err != nil {
+		t.Fatalf("ExtractRepositoryWithRelations failed: %v", err)
+	}
+
+	calls := make(map[string]*Edge)
+	for _, edge := range edges {
+		if edge.EdgeType == "calls" {
+			calls[edge.FromID+" -> "+edge.ToID] = edge
+		}
+	}
+	return calls
+}
+
+func TestCallGraphBuilder(t *testing.T) {
+	const pkg = "testrepo/app:"
+
+	for _, mode := range []string{CallGraphCHA, CallGraphVTA} {
+		t.Run(mode, func(t *testing.T) {
+			calls := extractCallGraphFixture(t, mode)
+
+			tests := []struct {
+				from, to   string
+				provenance string
+			}{
+				// Found by walking call expressions as well
+				{"method:Service.Put", "interface-method:Store.Save", ""},
+				{"func:Run", "func:flush", ""},
+				// Calls inside closures belong to the enclosing function
+				{"method:Service.Each", "func:validate", ""},
+				// Resolved dynamic calls
+				{"method:Service.Put", "method:memStore.Save", mode},
+				{"func:Run", "method:Service.Put", mode},
+				{"func:Run", "func:logEvent", mode},
+			}
+			for _, tt := range tests {
+				edge, ok := calls[pkg+tt.from+" -> "+pkg+tt.to]
+				if !ok {
+					t.Errorf("missing call edge %s -> %s", tt.from, tt.to)
+					continue
+				}
+				if edge.Provenance != tt.provenance {
+					t.Errorf("%s -> %s: provenance = %q, want %q", tt.from, tt.to, edge.Provenance, tt.provenance)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package ast
+
+import "fmt"
+
+// BuildContext is a set of build tags and a target platform to extract the
+// repository under
+type BuildContext struct {
+	Name   string   // Recorded on the symbols of the context
+	GOOS   string   // Target operating system (empty for the host's)
+	GOARCH string   // Target architecture (empty for the host's)
+	Tags   []string // Build tags
+}
+
+// contextMerge unions the symbols and edges extracted under several build
+// contexts
+type contextMerge struct {
+	symbols []*ExtractedSymbol
+	edges   []*Edge
+
+	byID      map[string]*ExtractedSymbol
+	seenEdges map[string]bool
+}
+
+func newContextMerge() *contextMerge {
+	return &contextMerge{
+		byID:      make(map[string]*ExtractedSymbol),
+		seenEdges: make(map[string]bool),
+	}
+}
+
+// add merges the result of a context. A symbol declared in several
+// contexts keeps its declaration from the first one, e.g. the file of the
+// first platform for a function with a file per platform.
+func (m *contextMerge) add(name string, symbols []*ExtractedSymbol, edges []*Edge) {
+	for _, sym := range symbols {
+		if first := m.byID[sym.ID]; first != nil {
+			first.BuildContexts = append(first.BuildContexts, name)
+			continue
+		}
+		sym.BuildContexts = []string{name}
+		m.byID[sym.ID] = sym
+		m.symbols = append(m.symbols, sym)
+	}
+
+	for _, edge := range edges {
+		key := fmt.Sprintf("%s|%s|%s|%s|%s:%d:%d", edge.EdgeType, edge.FromID, edge.ToID, edge.ImportPath, edge.FilePath, edge.Line, edge.Column)
+		if m.seenEdges[key] {
+			continue
+		}
+		m.seenEdges[key] = true
+		m.edges = append(m.edges, edge)
+	}
+}
@@ -0,0 +1,87 @@
+package ast
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPipeline_BuildContexts(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module testrepo\n\ngo 1.21\n",
+		"fs/open.go": `package fs
+
+func Open(name string) error { return open(name) }
+`,
+		"fs/open_linux.go": `package fs
+
+func open(name string) error { return nil }
+
+func epoll() {}
+`,
+		"fs/open_windows.go": `package fs
+
+func open(name string) error { return nil }
+`,
+		"fs/seed.go": `//go:build integration
+
+package fs
+
+func Seed() {}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	p := NewPipeline()
+	p.SetBuildContexts([]BuildContext{
+		{Name: "linux", GOOS: "linux", GOARCH: "amd64"},
+		{Name: "windows", GOOS: "windows", GOARCH: "amd64"},
+		{Name: "integration", GOOS: "linux", GOARCH: "amd64", Tags: []string{"integration"}},
+	})
+	symbols, edges, err := p.ExtractRepositoryWithRelations(root)
+	if err != nil {
+		t.Fatalf("ExtractRepositoryWithRelations failed: %v", err)
+	}
+
+	byID := symbolMap(symbols)
+	for id, want := range map[string][]string{
+		"testrepo/fs:func:Open":  {"linux", "windows", "integration"},
+		"testrepo/fs:func:open":  {"linux", "windows", "integration"},
+		"testrepo/fs:func:epoll": {"linux", "integration"},
+		"testrepo/fs:func:Seed":  {"integration"},
+	} {
+		sym := byID[id]
+		if sym == nil {
+			t.Errorf("missing symbol %s", id)
+			continue
+		}
+		if !reflect.DeepEqual(sym.BuildContexts, want) {
+			t.Errorf("%s contexts = %v, want %v", id, sym.BuildContexts, want)
+		}
+	}
+
+	// A declaration per platform keeps the first context's
+	if sym := byID["testrepo/fs:func:open"]; sym != nil && sym.FilePath != "fs/open_linux.go" {
+		t.Errorf("open file = %s, want fs/open_linux.go", sym.FilePath)
+	}
+
+	calls := 0
+	for _, edge := range edges {
+		if edge.EdgeType == "calls" && edge.FromID == "testrepo/fs:func:Open" && edge.ToID == "testrepo/fs:func:open" {
+			calls++
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Open -> open calls edges = %d, want 1 across contexts", calls)
+	}
+}
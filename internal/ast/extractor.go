@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"path/filepath"
 	"strings"
 
@@ -16,6 +17,7 @@ type SymbolExtractor struct {
 	repoPath   string
 	symbols    []*ExtractedSymbol
 	fileInfo   map[string]*fileContext
+	positioned map[string]bool // positioned IDs given out
 }
 
 // ExtractedSymbol represents a symbol extracted from the AST
@@ -41,11 +43,27 @@ type ExtractedSymbol struct {
 	Receiver    string   // For methods: receiver type
 	FieldName   string   // For struct fields: field name
 	FieldType   string   // For struct fields: field type
+	ValueType   string   // For consts and vars: declared or inferred type
+	Value       string   // For consts and vars: initializer or constant value
+
+	// Generics
+	TypeParams []TypeParam // For generic funcs and types: type parameters
+
+	// Build contexts the symbol exists in, when extracted under several
+	BuildContexts []string
 
 	// Additional metadata
 	ParentID    string   // Parent symbol ID (for nested symbols)
 	Children    []string // Child symbol IDs
 	Imports     []string // Imported packages (for package-level)
+	Group       string   // For members of an enum-like const block: ID of its first member
+	Members     []string // For the first member of an enum-like const block: names of all members
+}
+
+// TypeParam is a type parameter of a generic function or type
+type TypeParam struct {
+	Name       string
+	Constraint string // e.g. "any", "~int | ~float64" or "Number"
 }
 
 // fileContext holds information about a file being processed
@@ -59,10 +77,11 @@ type fileContext struct {
 // NewSymbolExtractor creates a new symbol extractor for a package
 func NewSymbolExtractor(pkg *packages.Package, repoPath string) *SymbolExtractor {
 	return &SymbolExtractor{
-		pkg:      pkg,
-		repoPath: repoPath,
-		symbols:  make([]*ExtractedSymbol, 0),
-		fileInfo: make(map[string]*fileContext),
+		pkg:        pkg,
+		repoPath:   repoPath,
+		symbols:    make([]*ExtractedSymbol, 0),
+		fileInfo:   make(map[string]*fileContext),
+		positioned: make(map[string]bool),
 	}
 }
 
@@ -100,9 +119,12 @@ func (e *SymbolExtractor) Extract() ([]*ExtractedSymbol, error) {
 func (e *SymbolExtractor) extractPackageSymbol() error {
 	pkgID := e.packageID()
 
-	// Gather all imports
+	// Gather all imports, except those of tests
 	importSet := make(map[string]bool)
 	for _, astFile := range e.pkg.Syntax {
+		if isTestFile(e.pkg.Fset.Position(astFile.Pos()).Filename) {
+			continue
+		}
 		for _, imp := range astFile.Imports {
 			path, _ := strconvQuotes(imp.Path.Value)
 			importSet[path] = true
@@ -181,6 +203,7 @@ func (e *SymbolExtractor) extractFileSymbols(filePath string, ctx *fileContext)
 // Returns the number of symbols extracted
 func (e *SymbolExtractor) extractGenDecl(decl *ast.GenDecl, filePath string, ctx *fileContext) int {
 	count := 0
+	first := len(e.symbols)
 	for _, spec := range decl.Specs {
 		switch s := spec.(type) {
 		case *ast.TypeSpec:
@@ -212,9 +235,54 @@ func (e *SymbolExtractor) extractGenDecl(decl *ast.GenDecl, filePath string, ctx
 			}
 		}
 	}
+
+	if decl.Tok == token.CONST && isEnumBlock(decl) {
+		e.groupEnum(e.symbols[first:], decl, ctx)
+	}
 	return count
 }
 
+// isEnumBlock reports whether a const declaration is a parenthesized block
+// using iota, i.e. an enumeration
+func isEnumBlock(decl *ast.GenDecl) bool {
+	if !decl.Lparen.IsValid() || len(decl.Specs) < 2 {
+		return false
+	}
+	found := false
+	for _, spec := range decl.Specs {
+		if vs, ok := spec.(*ast.ValueSpec); ok {
+			for _, value := range vs.Values {
+				ast.Inspect(value, func(n ast.Node) bool {
+					if ident, ok := n.(*ast.Ident); ok && ident.Name == "iota" {
+						found = true
+					}
+					return !found
+				})
+			}
+		}
+	}
+	return found
+}
+
+// groupEnum makes the first member of an enumeration stand for the whole
+// block: it spans the block and lists the member names, and the other
+// members point to it
+func (e *SymbolExtractor) groupEnum(members []*ExtractedSymbol, decl *ast.GenDecl, ctx *fileContext) {
+	var lead *ExtractedSymbol
+	for _, sym := range members {
+		if sym.Name == "_" {
+			continue
+		}
+		if lead == nil {
+			lead = sym
+			lead.LineEnd = ctx.fset.Position(decl.End()).Line
+		} else {
+			sym.Group = lead.ID
+		}
+		lead.Members = append(lead.Members, sym.Name)
+	}
+}
+
 // extractTypeSpec extracts symbols from type specifications
 func (e *SymbolExtractor) extractTypeSpec(spec *ast.TypeSpec, decl *ast.GenDecl, filePath string, ctx *fileContext) *ExtractedSymbol {
 	startPos := ctx.fset.Position(spec.Pos())
@@ -223,6 +291,8 @@ func (e *SymbolExtractor) extractTypeSpec(spec *ast.TypeSpec, decl *ast.GenDecl,
 	var kind string
 	var signature string
 
+	typeParams := e.formatTypeParams(spec.TypeParams)
+
 	switch t := spec.Type.(type) {
 	case *ast.StructType:
 		kind = "struct"
@@ -234,11 +304,20 @@ func (e *SymbolExtractor) extractTypeSpec(spec *ast.TypeSpec, decl *ast.GenDecl,
 
 	default:
 		kind = "type"
-		signature = fmt.Sprintf("type %s", spec.Name.Name)
+		if spec.Assign.IsValid() {
+			signature = fmt.Sprintf("type %s%s = %s", spec.Name.Name, typeParams, e.typeToString(spec.Type))
+		} else {
+			signature = fmt.Sprintf("type %s%s %s", spec.Name.Name, typeParams, e.typeToString(spec.Type))
+		}
+	}
+
+	id := e.symbolID(filePath, kind, spec.Name.Name)
+	if needsPosition(kind, spec.Name.Name) {
+		id = e.positionedID(id, filePath, ctx.fset.Position(spec.Name.Pos()))
 	}
 
 	sym := &ExtractedSymbol{
-		ID:          e.symbolID(filePath, kind, spec.Name.Name),
+		ID:          id,
 		Name:        spec.Name.Name,
 		Kind:        kind,
 		PackagePath: e.pkg.PkgPath,
@@ -247,8 +326,9 @@ func (e *SymbolExtractor) extractTypeSpec(spec *ast.TypeSpec, decl *ast.GenDecl,
 		LineStart:   startPos.Line,
 		LineEnd:     endPos.Line,
 		Signature:   signature,
-		DocComment:  e.extractDocComment(decl.Doc),
+		DocComment:  e.specDocComment(spec.Doc, spec.Comment, decl),
 		Exported:    spec.Name != nil && spec.Name.IsExported(),
+		TypeParams:  e.typeParams(spec.TypeParams),
 		ParentID:    e.packageID(),
 	}
 
@@ -259,7 +339,7 @@ func (e *SymbolExtractor) extractTypeSpec(spec *ast.TypeSpec, decl *ast.GenDecl,
 
 	// Extract struct fields
 	if structType, ok := spec.Type.(*ast.StructType); ok {
-		sym.Children = e.extractStructFields(structType, spec.Name.Name, filePath, ctx)
+		sym.Children = e.extractStructFields(structType, spec.Name.Name, id, filePath, ctx)
 	}
 
 	return sym
@@ -290,8 +370,8 @@ func (e *SymbolExtractor) extractFuncDecl(decl *ast.FuncDecl, filePath string, c
 	} else {
 		id = e.symbolID(filePath, kind, decl.Name.Name)
 	}
-	if kind == "func" && decl.Name.Name == "init" {
-		id = fmt.Sprintf("%s@%s:%d", id, filePath, startPos.Line)
+	if needsPosition(kind, decl.Name.Name) {
+		id = e.positionedID(id, filePath, startPos)
 	}
 
 	sym := &ExtractedSymbol{
@@ -307,9 +387,15 @@ func (e *SymbolExtractor) extractFuncDecl(decl *ast.FuncDecl, filePath string, c
 		DocComment:  e.extractDocComment(decl.Doc),
 		Exported:    decl.Name != nil && decl.Name.IsExported(),
 		Receiver:    receiver,
+		TypeParams:  e.typeParams(decl.Type.TypeParams),
 		ParentID:    e.packageID(),
 	}
 
+	// Tests keep the ID of a function, so edges resolve to them
+	if kind == "func" && isTestFile(filePath) && isTestFunc(decl) {
+		sym.Kind = "test"
+	}
+
 	return sym
 }
 
@@ -318,13 +404,22 @@ func (e *SymbolExtractor) extractValueSpec(name *ast.Ident, spec *ast.ValueSpec,
 	startPos := ctx.fset.Position(name.Pos())
 	endPos := ctx.fset.Position(name.End())
 
-	var typeStr string
-	if spec.Type != nil {
-		typeStr = e.typeToString(spec.Type)
+	valueType, value := e.valueOf(name, spec)
+	signature := fmt.Sprintf("%s %s", kind, name.Name)
+	if valueType != "" {
+		signature += " " + valueType
+	}
+	if value != "" {
+		signature += " = " + value
+	}
+
+	id := e.symbolID(filePath, kind, name.Name)
+	if needsPosition(kind, name.Name) {
+		id = e.positionedID(id, filePath, startPos)
 	}
 
 	sym := &ExtractedSymbol{
-		ID:          e.symbolID(filePath, kind, name.Name),
+		ID:          id,
 		Name:        name.Name,
 		Kind:        kind,
 		PackagePath: e.pkg.PkgPath,
@@ -332,15 +427,63 @@ func (e *SymbolExtractor) extractValueSpec(name *ast.Ident, spec *ast.ValueSpec,
 		FilePath:    filePath,
 		LineStart:   startPos.Line,
 		LineEnd:     endPos.Line,
-		Signature:   fmt.Sprintf("%s %s %s", kind, name.Name, typeStr),
-		DocComment:  e.extractDocComment(decl.Doc),
+		Signature:   signature,
+		DocComment:  e.specDocComment(spec.Doc, spec.Comment, decl),
 		Exported:    name.IsExported(),
+		ValueType:   valueType,
+		Value:       value,
 		ParentID:    e.packageID(),
 	}
 
 	return sym
 }
 
+// maxValueLength is the length const and var values are shortened to
+const maxValueLength = 80
+
+// valueOf returns the type and value of a const or var. The initializer is
+// used as written, except for consts with an implicit or iota-based value,
+// which get their constant value. Long values are shortened to one line.
+func (e *SymbolExtractor) valueOf(name *ast.Ident, spec *ast.ValueSpec) (string, string) {
+	valueType := e.typeToString(spec.Type)
+
+	var value string
+	if len(spec.Values) == len(spec.Names) {
+		for i, n := range spec.Names {
+			if n == name {
+				value = types.ExprString(spec.Values[i])
+			}
+		}
+	}
+
+	if e.pkg.TypesInfo == nil {
+		return valueType, shortValue(value)
+	}
+	if obj, ok := e.pkg.TypesInfo.Defs[name].(*types.Const); ok {
+		if valueType == "" {
+			if basic, ok := obj.Type().(*types.Basic); !ok || basic.Info()&types.IsUntyped == 0 {
+				valueType = types.TypeString(obj.Type(), types.RelativeTo(e.pkg.Types))
+			}
+		}
+		if value == "" || strings.Contains(value, "iota") {
+			value = obj.Val().String()
+		}
+	}
+	return valueType, shortValue(value)
+}
+
+// shortValue collapses a multi-line value (such as a raw string) to one line
+// and truncates it to maxValueLength runes
+func shortValue(value string) string {
+	if strings.Contains(value, "\n") {
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	if runes := []rune(value); len(runes) > maxValueLength {
+		value = string(runes[:maxValueLength-3]) + "..."
+	}
+	return value
+}
+
 // extractInterfaceMethods extracts method symbols from an interface
 func (e *SymbolExtractor) extractInterfaceMethods(iface *ast.InterfaceType, interfaceName string, filePath string, ctx *fileContext) []string {
 	var children []string
@@ -373,7 +516,7 @@ func (e *SymbolExtractor) extractInterfaceMethods(iface *ast.InterfaceType, inte
 }
 
 // extractStructFields extracts field symbols from a struct
-func (e *SymbolExtractor) extractStructFields(structType *ast.StructType, structName string, filePath string, ctx *fileContext) []string {
+func (e *SymbolExtractor) extractStructFields(structType *ast.StructType, structName, structID string, filePath string, ctx *fileContext) []string {
 	var children []string
 
 	if structType.Fields == nil {
@@ -398,10 +541,11 @@ func (e *SymbolExtractor) extractStructFields(structType *ast.StructType, struct
 				LineStart:   ctx.fset.Position(field.Pos()).Line,
 				LineEnd:     ctx.fset.Position(field.End()).Line,
 				Signature:   fmt.Sprintf("%s %s", fieldName, fieldType),
-				DocComment:  e.extractDocComment(field.Doc),
+				DocComment:  e.fieldDocComment(field),
 				Exported:    name.IsExported(),
 				FieldName:   fieldName,
 				FieldType:   fieldType,
+				ParentID:    structID,
 			}
 			e.symbols = append(e.symbols, sym)
 			children = append(children, sym.ID)
@@ -420,10 +564,11 @@ func (e *SymbolExtractor) extractStructFields(structType *ast.StructType, struct
 				LineStart:   ctx.fset.Position(field.Pos()).Line,
 				LineEnd:     ctx.fset.Position(field.End()).Line,
 				Signature:   fmt.Sprintf("embedded %s", fieldType),
-				DocComment:  e.extractDocComment(field.Doc),
+				DocComment:  e.fieldDocComment(field),
 				Exported:    true,
 				FieldName:   "",
 				FieldType:   fieldType,
+				ParentID:    structID,
 			}
 			e.symbols = append(e.symbols, sym)
 			children = append(children, sym.ID)
@@ -438,10 +583,15 @@ func (e *SymbolExtractor) extractStructFields(structType *ast.StructType, struct
 // toRelPath converts an absolute file path to a relative path from the repository root.
 // This ensures that file paths work correctly across different worktrees.
 func (e *SymbolExtractor) toRelPath(absPath string) string {
-	if e.repoPath == "" {
+	return toRelPath(e.repoPath, absPath)
+}
+
+// toRelPath converts an absolute file path to a path relative to repoPath
+func toRelPath(repoPath, absPath string) string {
+	if repoPath == "" {
 		return absPath
 	}
-	relPath, err := filepath.Rel(e.repoPath, absPath)
+	relPath, err := filepath.Rel(repoPath, absPath)
 	if err != nil || filepath.IsAbs(relPath) {
 		// If conversion fails or path is outside repo, return absolute path
 		return absPath
@@ -463,6 +613,29 @@ func (e *SymbolExtractor) symbolID(filePath, kind, name string) string {
 	return fmt.Sprintf("%s:%s:%s", e.pkg.PkgPath, kind, name)
 }
 
+// positionedID appends the file and line of a declaration to its ID, e.g.
+// "pkg:func:init@a/b.go:12"; a second declaration on the same line gets the
+// column as well
+func (e *SymbolExtractor) positionedID(id, filePath string, pos token.Position) string {
+	id = positionedID(id, filePath, pos.Line)
+	if e.positioned[id] {
+		id = fmt.Sprintf("%s:%d", id, pos.Column)
+	}
+	e.positioned[id] = true
+	return id
+}
+
+// needsPosition reports whether declarations of a name can repeat in a
+// package (init functions and blank identifiers), so that their IDs need
+// the position of the declaration
+func needsPosition(kind, name string) bool {
+	return name == "_" || (kind == "func" && name == "init")
+}
+
+func positionedID(id, filePath string, line int) string {
+	return fmt.Sprintf("%s@%s:%d", id, filePath, line)
+}
+
 func (e *SymbolExtractor) extractImports(file *ast.File) map[string]string {
 	imports := make(map[string]string)
 	for _, imp := range file.Imports {
@@ -492,6 +665,27 @@ func (e *SymbolExtractor) extractDocComment(doc *ast.CommentGroup) string {
 	return strings.Join(lines, "\n")
 }
 
+// specDocComment returns the documentation of a spec in a declaration: its
+// own doc comment, its line comment, or the doc comment of the declaration
+func (e *SymbolExtractor) specDocComment(doc, comment *ast.CommentGroup, decl *ast.GenDecl) string {
+	if text := e.extractDocComment(doc); text != "" {
+		return text
+	}
+	if text := e.extractDocComment(comment); text != "" {
+		return text
+	}
+	return e.extractDocComment(decl.Doc)
+}
+
+// fieldDocComment returns the doc comment of a struct field, or its line
+// comment
+func (e *SymbolExtractor) fieldDocComment(field *ast.Field) string {
+	if text := e.extractDocComment(field.Doc); text != "" {
+		return text
+	}
+	return e.extractDocComment(field.Comment)
+}
+
 func (e *SymbolExtractor) extractPackageDoc() string {
 	// Try to find package doc from the first file
 	for _, file := range e.pkg.Syntax {
@@ -527,7 +721,11 @@ func (e *SymbolExtractor) typeToString(typ ast.Expr) string {
 	case *ast.MapType:
 		return fmt.Sprintf("map[%s]%s", e.typeToString(t.Key), e.typeToString(t.Value))
 	case *ast.InterfaceType:
-		return "interface{}"
+		return e.formatInterfaceType(t)
+	case *ast.BinaryExpr: // union of constraint terms
+		return fmt.Sprintf("%s %s %s", e.typeToString(t.X), t.Op, e.typeToString(t.Y))
+	case *ast.UnaryExpr: // ~T constraint term
+		return fmt.Sprintf("%s%s", t.Op, e.typeToString(t.X))
 	case *ast.FuncType:
 		return "func"
 	case *ast.StructType:
@@ -548,10 +746,18 @@ func (e *SymbolExtractor) typeToString(typ ast.Expr) string {
 	}
 }
 
+// recvTypeToString returns the name of a receiver type, without '*' and the
+// type parameters of generic types
 func (e *SymbolExtractor) recvTypeToString(typ ast.Expr) string {
 	// Remove * if present
 	if star, ok := typ.(*ast.StarExpr); ok {
-		return e.typeToString(star.X)
+		typ = star.X
+	}
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
 	}
 	return e.typeToString(typ)
 }
@@ -578,6 +784,7 @@ func (e *SymbolExtractor) formatFuncSignature(decl *ast.FuncDecl) string {
 
 	// Name
 	builder.WriteString(decl.Name.Name)
+	builder.WriteString(e.formatTypeParams(decl.Type.TypeParams))
 
 	// Parameters
 	builder.WriteString(e.formatFuncParams(decl.Type.Params))
@@ -629,7 +836,7 @@ func (e *SymbolExtractor) formatFuncParams(params *ast.FieldList) string {
 
 func (e *SymbolExtractor) formatStructSignature(spec *ast.TypeSpec, structType *ast.StructType) string {
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("type %s struct", spec.Name))
+	builder.WriteString(fmt.Sprintf("type %s%s struct", spec.Name, e.formatTypeParams(spec.TypeParams)))
 
 	if structType.Fields != nil && len(structType.Fields.List) > 0 {
 		builder.WriteString(" {\n")
@@ -650,7 +857,7 @@ func (e *SymbolExtractor) formatStructSignature(spec *ast.TypeSpec, structType *
 
 func (e *SymbolExtractor) formatInterfaceSignature(spec *ast.TypeSpec, ifaceType *ast.InterfaceType) string {
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("type %s interface", spec.Name))
+	builder.WriteString(fmt.Sprintf("type %s%s interface", spec.Name, e.formatTypeParams(spec.TypeParams)))
 
 	if ifaceType.Methods != nil && len(ifaceType.Methods.List) > 0 {
 		builder.WriteString(" {\n")
@@ -669,6 +876,55 @@ func (e *SymbolExtractor) formatInterfaceSignature(spec *ast.TypeSpec, ifaceType
 	return builder.String()
 }
 
+// typeParams returns the type parameters of a generic declaration
+func (e *SymbolExtractor) typeParams(params *ast.FieldList) []TypeParam {
+	if params == nil {
+		return nil
+	}
+	var result []TypeParam
+	for _, field := range params.List {
+		constraint := e.typeToString(field.Type)
+		for _, name := range field.Names {
+			result = append(result, TypeParam{Name: name.Name, Constraint: constraint})
+		}
+	}
+	return result
+}
+
+// formatTypeParams formats a type parameter list, e.g. "[K comparable, V any]",
+// or returns "" for non-generic declarations
+func (e *SymbolExtractor) formatTypeParams(params *ast.FieldList) string {
+	if params == nil || len(params.List) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(params.List))
+	for _, field := range params.List {
+		names := make([]string, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = name.Name
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", strings.Join(names, ", "), e.typeToString(field.Type)))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// formatInterfaceType formats an interface type literal on one line, e.g.
+// "interface{ ~int | ~float64 }" for a constraint
+func (e *SymbolExtractor) formatInterfaceType(iface *ast.InterfaceType) string {
+	if iface.Methods == nil || len(iface.Methods.List) == 0 {
+		return "interface{}"
+	}
+	parts := make([]string, 0, len(iface.Methods.List))
+	for _, method := range iface.Methods.List {
+		if fn, ok := method.Type.(*ast.FuncType); ok && len(method.Names) > 0 {
+			parts = append(parts, method.Names[0].Name+e.formatFuncParams(fn.Params))
+		} else {
+			parts = append(parts, e.typeToString(method.Type))
+		}
+	}
+	return "interface{ " + strings.Join(parts, "; ") + " }"
+}
+
 func strconvQuotes(s string) (string, bool) {
 	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
 		return s[1 : len(s)-1], true
@@ -3,6 +3,7 @@ package ast
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -119,6 +120,112 @@ var GlobalVar string
 	}
 }
 
+func TestSymbolExtractor_ValueSymbols(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.go")
+	content := `package test
+
+import "errors"
+
+// Level is a log level
+type Level int
+
+// Log levels
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	_
+	LevelError
+)
+
+const (
+	// DefaultBatchSize is the number of texts embedded per request
+	DefaultBatchSize = 32
+	defaultName      = "bcindex" // Name used in logs
+)
+
+// ErrNotFound is returned for unknown symbols
+var ErrNotFound = errors.New("not found")
+
+// Alias of Level
+type Severity = Level
+
+type Config struct {
+	BatchSize int // Texts per request
+	Level
+}
+
+var usageText = ` + "`" + `Usage:
+    bcindex [flags] <command>
+
+Commands are listed by bcindex help; flags apply to every command.
+` + "`" + `
+`
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	pkg, err := NewPackageLoader().LoadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to load package: %v", err)
+	}
+	symbols, err := NewSymbolExtractor(pkg, tmpDir).Extract()
+	if err != nil {
+		t.Fatalf("failed to extract symbols: %v", err)
+	}
+	byName := make(map[string]*ExtractedSymbol)
+	for _, sym := range symbols {
+		byName[sym.Kind+" "+sym.Name] = sym
+	}
+
+	debug := byName["const LevelDebug"]
+	if debug == nil {
+		t.Fatal("LevelDebug not extracted")
+	}
+	if debug.Signature != "const LevelDebug Level = 0" {
+		t.Errorf("LevelDebug signature = %q", debug.Signature)
+	}
+	if debug.LineEnd != 14 || strings.Join(debug.Members, ",") != "LevelDebug,LevelInfo,LevelError" {
+		t.Errorf("LevelDebug lines end %d, members %v", debug.LineEnd, debug.Members)
+	}
+	if info := byName["const LevelInfo"]; info == nil || info.Group != debug.ID || info.Signature != "const LevelInfo Level = 1" {
+		t.Errorf("unexpected LevelInfo: %+v", info)
+	}
+	if debug.DocComment != "Log levels" {
+		t.Errorf("LevelDebug doc = %q", debug.DocComment)
+	}
+
+	batch := byName["const DefaultBatchSize"]
+	if batch == nil || batch.Signature != "const DefaultBatchSize = 32" || batch.Group != "" || len(batch.Members) != 0 {
+		t.Errorf("unexpected DefaultBatchSize: %+v", batch)
+	}
+	if batch != nil && batch.DocComment != "DefaultBatchSize is the number of texts embedded per request" {
+		t.Errorf("DefaultBatchSize doc = %q", batch.DocComment)
+	}
+	if name := byName["const defaultName"]; name == nil || name.DocComment != "Name used in logs" || name.Value != `"bcindex"` {
+		t.Errorf("unexpected defaultName: %+v", name)
+	}
+
+	if errSym := byName["var ErrNotFound"]; errSym == nil || errSym.Signature != `var ErrNotFound = errors.New("not found")` {
+		t.Errorf("unexpected ErrNotFound: %+v", errSym)
+	}
+	if usage := byName["var usageText"]; usage == nil || strings.Contains(usage.Signature, "\n") ||
+		len(usage.Value) != maxValueLength || !strings.HasPrefix(usage.Value, "`Usage: bcindex [flags] <command> Commands") {
+		t.Errorf("unexpected usageText: %+v", usage)
+	}
+	if alias := byName["type Severity"]; alias == nil || alias.Signature != "type Severity = Level" {
+		t.Errorf("unexpected Severity: %+v", alias)
+	}
+	if level := byName["type Level"]; level == nil || level.Signature != "type Level int" {
+		t.Errorf("unexpected Level: %+v", level)
+	}
+
+	field := byName["field BatchSize"]
+	if field == nil || field.ParentID != "command-line-arguments:struct:Config" || field.DocComment != "Texts per request" {
+		t.Errorf("unexpected BatchSize field: %+v", field)
+	}
+}
+
 func TestSymbolExtractor_ExportedSymbols(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -382,3 +489,108 @@ func internalFunc() {}
 		t.Error("expected at least 1 exported symbol, got 0")
 	}
 }
+
+func TestPipeline_PositionedSymbolIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module testrepo\n\ngo 1.21\n",
+		"a/a.go": `package a
+
+type I interface{ M() }
+
+type T struct{}
+
+func (*T) M() {}
+
+func helper() {}
+
+func init() { helper() }
+
+func init() {}
+
+var _ I = (*T)(nil)
+
+var _ I = (*T)(nil)
+
+var _, _ = 1, 2
+
+const (
+	_ = iota
+	One
+	_
+)
+`,
+		"a/b.go": "package a\n\nfunc init() { helper() }\n",
+		"a/a_test.go": `package a
+
+import "testing"
+
+func TestHelper(t *testing.T) { helper() }
+`,
+		"a/x_test.go": `package a_test
+
+import (
+	"testing"
+
+	"testrepo/a"
+)
+
+func TestT(t *testing.T) { _ = a.One }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	pipeline := NewPipeline()
+	pipeline.SetTests(true)
+	symbols, edges, err := pipeline.ExtractRepositoryWithRelations(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to extract repository: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, sym := range symbols {
+		if ids[sym.ID] {
+			t.Errorf("duplicate symbol ID %s", sym.ID)
+		}
+		ids[sym.ID] = true
+	}
+
+	for _, id := range []string{
+		"testrepo/a:func:init@a/a.go:11",
+		"testrepo/a:func:init@a/a.go:13",
+		"testrepo/a:func:init@a/b.go:3",
+		"testrepo/a:var:_@a/a.go:15",
+		"testrepo/a:var:_@a/a.go:17",
+		"testrepo/a:var:_@a/a.go:19",
+		"testrepo/a:var:_@a/a.go:19:8",
+		"testrepo/a:const:_@a/a.go:22",
+		"testrepo/a:const:_@a/a.go:24",
+		"testrepo/a:func:TestHelper",
+		"testrepo/a_test:func:TestT",
+	} {
+		if !ids[id] {
+			t.Errorf("expected symbol %s", id)
+		}
+	}
+
+	set := edgeSet(edges)
+	for _, want := range []string{
+		"calls testrepo/a:func:init@a/a.go:11 -> testrepo/a:func:helper",
+		"calls testrepo/a:func:init@a/b.go:3 -> testrepo/a:func:helper",
+		"references testrepo/a:var:_@a/a.go:15 -> testrepo/a:interface:I",
+		"references testrepo/a:var:_@a/a.go:17 -> testrepo/a:struct:T",
+		"tests testrepo/a:func:TestHelper -> testrepo/a:func:helper",
+	} {
+		if !set[want] {
+			t.Errorf("expected edge %s", want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"log"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// interfaceDecl is an interface a named type can implement
+type interfaceDecl struct {
+	obj    *types.TypeName
+	iface  *types.Interface
+	stdlib bool
+}
+
+// ImplementationPass finds implements and dispatches edges across package
+// boundaries once every package of the repository is loaded. Pairs within a
+// package are left to RelationExtractor.
+type ImplementationPass struct {
+	pkgs    []*packages.Package
+	symbols map[string]*ExtractedSymbol
+
+	// StdlibInterfaces lists standard library interfaces to check as well,
+	// e.g. "error", "fmt.Stringer", "io.Reader" or "net/http.Handler"
+	StdlibInterfaces []string
+
+	// Changed limits the pass to pairs with a side in one of these packages;
+	// nil checks every pair. Standard library interfaces are always checked
+	// against every type, since their symbols are rebuilt on each run.
+	Changed map[string]bool
+}
+
+// NewImplementationPass creates a pass over the loaded packages; symbols must
+// hold the extracted symbols of all of them
+func NewImplementationPass(pkgs []*packages.Package, symbols []*ExtractedSymbol) *ImplementationPass {
+	return &ImplementationPass{
+		pkgs:    pkgs,
+		symbols: symbolMap(symbols),
+	}
+}
+
+// Run returns the edges found and the symbols created for the standard
+// library interfaces, which have no source in the repository
+func (p *ImplementationPass) Run() ([]*ExtractedSymbol, []*Edge) {
+	var interfaces []interfaceDecl
+	var named []*types.Named
+
+	for _, pkg := range p.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			typ, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+
+			if iface, ok := typ.Underlying().(*types.Interface); ok {
+				if iface.NumMethods() > 0 {
+					interfaces = append(interfaces, interfaceDecl{obj: obj, iface: iface})
+				}
+				continue
+			}
+			named = append(named, typ)
+		}
+	}
+
+	stdlib, stdlibSymbols := p.stdlibInterfaces()
+	interfaces = append(interfaces, stdlib...)
+	for _, sym := range stdlibSymbols {
+		p.symbols[sym.ID] = sym
+	}
+
+	r := &RelationExtractor{symbols: p.symbols}
+	for _, typ := range named {
+		typPkg := typ.Obj().Pkg().Path()
+		for _, decl := range interfaces {
+			ifacePkg := ""
+			if decl.obj.Pkg() != nil {
+				ifacePkg = decl.obj.Pkg().Path()
+			}
+			if ifacePkg == typPkg {
+				continue
+			}
+			if !decl.stdlib && p.Changed != nil && !p.Changed[typPkg] && !p.Changed[ifacePkg] {
+				continue
+			}
+
+			if !types.Implements(typ, decl.iface) && !types.Implements(types.NewPointer(typ), decl.iface) {
+				continue
+			}
+
+			fromSym := r.symbolOf(typ.Obj())
+			toSym := r.symbolOf(decl.obj)
+			if fromSym == nil || toSym == nil {
+				continue
+			}
+
+			r.edges = append(r.edges, &Edge{
+				FromID:   fromSym.ID,
+				ToID:     toSym.ID,
+				EdgeType: "implements",
+				Weight:   10, // Higher weight for implementations
+			})
+			r.addDispatchEdges(typ, decl.iface)
+		}
+	}
+
+	return stdlibSymbols, r.edges
+}
+
+// stdlibInterfaces resolves the configured standard library interfaces and
+// creates symbols for them and their methods. Names that do not resolve are
+// skipped with a warning.
+func (p *ImplementationPass) stdlibInterfaces() ([]interfaceDecl, []*ExtractedSymbol) {
+	var decls []interfaceDecl
+	var symbols []*ExtractedSymbol
+	seen := make(map[string]bool)
+
+	for _, name := range p.StdlibInterfaces {
+		obj, err := p.lookupStdlib(strings.TrimSpace(name))
+		if err != nil {
+			log.Printf("Warning: skipping standard library interface: %v", err)
+			continue
+		}
+		iface, ok := obj.Type().Underlying().(*types.Interface)
+		if !ok || iface.NumMethods() == 0 {
+			log.Printf("Warning: skipping standard library interface: %s is not an interface with methods", name)
+			continue
+		}
+
+		id := objectSymbolID(obj)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		decls = append(decls, interfaceDecl{obj: obj, iface: iface, stdlib: true})
+
+		pkgPath, pkgName := "builtin", "builtin"
+		if obj.Pkg() != nil {
+			pkgPath, pkgName = obj.Pkg().Path(), obj.Pkg().Name()
+		}
+		qualifier := types.RelativeTo(obj.Pkg())
+
+		sym := &ExtractedSymbol{
+			ID:          id,
+			Name:        obj.Name(),
+			Kind:        "interface",
+			PackagePath: pkgPath,
+			PackageName: pkgName,
+			Exported:    true,
+		}
+
+		var signature strings.Builder
+		fmt.Fprintf(&signature, "type %s interface {\n", obj.Name())
+		for i := 0; i < iface.NumMethods(); i++ {
+			method := iface.Method(i)
+			params := signatureString(method.Type().(*types.Signature), qualifier)
+			fmt.Fprintf(&signature, "\t%s%s\n", method.Name(), params)
+
+			// Methods of embedded interfaces keep the ID of their declaring
+			// interface, matching the call edges to them
+			methodID := objectSymbolID(method)
+			if seen[methodID] {
+				continue
+			}
+			seen[methodID] = true
+			symbols = append(symbols, &ExtractedSymbol{
+				ID:          methodID,
+				Name:        method.Name(),
+				Kind:        "method",
+				PackagePath: pkgPath,
+				PackageName: pkgName,
+				Signature:   fmt.Sprintf("func %s%s", method.Name(), params),
+				Exported:    true,
+				ParentID:    id,
+			})
+			sym.Children = append(sym.Children, methodID)
+		}
+		signature.WriteString("}")
+		sym.Signature = signature.String()
+
+		symbols = append(symbols, sym)
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].ID < symbols[j].ID
+	})
+	return decls, symbols
+}
+
+// lookupStdlib finds a standard library type by its qualified name
+// ("io.Reader", "net/http.Handler") in the packages the repository imports,
+// or a predeclared type ("error")
+func (p *ImplementationPass) lookupStdlib(name string) (*types.TypeName, error) {
+	dot := strings.LastIndex(name, ".")
+	if dot < strings.LastIndex(name, "/") {
+		dot = -1
+	}
+	if dot < 0 {
+		if obj, ok := types.Universe.Lookup(name).(*types.TypeName); ok {
+			return obj, nil
+		}
+		return nil, fmt.Errorf("unknown predeclared interface %q", name)
+	}
+
+	pkgPath, typeName := name[:dot], name[dot+1:]
+	var pkg *types.Package
+	packages.Visit(p.pkgs, func(visited *packages.Package) bool {
+		if visited.PkgPath == pkgPath && visited.Types != nil {
+			pkg = visited.Types
+		}
+		return pkg == nil
+	}, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("package %s of interface %s is not imported by the repository", pkgPath, name)
+	}
+
+	if obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName); ok {
+		return obj, nil
+	}
+	return nil, fmt.Errorf("unknown interface %q", name)
+}
+
+// signatureString formats parameters and results, e.g. "(p []byte) (n int, err error)"
+func signatureString(sig *types.Signature, qualifier types.Qualifier) string {
+	var buf bytes.Buffer
+	types.WriteSignature(&buf, sig, qualifier)
+	return buf.String()
+}
@@ -0,0 +1,126 @@
+package ast
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeImplementsRepo(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module testrepo\n\ngo 1.21\n",
+		"api/api.go": `package api
+
+type Embedder interface {
+	Embed(text string) []float32
+}
+`,
+		"impl/impl.go": `package impl
+
+import "fmt"
+
+type Local struct{}
+
+func (l *Local) Embed(text string) []float32 { return nil }
+
+func (l *Local) Name() string { return fmt.Sprint("local") }
+
+func (l Local) String() string { return "local" }
+`,
+		"downstream/downstream.go": `package downstream
+
+type Named interface {
+	Name() string
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return tmpDir
+}
+
+func edgeSet(edges []*Edge) map[string]bool {
+	set := make(map[string]bool)
+	for _, edge := range edges {
+		set[edge.EdgeType+" "+edge.FromID+" -> "+edge.ToID] = true
+	}
+	return set
+}
+
+func TestPipeline_CrossPackageImplementations(t *testing.T) {
+	root := writeImplementsRepo(t)
+
+	p := NewPipeline()
+	p.SetStdlibInterfaces([]string{"fmt.Stringer", "error", "net/http.Handler"})
+	symbols, edges, err := p.ExtractRepositoryWithRelations(root)
+	if err != nil {
+		t.Fatalf("ExtractRepositoryWithRelations failed: %v", err)
+	}
+
+	found := edgeSet(edges)
+	want := []string{
+		"implements testrepo/impl:struct:Local -> testrepo/api:interface:Embedder",
+		"dispatches testrepo/api:interface-method:Embedder.Embed -> testrepo/impl:method:Local.Embed",
+		// Interfaces of downstream packages count as well
+		"implements testrepo/impl:struct:Local -> testrepo/downstream:interface:Named",
+		"implements testrepo/impl:struct:Local -> fmt:interface:Stringer",
+		"dispatches fmt:interface-method:Stringer.String -> testrepo/impl:method:Local.String",
+	}
+	for _, w := range want {
+		if !found[w] {
+			t.Errorf("missing edge %q", w)
+		}
+	}
+	if found["implements testrepo/impl:struct:Local -> builtin:interface:error"] {
+		t.Error("Local does not implement error")
+	}
+
+	// Standard library interfaces get symbols so edges can point to them;
+	// net/http is not imported by the repository and is skipped
+	ids := make(map[string]bool)
+	for _, sym := range symbols {
+		ids[sym.ID] = true
+	}
+	for _, id := range []string{"fmt:interface:Stringer", "fmt:interface-method:Stringer.String", "builtin:interface:error"} {
+		if !ids[id] {
+			t.Errorf("missing symbol %s", id)
+		}
+	}
+	if ids["net/http:interface:Handler"] {
+		t.Error("unexpected symbol for http.Handler")
+	}
+}
+
+func TestPipeline_ExtractPackagesWithRelations(t *testing.T) {
+	root := writeImplementsRepo(t)
+
+	symbols, edges, err := NewPipeline().ExtractPackagesWithRelations(root, map[string]bool{"testrepo/api": true})
+	if err != nil {
+		t.Fatalf("ExtractPackagesWithRelations failed: %v", err)
+	}
+
+	for _, sym := range symbols {
+		if sym.PackagePath != "testrepo/api" {
+			t.Errorf("unexpected symbol %s of an unchanged package", sym.ID)
+		}
+	}
+
+	// Pairs with a side in the changed package are re-checked, others kept
+	found := edgeSet(edges)
+	if !found["implements testrepo/impl:struct:Local -> testrepo/api:interface:Embedder"] {
+		t.Error("missing implements edge to the changed package")
+	}
+	if found["implements testrepo/impl:struct:Local -> testrepo/downstream:interface:Named"] {
+		t.Error("unexpected edge between unchanged packages")
+	}
+}
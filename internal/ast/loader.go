@@ -59,6 +59,10 @@ type LoadConfig struct {
 
 	// BuildTags specifies build tags to respect
 	BuildTags []string
+
+	// GOOS and GOARCH select the target platform (default: host)
+	GOOS   string
+	GOARCH string
 }
 
 // LoadRepo loads all packages from a repository root
@@ -105,6 +109,15 @@ func (l *PackageLoader) LoadRepo(config *LoadConfig) ([]*packages.Package, error
 		BuildFlags:  l.buildFlags(config.BuildTags),
 		Logf:        func(format string, args ...interface{}) {}, // Suppress noisy logs
 	}
+	if config.GOOS != "" || config.GOARCH != "" {
+		cfg.Env = os.Environ()
+		if config.GOOS != "" {
+			cfg.Env = append(cfg.Env, "GOOS="+config.GOOS)
+		}
+		if config.GOARCH != "" {
+			cfg.Env = append(cfg.Env, "GOARCH="+config.GOARCH)
+		}
+	}
 
 	// Load packages
 	pkgs, err := packages.Load(cfg, patterns...)
@@ -133,9 +146,38 @@ func (l *PackageLoader) LoadRepo(config *LoadConfig) ([]*packages.Package, error
 		result = append(result, pkg)
 	}
 
+	if cfg.Tests {
+		result = dropTestDuplicates(result)
+	}
+
 	return result, nil
 }
 
+// dropTestDuplicates keeps a single package per import path when tests are
+// loaded, so that no declaration is extracted twice: the variant compiled
+// with the in-package tests replaces the package, and the generated test
+// main packages are dropped. External test packages (path "x_test") stay.
+func dropTestDuplicates(pkgs []*packages.Package) []*packages.Package {
+	variants := make(map[string]*packages.Package)
+	for _, pkg := range pkgs {
+		if pkg.ID != pkg.PkgPath && variants[pkg.PkgPath] == nil {
+			variants[pkg.PkgPath] = pkg
+		}
+	}
+
+	var result []*packages.Package
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		if variant := variants[pkg.PkgPath]; variant != nil && variant != pkg {
+			continue
+		}
+		result = append(result, pkg)
+	}
+	return result
+}
+
 // LoadPackage loads a single package by its import path
 func (l *PackageLoader) LoadPackage(importPath string) (*packages.Package, error) {
 	cfg := &packages.Config{
@@ -196,12 +238,13 @@ func (l *PackageLoader) LoadFile(filePath string) (*packages.Package, error) {
 func (l *PackageLoader) buildFlags(tags []string) []string {
 	var flags []string
 
-	// Add custom build tags
+	// Add custom build tags; go only honors the last -tags flag, so they
+	// are passed as one list
 	allTags := append([]string{}, l.BuildTags...)
 	allTags = append(allTags, tags...)
 
-	for _, tag := range allTags {
-		flags = append(flags, "-tags", tag)
+	if len(allTags) > 0 {
+		flags = append(flags, "-tags", strings.Join(allTags, ","))
 	}
 
 	return flags
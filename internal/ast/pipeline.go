@@ -2,6 +2,7 @@ package ast
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 
@@ -10,7 +11,10 @@ import (
 
 // Pipeline provides a high-level interface for loading and extracting symbols
 type Pipeline struct {
-	loader *PackageLoader
+	loader           *PackageLoader
+	stdlibInterfaces []string
+	callGraph        string
+	buildContexts    []BuildContext
 }
 
 // NewPipeline creates a new extraction pipeline
@@ -209,7 +213,28 @@ func (p *Pipeline) SetBuildTags(tags []string) {
 	p.loader.BuildTags = tags
 }
 
-// SetTests sets whether to include test files
+// SetStdlibInterfaces sets the standard library interfaces (e.g. "io.Reader")
+// that implementations are looked up for
+func (p *Pipeline) SetStdlibInterfaces(names []string) {
+	p.stdlibInterfaces = names
+}
+
+// SetCallGraph sets the call graph mode: CallGraphAST (default), CallGraphCHA
+// or CallGraphVTA
+func (p *Pipeline) SetCallGraph(mode string) {
+	p.callGraph = mode
+}
+
+// SetBuildContexts sets the build contexts the repository is extracted
+// under. Symbols record the names of the contexts they exist in. Empty uses
+// the host's context.
+func (p *Pipeline) SetBuildContexts(contexts []BuildContext) {
+	p.buildContexts = contexts
+}
+
+// SetTests sets whether to include test files. Tests, benchmarks, fuzz tests
+// and examples get the test kind and tests edges to the production symbols
+// they call.
 func (p *Pipeline) SetTests(include bool) {
 	p.loader.Tests = include
 }
@@ -221,6 +246,19 @@ func (p *Pipeline) SetSkipDir(dirs []string) {
 
 // ExtractRepositoryWithRelations extracts both symbols and relationships
 func (p *Pipeline) ExtractRepositoryWithRelations(root string) ([]*ExtractedSymbol, []*Edge, error) {
+	return p.extractWithRelations(root, nil)
+}
+
+// ExtractPackagesWithRelations loads the whole repository but returns only
+// the symbols and relationships of the given packages, plus implementations
+// across package boundaries with a side in one of them
+func (p *Pipeline) ExtractPackagesWithRelations(root string, pkgPaths map[string]bool) ([]*ExtractedSymbol, []*Edge, error) {
+	return p.extractWithRelations(root, pkgPaths)
+}
+
+// extractWithRelations extracts the packages in only, or all packages if only
+// is nil
+func (p *Pipeline) extractWithRelations(root string, only map[string]bool) ([]*ExtractedSymbol, []*Edge, error) {
 	// Get absolute path
 	absRoot, err := filepath.Abs(root)
 	if err != nil {
@@ -232,11 +270,32 @@ func (p *Pipeline) ExtractRepositoryWithRelations(root string) ([]*ExtractedSymb
 		return nil, nil, fmt.Errorf("repository root does not exist: %s", absRoot)
 	}
 
+	if len(p.buildContexts) == 0 {
+		return p.extractContext(absRoot, only, BuildContext{})
+	}
+
+	merged := newContextMerge()
+	for _, bc := range p.buildContexts {
+		symbols, edges, err := p.extractContext(absRoot, only, bc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to extract build context %s: %w", bc.Name, err)
+		}
+		merged.add(bc.Name, symbols, edges)
+	}
+	return merged.symbols, merged.edges, nil
+}
+
+// extractContext extracts the packages in only, or all packages if only is
+// nil, as compiled under a build context
+func (p *Pipeline) extractContext(absRoot string, only map[string]bool, bc BuildContext) ([]*ExtractedSymbol, []*Edge, error) {
 	// Load all packages
 	config := &LoadConfig{
-		Root:     absRoot,
-		Patterns: []string{"./..."},
-		Tests:    false,
+		Root:      absRoot,
+		Patterns:  []string{"./..."},
+		Tests:     false,
+		BuildTags: bc.Tags,
+		GOOS:      bc.GOOS,
+		GOARCH:    bc.GOARCH,
 	}
 
 	pkgs, err := p.loader.LoadRepo(config)
@@ -250,7 +309,9 @@ func (p *Pipeline) ExtractRepositoryWithRelations(root string) ([]*ExtractedSymb
 
 	// Extract symbols from each package
 	var allSymbols []*ExtractedSymbol
+	var repoSymbols []*ExtractedSymbol
 	var allEdges []*Edge
+	var extracted []*packages.Package
 
 	for _, pkg := range pkgs {
 		symbols, err := p.ExtractPackage(pkg, absRoot)
@@ -258,20 +319,92 @@ func (p *Pipeline) ExtractRepositoryWithRelations(root string) ([]*ExtractedSymb
 			// Log error but continue with other packages
 			continue
 		}
-		allSymbols = append(allSymbols, symbols...)
+		extracted = append(extracted, pkg)
+		repoSymbols = append(repoSymbols, symbols...)
+		if only == nil || only[pkg.PkgPath] {
+			allSymbols = append(allSymbols, symbols...)
+		}
+	}
 
-		// Extract relationships for this package
-		edges, err := p.ExtractRelations(pkg, absRoot, symbols)
+	// Extract relationships of each package against the symbols of the
+	// whole repository, so that edges cross package boundaries. Unchanged
+	// packages still have edges into changed ones.
+	symMap := symbolMap(repoSymbols)
+	keep := func(edge *Edge) bool {
+		return only == nil || only[symMap[edge.FromID].PackagePath] || only[symMap[edge.ToID].PackagePath]
+	}
+	var repoEdges []*Edge
+	for _, pkg := range extracted {
+		edges, err := p.extractRelations(pkg, absRoot, symMap)
 		if err != nil {
 			// Log error but continue
 			continue
 		}
-		allEdges = append(allEdges, edges...)
+		repoEdges = append(repoEdges, edges...)
+		for _, edge := range edges {
+			if keep(edge) {
+				allEdges = append(allEdges, edge)
+			}
+		}
+	}
+
+	// Implementations across packages need every package loaded
+	pass := NewImplementationPass(pkgs, repoSymbols)
+	pass.StdlibInterfaces = p.stdlibInterfaces
+	pass.Changed = only
+	stdlibSymbols, implEdges := pass.Run()
+	allSymbols = append(allSymbols, stdlibSymbols...)
+	allEdges = append(allEdges, implEdges...)
+
+	if p.callGraph != CallGraphAST {
+		builder := NewCallGraphBuilder(pkgs, absRoot, repoSymbols, p.callGraph)
+		builder.Changed = only
+		if p.loader.Tests {
+			// Tests reach changed packages through helpers anywhere
+			builder.Changed = nil
+		}
+		callEdges, err := builder.Build(allEdges)
+		if err != nil {
+			log.Printf("Warning: skipping %s call graph: %v", p.callGraph, err)
+		}
+		repoEdges = append(repoEdges, callEdges...)
+		for _, edge := range callEdges {
+			if keep(edge) {
+				allEdges = append(allEdges, edge)
+			}
+		}
+	}
+
+	if p.loader.Tests {
+		allEdges = withTestEdges(symMap, allEdges, repoEdges, keep)
 	}
 
 	return allSymbols, allEdges, nil
 }
 
+// withTestEdges replaces the edges of symbols of the test layer with
+// tests edges found over the edges of the whole repository, so that tests do
+// not count as callers or implementations of production code
+func withTestEdges(symbols map[string]*ExtractedSymbol, edges, repoEdges []*Edge, keep func(*Edge) bool) []*Edge {
+	inTests := func(id string) bool {
+		sym := symbols[id]
+		return sym != nil && IsTestSymbol(sym)
+	}
+
+	var result []*Edge
+	for _, edge := range edges {
+		if !inTests(edge.FromID) && !inTests(edge.ToID) {
+			result = append(result, edge)
+		}
+	}
+	for _, edge := range testEdges(symbols, repoEdges) {
+		if keep(edge) {
+			result = append(result, edge)
+		}
+	}
+	return result
+}
+
 // ExtractRelations extracts relationships from a package
 func (p *Pipeline) ExtractRelations(pkg *packages.Package, repoPath string, symbols []*ExtractedSymbol) ([]*Edge, error) {
 	if pkg == nil {
@@ -290,7 +423,17 @@ func (p *Pipeline) ExtractRelations(pkg *packages.Package, repoPath string, symb
 		}
 	}
 
-	extractor := NewRelationExtractor(pkg, repoPath, symbols)
+	return p.extractRelations(pkg, repoPath, symbolMap(symbols))
+}
+
+// extractRelations extracts relationships from a package, resolving them
+// against symbols
+func (p *Pipeline) extractRelations(pkg *packages.Package, repoPath string, symbols map[string]*ExtractedSymbol) ([]*Edge, error) {
+	extractor := &RelationExtractor{
+		pkg:      pkg,
+		repoPath: repoPath,
+		symbols:  symbols,
+	}
 	edges, err := extractor.ExtractAll()
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract relations: %w", err)
@@ -3,6 +3,7 @@ package ast
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
 
@@ -15,32 +16,55 @@ type RelationExtractor struct {
 	repoPath   string
 	symbols    map[string]*ExtractedSymbol // ID -> Symbol
 	edges      []*Edge
+
+	fieldOwners map[*types.Package]map[*types.Var]string // field -> struct name
 }
 
 // Edge represents a relationship between two symbols
 type Edge struct {
 	FromID     string // Source symbol ID
 	ToID       string // Target symbol ID
-	EdgeType   string // imports | implements | calls | references | embeds
+	EdgeType   string // imports | implements | calls | dispatches | references | embeds
 	Weight     int    // Relationship weight (for ranking)
 	ImportPath string // For import edges: specific import path
+	Provenance string // static | cha | vta (empty means static)
+
+	// Call or reference site the edge stands for (relative path, 1-based)
+	FilePath string
+	Line     int
+	Column   int
 }
 
-// NewRelationExtractor creates a new relation extractor
-func NewRelationExtractor(pkg *packages.Package, repoPath string, symbols []*ExtractedSymbol) *RelationExtractor {
-	symMap := make(map[string]*ExtractedSymbol)
-	for _, sym := range symbols {
-		symMap[sym.ID] = sym
+// setSite records the position of a call or reference site on an edge
+func (r *RelationExtractor) setSite(edge *Edge, pos token.Pos) {
+	position := r.pkg.Fset.Position(pos)
+	if !position.IsValid() {
+		return
 	}
+	edge.FilePath = toRelPath(r.repoPath, position.Filename)
+	edge.Line = position.Line
+	edge.Column = position.Column
+}
 
+// NewRelationExtractor creates a new relation extractor
+func NewRelationExtractor(pkg *packages.Package, repoPath string, symbols []*ExtractedSymbol) *RelationExtractor {
 	return &RelationExtractor{
 		pkg:      pkg,
 		repoPath: repoPath,
-		symbols:  symMap,
+		symbols:  symbolMap(symbols),
 		edges:    make([]*Edge, 0),
 	}
 }
 
+// symbolMap indexes symbols by ID
+func symbolMap(symbols []*ExtractedSymbol) map[string]*ExtractedSymbol {
+	symMap := make(map[string]*ExtractedSymbol, len(symbols))
+	for _, sym := range symbols {
+		symMap[sym.ID] = sym
+	}
+	return symMap
+}
+
 // ExtractAll extracts all types of relationships
 func (r *RelationExtractor) ExtractAll() ([]*Edge, error) {
 	// Extract import relationships
@@ -58,11 +82,21 @@ func (r *RelationExtractor) ExtractAll() ([]*Edge, error) {
 		return nil, fmt.Errorf("failed to extract call edges: %w", err)
 	}
 
-	// Extract field/embedding relationships
+	// Extract embedding relationships
 	if err := r.extractFieldEdges(); err != nil {
 		return nil, fmt.Errorf("failed to extract field edges: %w", err)
 	}
 
+	// Extract type parameter constraints
+	if err := r.extractConstraintEdges(); err != nil {
+		return nil, fmt.Errorf("failed to extract constraint edges: %w", err)
+	}
+
+	// Extract uses of symbols
+	if err := r.extractReferenceEdges(); err != nil {
+		return nil, fmt.Errorf("failed to extract reference edges: %w", err)
+	}
+
 	return r.edges, nil
 }
 
@@ -72,16 +106,12 @@ func (r *RelationExtractor) extractImportEdges() error {
 		return nil
 	}
 
-	// Build a map of import paths to package IDs
-	importPathToPkgID := make(map[string]string)
-	for _, sym := range r.symbols {
-		if sym.Kind == "package" {
-			importPathToPkgID[sym.PackagePath] = sym.ID
-		}
-	}
-
 	// Extract imports from each file
 	for _, astFile := range r.pkg.Syntax {
+		// Imports of tests are not dependencies of the package
+		if isTestFile(r.pkg.Fset.Position(astFile.Pos()).Filename) {
+			continue
+		}
 		for _, imp := range astFile.Imports {
 			importPath, _ := strconvQuotes(imp.Path.Value)
 
@@ -92,7 +122,7 @@ func (r *RelationExtractor) extractImportEdges() error {
 
 			// Find the package symbol for this file
 			fromPkgID := fmt.Sprintf("pkg:%s", r.pkg.PkgPath)
-			toPkgID, ok := importPathToPkgID[importPath]
+			toPkg, ok := r.symbols[fmt.Sprintf("pkg:%s", importPath)]
 			if !ok {
 				// Skip external packages (not indexed in our database)
 				// This prevents foreign key constraint errors
@@ -101,7 +131,7 @@ func (r *RelationExtractor) extractImportEdges() error {
 
 			edge := &Edge{
 				FromID:     fromPkgID,
-				ToID:       toPkgID,
+				ToID:       toPkg.ID,
 				EdgeType:   "imports",
 				Weight:     1,
 				ImportPath: importPath,
@@ -123,7 +153,7 @@ func (r *RelationExtractor) extractImplementationEdges() error {
 	info := r.pkg.TypesInfo
 
 	// Find all interface types by scanning the AST
-	interfaces := make(map[string]*types.Interface)
+	interfaces := make(map[*types.TypeName]*types.Interface)
 	for _, astFile := range r.pkg.Syntax {
 		ast.Inspect(astFile, func(n ast.Node) bool {
 			typeSpec, ok := n.(*ast.TypeSpec)
@@ -132,15 +162,15 @@ func (r *RelationExtractor) extractImplementationEdges() error {
 			}
 
 			// Get the type object
-			obj := info.ObjectOf(typeSpec.Name)
-			if obj == nil {
+			obj, ok := info.ObjectOf(typeSpec.Name).(*types.TypeName)
+			if !ok {
 				return true
 			}
 
-			// Check if it's an interface
+			// Check if it's an interface; every type implements an empty one
 			ifaceType, ok := obj.Type().Underlying().(*types.Interface)
-			if ok {
-				interfaces[obj.Id()] = ifaceType
+			if ok && ifaceType.NumMethods() > 0 {
+				interfaces[obj] = ifaceType
 			}
 
 			return true
@@ -162,32 +192,31 @@ func (r *RelationExtractor) extractImplementationEdges() error {
 			}
 
 			named, ok := obj.Type().(*types.Named)
-			if !ok {
+			if !ok || types.IsInterface(named) {
 				return true
 			}
 
 			// Check if this type implements any interfaces
-			for ifaceID, iface := range interfaces {
-				// Skip self
-				if obj.Id() == ifaceID {
+			for ifaceObj, iface := range interfaces {
+				if !types.Implements(named, iface) && !types.Implements(types.NewPointer(named), iface) {
 					continue
 				}
 
-				if types.Implements(named, iface) || types.Implements(types.NewPointer(named), iface) {
-					// Create edge from concrete type to interface
-					fromSym := r.findSymbolByObj(obj)
-					toSym := r.findSymbolByTypesID(ifaceID)
-
-					if fromSym != nil && toSym != nil {
-						edge := &Edge{
-							FromID:   fromSym.ID,
-							ToID:     toSym.ID,
-							EdgeType: "implements",
-							Weight:   10, // Higher weight for implementations
-						}
-						r.edges = append(r.edges, edge)
-					}
+				// Create edge from concrete type to interface
+				fromSym := r.findSymbolByObj(obj)
+				toSym := r.findSymbolByObj(ifaceObj)
+				if fromSym == nil || toSym == nil {
+					continue
+				}
+
+				edge := &Edge{
+					FromID:   fromSym.ID,
+					ToID:     toSym.ID,
+					EdgeType: "implements",
+					Weight:   10, // Higher weight for implementations
 				}
+				r.edges = append(r.edges, edge)
+				r.addDispatchEdges(named, iface)
 			}
 
 			return true
@@ -197,6 +226,33 @@ func (r *RelationExtractor) extractImplementationEdges() error {
 	return nil
 }
 
+// addDispatchEdges links each method of an interface to the method that runs
+// when it is called on the implementing type
+func (r *RelationExtractor) addDispatchEdges(named *types.Named, iface *types.Interface) {
+	methods := types.NewMethodSet(types.NewPointer(named))
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		ifaceMethod := iface.Method(i)
+		sel := methods.Lookup(ifaceMethod.Pkg(), ifaceMethod.Name())
+		if sel == nil {
+			continue
+		}
+
+		fromSym := r.symbolOf(ifaceMethod)
+		toSym := r.symbolOf(sel.Obj())
+		if fromSym == nil || toSym == nil || fromSym.ID == toSym.ID {
+			continue
+		}
+
+		r.edges = append(r.edges, &Edge{
+			FromID:   fromSym.ID,
+			ToID:     toSym.ID,
+			EdgeType: "dispatches",
+			Weight:   5, // Same weight as the call it stands for
+		})
+	}
+}
+
 // extractCallEdges extracts function call relationships
 func (r *RelationExtractor) extractCallEdges() error {
 	if r.pkg.Types == nil || r.pkg.TypesInfo == nil {
@@ -205,70 +261,63 @@ func (r *RelationExtractor) extractCallEdges() error {
 
 	info := r.pkg.TypesInfo
 
-	// Build a map of AST nodes to their enclosing function
-	enclosingFunc := make(map[ast.Node]types.Object)
-
 	for _, astFile := range r.pkg.Syntax {
-		// First pass: find all function declarations
-		ast.Inspect(astFile, func(n ast.Node) bool {
-			funcDecl, ok := n.(*ast.FuncDecl)
-			if ok {
-				obj := info.ObjectOf(funcDecl.Name)
-				if obj != nil {
-					enclosingFunc[funcDecl] = obj
-				}
-			}
-			return true
-		})
-
-		// Second pass: find call expressions
-		ast.Inspect(astFile, func(n ast.Node) bool {
-			callExpr, ok := n.(*ast.CallExpr)
-			if !ok {
-				return true
-			}
-
+		for _, decl := range astFile.Decls {
 			// Find the calling function
-			var caller types.Object
-			for node, obj := range enclosingFunc {
-				if r.isNodeInRange(callExpr, node, astFile) {
-					caller = obj
-					break
-				}
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
 			}
-
+			caller := info.ObjectOf(funcDecl.Name)
 			if caller == nil {
-				return true
+				continue
 			}
-
-			// Get the called function
-			called := r.getCalledFunction(callExpr, info)
-			if called == nil {
-				return true
+			fromSym := r.findSymbolByObj(caller)
+			if fromSym == nil {
+				continue
 			}
 
-			// Create edge
-			fromSym := r.findSymbolByObj(caller)
-			toSym := r.findSymbolByObj(called)
+			// Find call expressions in its body
+			ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+				callExpr, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
 
-			if fromSym != nil && toSym != nil && fromSym.ID != toSym.ID {
-				edge := &Edge{
-					FromID:   fromSym.ID,
-					ToID:     toSym.ID,
-					EdgeType: "calls",
-					Weight:   5, // Medium weight for calls
+				// Get the called function; calls through an interface
+				// resolve to the interface method, calls of instantiated
+				// generics to the generic declaration
+				called := r.getCalledFunction(callExpr, info)
+				if called == nil {
+					return true
+				}
+				if fn, ok := called.(*types.Func); ok {
+					called = fn.Origin()
 				}
-				r.edges = append(r.edges, edge)
-			}
 
-			return true
-		})
+				// Create edge, one per call site
+				toSym := r.findSymbolByObj(called)
+				if toSym != nil && fromSym.ID != toSym.ID {
+					edge := &Edge{
+						FromID:   fromSym.ID,
+						ToID:     toSym.ID,
+						EdgeType: "calls",
+						Weight:   5, // Medium weight for calls
+					}
+					r.setSite(edge, calleePos(callExpr, info))
+					r.edges = append(r.edges, edge)
+				}
+
+				return true
+			})
+		}
 	}
 
 	return nil
 }
 
-// extractFieldEdges extracts struct field and embedding relationships
+// extractFieldEdges extracts struct embedding relationships; the types of
+// named fields are found by extractReferenceEdges
 func (r *RelationExtractor) extractFieldEdges() error {
 	if r.pkg.Types == nil || r.pkg.TypesInfo == nil {
 		return nil
@@ -315,24 +364,6 @@ func (r *RelationExtractor) extractFieldEdges() error {
 								r.edges = append(r.edges, edge)
 							}
 						}
-					} else {
-						// Regular field - create reference edge
-						for range field.Names {
-							fieldType := info.TypeOf(field.Type)
-							if fieldType != nil {
-								fieldTypeSym := r.findSymbolByType(fieldType)
-								if fieldTypeSym != nil {
-									// Create edge from struct to field type
-									edge := &Edge{
-										FromID:   structSym.ID,
-										ToID:     fieldTypeSym.ID,
-										EdgeType: "references",
-										Weight:   2, // Low weight for references
-									}
-									r.edges = append(r.edges, edge)
-								}
-							}
-						}
 					}
 				}
 			}
@@ -344,58 +375,328 @@ func (r *RelationExtractor) extractFieldEdges() error {
 	return nil
 }
 
-// Helper methods
+// extractConstraintEdges links generic functions and types to the interfaces
+// constraining their type parameters, e.g. Sum to Number in
+// "func Sum[T Number](values []T) T"
+func (r *RelationExtractor) extractConstraintEdges() error {
+	if r.pkg.Types == nil || r.pkg.TypesInfo == nil {
+		return nil
+	}
 
-func (r *RelationExtractor) isStdLib(importPath string) bool {
-	// Standard library packages don't contain a dot
-	return !strings.Contains(importPath, ".")
+	info := r.pkg.TypesInfo
+	for _, astFile := range r.pkg.Syntax {
+		for _, decl := range astFile.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				r.addConstraintEdges(info.Defs[d.Name], d.Type.TypeParams, info)
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if s, ok := spec.(*ast.TypeSpec); ok {
+						r.addConstraintEdges(info.Defs[s.Name], s.TypeParams, info)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
 }
 
-func (r *RelationExtractor) findSymbolByObj(obj types.Object) *ExtractedSymbol {
-	if obj == nil {
+func (r *RelationExtractor) addConstraintEdges(decl types.Object, params *ast.FieldList, info *types.Info) {
+	fromSym := r.symbolOf(decl)
+	if fromSym == nil {
+		return
+	}
+	for _, ident := range constraintIdents(params, info) {
+		toSym := r.symbolOf(info.Uses[ident])
+		if toSym == nil || toSym.ID == fromSym.ID {
+			continue
+		}
+		edge := &Edge{
+			FromID:   fromSym.ID,
+			ToID:     toSym.ID,
+			EdgeType: "constrains",
+			Weight:   5, // Medium weight for constraints
+		}
+		r.setSite(edge, ident.Pos())
+		r.edges = append(r.edges, edge)
+	}
+}
+
+// constraintIdents returns the names of interfaces in the constraints of a
+// type parameter list, e.g. Number in "[T Number]" or "[T ~int | Number]"
+func constraintIdents(params *ast.FieldList, info *types.Info) []*ast.Ident {
+	if params == nil {
 		return nil
 	}
+	var idents []*ast.Ident
+	for _, field := range params.List {
+		ast.Inspect(field.Type, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj, ok := info.Uses[ident].(*types.TypeName)
+			if !ok {
+				return true
+			}
+			if _, isParam := obj.Type().(*types.TypeParam); !isParam && types.IsInterface(obj.Type()) {
+				idents = append(idents, ident)
+			}
+			return true
+		})
+	}
+	return idents
+}
 
-	// Handle builtin types (nil package)
-	if obj.Pkg() == nil {
+// extractReferenceEdges extracts a references edge for each use of an
+// indexed symbol, from the declaration the use appears in. Called names in
+// function bodies are left to call edges, embedded types to embeds edges and
+// type parameter constraints to constrains edges.
+func (r *RelationExtractor) extractReferenceEdges() error {
+	if r.pkg.Types == nil || r.pkg.TypesInfo == nil {
 		return nil
 	}
 
-	// Try to find symbol by object name and package
-	pkgPath := obj.Pkg().Path()
-	objName := obj.Name()
-	kind := r.objKindToSymKind(obj)
+	info := r.pkg.TypesInfo
+	pkgSym := r.symbols[fmt.Sprintf("pkg:%s", r.pkg.PkgPath)]
 
-	// Generate possible IDs
-	possibleIDs := []string{
-		fmt.Sprintf("%s:%s:%s", pkgPath, kind, objName),
-		fmt.Sprintf("pkg:%s", pkgPath),
+	addUses := func(node ast.Node, from types.Object, skip map[*ast.Ident]bool) {
+		fromSym := pkgSym
+		if sym := r.symbolOf(from); sym != nil {
+			fromSym = sym
+		}
+		if fromSym == nil {
+			return
+		}
+
+		ast.Inspect(node, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok || skip[ident] {
+				return true
+			}
+			toSym := r.usedSymbol(info.Uses[ident])
+			if toSym == nil || toSym.ID == fromSym.ID {
+				return true
+			}
+
+			edge := &Edge{
+				FromID:   fromSym.ID,
+				ToID:     toSym.ID,
+				EdgeType: "references",
+				Weight:   2, // Low weight for references
+			}
+			r.setSite(edge, ident.Pos())
+			r.edges = append(r.edges, edge)
+			return true
+		})
 	}
 
-	for _, id := range possibleIDs {
-		if sym, ok := r.symbols[id]; ok {
-			return sym
+	for _, astFile := range r.pkg.Syntax {
+		for _, decl := range astFile.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				skip := make(map[*ast.Ident]bool)
+				if d.Recv != nil {
+					// The receiver type declares the method
+					ast.Inspect(d.Recv, func(n ast.Node) bool {
+						if ident, ok := n.(*ast.Ident); ok {
+							skip[ident] = true
+						}
+						return true
+					})
+				}
+				if d.Body != nil {
+					ast.Inspect(d.Body, func(n ast.Node) bool {
+						if callExpr, ok := n.(*ast.CallExpr); ok {
+							if ident := calleeIdent(callExpr, info); ident != nil {
+								skip[ident] = true
+							}
+						}
+						return true
+					})
+				}
+				for _, ident := range constraintIdents(d.Type.TypeParams, info) {
+					skip[ident] = true
+				}
+				addUses(d, info.Defs[d.Name], skip)
+
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						skip := embeddedIdents(s)
+						for _, ident := range constraintIdents(s.TypeParams, info) {
+							skip[ident] = true
+						}
+						addUses(s, info.Defs[s.Name], skip)
+					case *ast.ValueSpec:
+						addUses(s, info.Defs[s.Names[0]], nil)
+					}
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
-func (r *RelationExtractor) findSymbolByTypesID(id string) *ExtractedSymbol {
-	// Try to parse the ID format from types.Info
-	// The ID format is typically "path/to/file:line" or similar
-	// We need to match it to our symbol IDs
+// usedSymbol returns the indexed symbol of a used object: a package-level
+// declaration, a method or a struct field
+func (r *RelationExtractor) usedSymbol(obj types.Object) *ExtractedSymbol {
+	switch o := obj.(type) {
+	case *types.Func:
+		return r.symbolOf(o.Origin())
+	case *types.TypeName, *types.Const:
+		return r.symbolOf(o)
+	case *types.Var:
+		o = o.Origin()
+		if o.Pkg() == nil {
+			return nil
+		}
+		if o.IsField() {
+			owner := r.fieldOwner(o)
+			if owner == "" {
+				return nil
+			}
+			return r.symbols[fmt.Sprintf("%s:field:%s.%s", o.Pkg().Path(), owner, o.Name())]
+		}
+		if o.Parent() != o.Pkg().Scope() {
+			return nil // local variable or parameter
+		}
+		return r.symbolOf(o)
+	}
+	return nil
+}
+
+// fieldOwner returns the name of the package-level struct type declaring a
+// field, or ""
+func (r *RelationExtractor) fieldOwner(field *types.Var) string {
+	if r.fieldOwners == nil {
+		r.fieldOwners = make(map[*types.Package]map[*types.Var]string)
+	}
 
-	// Try to find by name matching
-	for _, sym := range r.symbols {
-		if strings.Contains(id, sym.Name) {
-			return sym
+	owners, ok := r.fieldOwners[field.Pkg()]
+	if !ok {
+		owners = make(map[*types.Var]string)
+		scope := field.Pkg().Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			st, ok := obj.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			for i := 0; i < st.NumFields(); i++ {
+				owners[st.Field(i)] = obj.Name()
+			}
 		}
+		r.fieldOwners[field.Pkg()] = owners
 	}
+	return owners[field]
+}
 
+// calleeIdent returns the called name of a call expression, e.g. Search in
+// "s.store.Search(q)" or Map in "Map[int](xs, f)", or nil; these are the
+// names getCalledFunction resolves
+func calleeIdent(callExpr *ast.CallExpr, info *types.Info) *ast.Ident {
+	switch fn := calledExpr(callExpr, info).(type) {
+	case *ast.Ident:
+		return fn
+	case *ast.SelectorExpr:
+		return fn.Sel
+	}
 	return nil
 }
 
+// calledExpr returns the function expression of a call without parentheses
+// and the type arguments of an explicitly instantiated generic function
+func calledExpr(callExpr *ast.CallExpr, info *types.Info) ast.Expr {
+	fun := ast.Unparen(callExpr.Fun)
+	var x ast.Expr
+	switch fn := fun.(type) {
+	case *ast.IndexExpr:
+		x = fn.X
+	case *ast.IndexListExpr:
+		x = fn.X
+	default:
+		return fun
+	}
+
+	// Indexing a slice or map of functions is not an instantiation
+	ident, ok := x.(*ast.Ident)
+	if sel, isSel := x.(*ast.SelectorExpr); isSel {
+		ident, ok = sel.Sel, true
+	}
+	if !ok || info == nil {
+		return fun
+	}
+	if _, ok := info.Instances[ident]; !ok {
+		return fun
+	}
+	return x
+}
+
+// embeddedIdents returns the names in the embedded fields of a struct type
+func embeddedIdents(spec *ast.TypeSpec) map[*ast.Ident]bool {
+	idents := make(map[*ast.Ident]bool)
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok || structType.Fields == nil {
+		return idents
+	}
+	for _, field := range structType.Fields.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		ast.Inspect(field.Type, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				idents[ident] = true
+			}
+			return true
+		})
+	}
+	return idents
+}
+
+// Helper methods
+
+func (r *RelationExtractor) isStdLib(importPath string) bool {
+	// Standard library packages don't contain a dot
+	return !strings.Contains(importPath, ".")
+}
+
+func (r *RelationExtractor) findSymbolByObj(obj types.Object) *ExtractedSymbol {
+	if obj == nil {
+		return nil
+	}
+
+	// Handle builtin types (nil package)
+	if obj.Pkg() == nil {
+		return nil
+	}
+
+	// Try to find symbol by object name and package
+	if sym := r.symbolOf(obj); sym != nil {
+		return sym
+	}
+	return r.symbols[fmt.Sprintf("pkg:%s", obj.Pkg().Path())]
+}
+
+// symbolOf returns the symbol extracted for an object, without falling back
+// to its package
+func (r *RelationExtractor) symbolOf(obj types.Object) *ExtractedSymbol {
+	if obj == nil {
+		return nil
+	}
+	var fset *token.FileSet
+	if r.pkg != nil {
+		fset = r.pkg.Fset
+	}
+	return r.symbols[declSymbolID(obj, fset, r.repoPath)]
+}
+
 func (r *RelationExtractor) findSymbolByType(typ types.Type) *ExtractedSymbol {
 	if typ == nil {
 		return nil
@@ -417,14 +718,6 @@ func (r *RelationExtractor) findSymbolByType(typ types.Type) *ExtractedSymbol {
 	return nil
 }
 
-func (r *RelationExtractor) isNodeInRange(node ast.Node, container ast.Node, file *ast.File) bool {
-	nodePos := r.pkg.Fset.Position(node.Pos())
-	containerPos := r.pkg.Fset.Position(container.Pos())
-	containerEnd := r.pkg.Fset.Position(container.End())
-
-	return nodePos.Line >= containerPos.Line && nodePos.Line <= containerEnd.Line
-}
-
 func (r *RelationExtractor) getCalledFunction(callExpr *ast.CallExpr, info *types.Info) types.Object {
 	// Get the function being called
 	funType := info.TypeOf(callExpr.Fun)
@@ -433,7 +726,7 @@ func (r *RelationExtractor) getCalledFunction(callExpr *ast.CallExpr, info *type
 	}
 
 	// Handle different types of callees
-	switch fn := callExpr.Fun.(type) {
+	switch fn := calledExpr(callExpr, info).(type) {
 	case *ast.Ident:
 		return info.ObjectOf(fn)
 	case *ast.SelectorExpr:
@@ -447,22 +740,90 @@ func (r *RelationExtractor) getCalledFunction(callExpr *ast.CallExpr, info *type
 	return nil
 }
 
-func (r *RelationExtractor) objKindToSymKind(obj types.Object) string {
+// objectSymbolID returns the ID the symbol extractor gives an object, with
+// "builtin" as the package of predeclared objects like error
+func objectSymbolID(obj types.Object) string {
+	pkgPath := "builtin"
+	if obj.Pkg() != nil {
+		pkgPath = obj.Pkg().Path()
+	}
+	name := obj.Name()
+	if recv := receiverName(obj); recv != "" {
+		name = recv + "." + name
+	}
+	return fmt.Sprintf("%s:%s:%s", pkgPath, objKindToSymKind(obj), name)
+}
+
+// declSymbolID returns the ID the symbol extractor gives an object declared
+// in the repository, including the position for init functions and blank
+// identifiers
+func declSymbolID(obj types.Object, fset *token.FileSet, repoPath string) string {
+	id := objectSymbolID(obj)
+	if fset == nil || !needsPosition(objKindToSymKind(obj), obj.Name()) {
+		return id
+	}
+	pos := fset.Position(obj.Pos())
+	return positionedID(id, toRelPath(repoPath, pos.Filename), pos.Line)
+}
+
+func objKindToSymKind(obj types.Object) string {
 	switch obj.(type) {
 	case *types.Func:
 		if sig, ok := obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+			if types.IsInterface(sig.Recv().Type()) {
+				return "interface-method"
+			}
 			return "method"
 		}
 		return "func"
 	case *types.TypeName:
+		switch obj.Type().Underlying().(type) {
+		case *types.Struct:
+			return "struct"
+		case *types.Interface:
+			return "interface"
+		}
 		return "type"
 	case *types.Var:
 		return "var"
+	case *types.Const:
+		return "const"
 	default:
 		return "symbol"
 	}
 }
 
+// calleePos returns the position of the called name, e.g. of Search in
+// "s.store.Search(q)"
+func calleePos(callExpr *ast.CallExpr, info *types.Info) token.Pos {
+	if ident := calleeIdent(callExpr, info); ident != nil {
+		return ident.Pos()
+	}
+	return callExpr.Fun.Pos()
+}
+
+// receiverName returns the name of the type declaring a method, or "" for
+// other objects and methods of unnamed interfaces
+func receiverName(obj types.Object) string {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return ""
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return ""
+	}
+
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	if named, ok := recv.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return ""
+}
+
 // GetEdges returns all extracted edges
 func (r *RelationExtractor) GetEdges() []*Edge {
 	return r.edges
@@ -204,6 +204,11 @@ func MainFunc() string {
 		if edge.EdgeType == "calls" {
 			hasCallEdge = true
 			t.Logf("Found call edge: %s -> %s", edge.FromID, edge.ToID)
+
+			// The site is the called name in "return Helper()"
+			if edge.FilePath != "mypkg/lib.go" || edge.Line != 9 || edge.Column != 9 {
+				t.Errorf("call site = %s:%d:%d, want mypkg/lib.go:9:9", edge.FilePath, edge.Line, edge.Column)
+			}
 		}
 	}
 
@@ -384,3 +389,299 @@ func TestRelationConverter_ConvertToStoreEdges(t *testing.T) {
 		}
 	}
 }
+
+func TestRelationExtractor_DispatchEdges(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module testrepo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	pkgDir := filepath.Join(tmpDir, "mypkg")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	pkgFile := filepath.Join(pkgDir, "lib.go")
+	if err := os.WriteFile(pkgFile, []byte(`
+package mypkg
+
+type Client interface {
+	Embed(text string) []float32
+}
+
+type LocalClient struct{}
+
+func (c *LocalClient) Embed(text string) []float32 { return nil }
+
+type base struct{}
+
+func (base) Embed(text string) []float32 { return nil }
+
+type RemoteClient struct {
+	base
+}
+
+type Service struct {
+	client Client
+}
+
+func (s *Service) Run() []float32 {
+	return s.client.Embed("query")
+}
+`), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	loader := NewPackageLoader()
+	pkg, err := loader.LoadFile(pkgFile)
+	if err != nil {
+		t.Fatalf("failed to load package: %v", err)
+	}
+
+	symbols, err := NewSymbolExtractor(pkg, tmpDir).Extract()
+	if err != nil {
+		t.Fatalf("failed to extract symbols: %v", err)
+	}
+
+	edges, err := NewRelationExtractor(pkg, tmpDir, symbols).ExtractAll()
+	if err != nil {
+		t.Fatalf("failed to extract relations: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, edge := range edges {
+		found[edge.EdgeType+" "+edge.FromID+" -> "+edge.ToID] = true
+	}
+
+	p := pkg.PkgPath
+	want := []string{
+		"implements " + p + ":struct:LocalClient -> " + p + ":interface:Client",
+		"calls " + p + ":method:Service.Run -> " + p + ":interface-method:Client.Embed",
+		"dispatches " + p + ":interface-method:Client.Embed -> " + p + ":method:LocalClient.Embed",
+		// Promoted methods dispatch to the embedded type's method
+		"dispatches " + p + ":interface-method:Client.Embed -> " + p + ":method:base.Embed",
+	}
+	for _, w := range want {
+		if !found[w] {
+			t.Errorf("missing edge %q", w)
+		}
+	}
+}
+
+func TestPipeline_ReferenceEdges(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module testrepo\n\ngo 1.21\n",
+		"model/model.go": `package model
+
+const DefaultLimit = 10
+
+var Registry = map[string]*Order{}
+
+type Status int
+
+type Order struct {
+	ID     string
+	Status Status
+}
+
+func (o *Order) Close() {}
+`,
+		"service/service.go": `package service
+
+import "testrepo/model"
+
+var defaultOrder model.Order
+
+func Find(id string) (*model.Order, error) {
+	o := &model.Order{ID: id}
+	if len(id) > model.DefaultLimit {
+		return model.Registry[id], nil
+	}
+	o.Close()
+	return o, nil
+}
+
+func Check(v any) func() {
+	if o, ok := v.(*model.Order); ok {
+		return o.Close
+	}
+	return nil
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	_, edges, err := NewPipeline().ExtractRepositoryWithRelations(tmpDir)
+	if err != nil {
+		t.Fatalf("ExtractRepositoryWithRelations failed: %v", err)
+	}
+	set := edgeSet(edges)
+
+	const m, s = "testrepo/model:", "testrepo/service:"
+	want := []string{
+		// Parameter, result, composite literal and type assertion types
+		"references " + s + "func:Find -> " + m + "struct:Order",
+		"references " + s + "func:Check -> " + m + "struct:Order",
+		// Variable declaration
+		"references " + s + "var:defaultOrder -> " + m + "struct:Order",
+		// Const and var reads
+		"references " + s + "func:Find -> " + m + "const:DefaultLimit",
+		"references " + s + "func:Find -> " + m + "var:Registry",
+		// Field in a composite literal, field type, method value
+		"references " + s + "func:Find -> " + m + "field:Order.ID",
+		"references " + m + "struct:Order -> " + m + "type:Status",
+		"references " + s + "func:Check -> " + m + "method:Order.Close",
+		// Calls stay call edges
+		"calls " + s + "func:Find -> " + m + "method:Order.Close",
+	}
+	for _, key := range want {
+		if !set[key] {
+			t.Errorf("missing edge %s", key)
+		}
+	}
+
+	unwanted := []string{
+		"references " + s + "func:Find -> " + m + "method:Order.Close",
+		// The receiver type declares the method
+		"references " + m + "method:Order.Close -> " + m + "struct:Order",
+	}
+	for _, key := range unwanted {
+		if set[key] {
+			t.Errorf("unexpected edge %s", key)
+		}
+	}
+
+	// One edge per site: Order is used twice in Find
+	sites := 0
+	for _, edge := range edges {
+		if edge.EdgeType == "references" && edge.FromID == s+"func:Find" && edge.ToID == m+"struct:Order" {
+			sites++
+		}
+	}
+	if sites != 2 {
+		t.Errorf("Find -> Order has %d sites, want 2", sites)
+	}
+}
+
+func TestPipeline_GenericEdges(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module testrepo\n\ngo 1.21\n",
+		"gen/gen.go": `package gen
+
+type Number interface{ ~int | ~float64 }
+
+// Sum adds values
+func Sum[T Number](values []T) T {
+	var total T
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+type List[T any] struct {
+	items []T
+}
+
+func (l *List[T]) Push(v T) { l.items = append(l.items, v) }
+
+func Map[T, U any](xs []T, f func(T) U) []U {
+	var out []U
+	for _, x := range xs {
+		out = append(out, f(x))
+	}
+	return out
+}
+
+type Set[K comparable, V interface{ Number | ~string }] map[K]V
+`,
+		"use/use.go": `package use
+
+import "testrepo/gen"
+
+func Use() int {
+	l := &gen.List[int]{}
+	l.Push(1)
+	_ = gen.Map[int, string]([]int{1}, func(i int) string { return "" })
+	return gen.Sum([]int{1, 2})
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	symbols, edges, err := NewPipeline().ExtractRepositoryWithRelations(tmpDir)
+	if err != nil {
+		t.Fatalf("ExtractRepositoryWithRelations failed: %v", err)
+	}
+
+	const g, u = "testrepo/gen:", "testrepo/use:"
+	byID := make(map[string]*ExtractedSymbol)
+	for _, sym := range symbols {
+		byID[sym.ID] = sym
+	}
+	signatures := map[string]string{
+		g + "func:Sum":         "func Sum[T Number](values []T) (T)",
+		g + "func:Map":         "func Map[T, U any](xs []T, f func) ([]U)",
+		g + "method:List.Push": "func (l *List[T]) Push(v T)",
+		g + "type:Set":         "type Set[K comparable, V interface{ Number | ~string }] map[K]V",
+		g + "interface:Number": "type Number interface {\n\t~int | ~float64\n}",
+	}
+	for id, want := range signatures {
+		sym := byID[id]
+		if sym == nil {
+			t.Errorf("missing symbol %s", id)
+			continue
+		}
+		if sym.Signature != want {
+			t.Errorf("signature of %s = %q, want %q", id, sym.Signature, want)
+		}
+	}
+	if sym := byID[g+"type:Set"]; sym != nil {
+		want := []TypeParam{{Name: "K", Constraint: "comparable"}, {Name: "V", Constraint: "interface{ Number | ~string }"}}
+		if len(sym.TypeParams) != 2 || sym.TypeParams[0] != want[0] || sym.TypeParams[1] != want[1] {
+			t.Errorf("type params of Set = %+v", sym.TypeParams)
+		}
+	}
+
+	set := edgeSet(edges)
+	for _, key := range []string{
+		// Calls of instantiations point to the generic declarations
+		"calls " + u + "func:Use -> " + g + "func:Sum",
+		"calls " + u + "func:Use -> " + g + "func:Map",
+		"calls " + u + "func:Use -> " + g + "method:List.Push",
+		"constrains " + g + "func:Sum -> " + g + "interface:Number",
+		"constrains " + g + "type:Set -> " + g + "interface:Number",
+	} {
+		if !set[key] {
+			t.Errorf("missing edge %s", key)
+		}
+	}
+	for _, key := range []string{
+		"references " + g + "func:Sum -> " + g + "interface:Number",
+		"references " + u + "func:Use -> " + g + "func:Map",
+	} {
+		if set[key] {
+			t.Errorf("unexpected edge %s", key)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// isTestFile reports whether a file is only compiled by go test
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// IsTestSymbol reports whether a symbol belongs to the test layer: a test,
+// a declaration of a test file or an external test package
+func IsTestSymbol(sym *ExtractedSymbol) bool {
+	return sym.Kind == "test" || isTestFile(sym.FilePath) || strings.HasSuffix(sym.PackageName, "_test")
+}
+
+// isTestFunc reports whether a function of a test file is run by go test:
+// TestXxx(*testing.T), BenchmarkXxx(*testing.B), FuzzXxx(*testing.F) or
+// ExampleXxx()
+func isTestFunc(decl *ast.FuncDecl) bool {
+	if decl.Recv != nil || decl.Type.TypeParams != nil || decl.Type.Results != nil {
+		return false
+	}
+
+	name := decl.Name.Name
+	params := decl.Type.Params.List
+	switch {
+	case hasTestPrefix(name, "Example"):
+		return len(params) == 0
+	case hasTestPrefix(name, "Test"):
+		return isTestingParam(params, "T")
+	case hasTestPrefix(name, "Benchmark"):
+		return isTestingParam(params, "B")
+	case hasTestPrefix(name, "Fuzz"):
+		return isTestingParam(params, "F")
+	}
+	return false
+}
+
+// hasTestPrefix reports whether name is prefix followed by nothing or by a
+// character that is not a lower case letter, as go test requires
+func hasTestPrefix(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// isTestingParam reports whether params is a single *testing.<typeName>
+func isTestingParam(params []*ast.Field, typeName string) bool {
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return false
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "testing" && sel.Sel.Name == typeName
+}
+
+// testEdges returns a tests edge from each test to every production symbol
+// it calls, directly or through helpers declared in test files. An edge
+// keeps the site of the call into production code.
+func testEdges(symbols map[string]*ExtractedSymbol, edges []*Edge) []*Edge {
+	calls := make(map[string][]*Edge)
+	for _, edge := range edges {
+		if edge.EdgeType == "calls" || edge.EdgeType == "dispatches" {
+			calls[edge.FromID] = append(calls[edge.FromID], edge)
+		}
+	}
+
+	var tests []*ExtractedSymbol
+	for _, sym := range symbols {
+		if sym.Kind == "test" {
+			tests = append(tests, sym)
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		return tests[i].ID < tests[j].ID
+	})
+
+	var result []*Edge
+	for _, test := range tests {
+		seen := make(map[string]bool)
+		visited := map[string]bool{test.ID: true}
+		queue := []string{test.ID}
+		for depth := 0; len(queue) > 0; depth++ {
+			var next []string
+			for _, fromID := range queue {
+				for _, call := range calls[fromID] {
+					callee := symbols[call.ToID]
+					if callee == nil || callee.FilePath == "" {
+						continue
+					}
+					if isTestFile(callee.FilePath) {
+						if !visited[callee.ID] {
+							visited[callee.ID] = true
+							next = append(next, callee.ID)
+						}
+						continue
+					}
+
+					edge := &Edge{
+						FromID:     test.ID,
+						ToID:       callee.ID,
+						EdgeType:   "tests",
+						Weight:     5, // Medium weight for direct calls
+						Provenance: call.Provenance,
+						FilePath:   call.FilePath,
+						Line:       call.Line,
+						Column:     call.Column,
+					}
+					if depth > 0 {
+						edge.Weight = 3 // Lower weight through helpers
+					}
+					key := fmt.Sprintf("%s|%s:%d:%d", edge.ToID, edge.FilePath, edge.Line, edge.Column)
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					result = append(result, edge)
+				}
+			}
+			queue = next
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,155 @@
+package ast
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestsRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module testrepo\n\ngo 1.21\n",
+		"calc/calc.go": `package calc
+
+func Add(a, b int) int { return a + b }
+
+func Mul(a, b int) int { return a * b }
+`,
+		"calc/calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("wrong sum")
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		square(2)
+	}
+}
+
+func square(n int) int { return Mul(n, n) }
+
+func Testify(t *testing.T) {}
+
+func TestMain(m *testing.M) {}
+`,
+		"calc/example_test.go": `package calc_test
+
+import (
+	"fmt"
+	"testing"
+
+	"testrepo/calc"
+)
+
+func ExampleAdd() {
+	fmt.Println(calc.Add(1, 2))
+	// Output: 3
+}
+
+func FuzzAdd(f *testing.F) {
+	f.Fuzz(func(t *testing.T, a int) { calc.Add(a, a) })
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestPipeline_TestEdges(t *testing.T) {
+	root := writeTestsRepo(t)
+
+	p := NewPipeline()
+	p.SetTests(true)
+	symbols, edges, err := p.ExtractRepositoryWithRelations(root)
+	if err != nil {
+		t.Fatalf("ExtractRepositoryWithRelations failed: %v", err)
+	}
+
+	const c, x = "testrepo/calc:", "testrepo/calc_test:"
+	kinds := make(map[string]string)
+	for _, sym := range symbols {
+		kinds[sym.ID] = sym.Kind
+		if sym.ID == "pkg:testrepo/calc" {
+			for _, imp := range sym.Imports {
+				if imp == "testing" {
+					t.Errorf("package imports include the imports of tests: %v", sym.Imports)
+				}
+			}
+		}
+	}
+	for id, want := range map[string]string{
+		c + "func:Add":          "func",
+		c + "func:TestAdd":      "test",
+		c + "func:BenchmarkMul": "test",
+		c + "func:square":       "func",
+		c + "func:Testify":      "func",
+		c + "func:TestMain":     "func",
+		x + "func:ExampleAdd":   "test",
+		x + "func:FuzzAdd":      "test",
+	} {
+		if kinds[id] != want {
+			t.Errorf("kind of %s = %q, want %q", id, kinds[id], want)
+		}
+	}
+
+	set := edgeSet(edges)
+	for _, key := range []string{
+		"tests " + c + "func:TestAdd -> " + c + "func:Add",
+		"tests " + c + "func:BenchmarkMul -> " + c + "func:Mul",
+		"tests " + x + "func:ExampleAdd -> " + c + "func:Add",
+		"tests " + x + "func:FuzzAdd -> " + c + "func:Add",
+	} {
+		if !set[key] {
+			t.Errorf("missing edge %s", key)
+		}
+	}
+	for _, key := range []string{
+		// Edges of test code are replaced by tests edges
+		"calls " + c + "func:TestAdd -> " + c + "func:Add",
+		"calls " + c + "func:square -> " + c + "func:Mul",
+		"tests " + c + "func:BenchmarkMul -> " + c + "func:square",
+		"imports pkg:" + x[:len(x)-1] + " -> pkg:testrepo/calc",
+	} {
+		if set[key] {
+			t.Errorf("unexpected edge %s", key)
+		}
+	}
+
+	for _, edge := range edges {
+		if edge.EdgeType != "tests" {
+			continue
+		}
+		if edge.Line == 0 {
+			t.Errorf("tests edge %s -> %s has no site", edge.FromID, edge.ToID)
+		}
+		if edge.FromID == c+"func:BenchmarkMul" && (edge.Weight != 3 || edge.Line != 17) {
+			t.Errorf("tests edge through helper = weight %d, line %d; want 3, 17", edge.Weight, edge.Line)
+		}
+	}
+
+	// Without tests, test files are not extracted
+	symbols, _, err = NewPipeline().ExtractRepositoryWithRelations(root)
+	if err != nil {
+		t.Fatalf("ExtractRepositoryWithRelations failed: %v", err)
+	}
+	for _, sym := range symbols {
+		if IsTestSymbol(sym) {
+			t.Errorf("unexpected test symbol %s without tests", sym.ID)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -27,9 +28,12 @@ type RepoConfig struct {
 	Path string `yaml:"path,omitempty"`
 }
 
+// LocalEmbeddingModel is the model name of the local embedding provider
+const LocalEmbeddingModel = "hashed-ngram-v1"
+
 // EmbeddingConfig holds embedding service configuration
 type EmbeddingConfig struct {
-	Provider string `yaml:"provider"` // "volcengine" | "openai" | "local"
+	Provider string `yaml:"provider"` // "volcengine" | "openai" | "openai_compatible" | "local" (offline hashed n-grams)
 
 	// VolcEngine specific
 	APIKey   string `yaml:"api_key"`
@@ -40,10 +44,33 @@ type EmbeddingConfig struct {
 	OpenAIAPIKey string `yaml:"openai_api_key,omitempty"`
 	OpenAIModel  string `yaml:"openai_model,omitempty"`
 
+	// OpenAI-compatible endpoint (Ollama, vLLM, LM Studio, ...); uses model and api_key
+	BaseURL        string            `yaml:"base_url,omitempty"`        // e.g. http://localhost:11434/v1
+	Headers        map[string]string `yaml:"headers,omitempty"`         // Extra request headers
+	RequestTimeout time.Duration     `yaml:"request_timeout,omitempty"` // Per-request timeout (default 30s)
+
 	// Embedding parameters
 	Dimensions     int    `yaml:"dimensions"`      // 1024 | 2048
 	BatchSize      int    `yaml:"batch_size"`      // Batch size for embedding
 	EncodingFormat string `yaml:"encoding_format"` // "float" | "base64"
+
+	// Retries with exponential backoff and jitter (429, 5xx, network errors)
+	MaxRetries     int           `yaml:"max_retries,omitempty"`      // Default 5, -1 disables retries
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay,omitempty"` // Default 1s, doubled per attempt
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay,omitempty"`  // Default 30s
+
+	// Client-side rate limits shared by all workers (0 = unlimited)
+	RequestsPerMinute int `yaml:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `yaml:"tokens_per_minute,omitempty"` // Estimated at ~4 bytes per token
+
+	// Text embedded per symbol (see `bcindex debug embed-text`)
+	TextTemplate     string `yaml:"text_template,omitempty"`      // Go text/template, empty uses the built-in template
+	BodyExcerptLines int    `yaml:"body_excerpt_lines,omitempty"` // Default 30, -1 omits the body
+	MaxRelatedNames  int    `yaml:"max_related_names,omitempty"`  // Callers/callees listed, default 10, -1 omits them
+
+	// Embedding cache shared by all repositories and worktrees
+	CachePath    string `yaml:"cache_path,omitempty"`    // Default: ~/.bcindex/cache/embeddings.db
+	DisableCache bool   `yaml:"disable_cache,omitempty"` // Always call the provider
 }
 
 // DatabaseConfig holds database configuration
@@ -55,9 +82,60 @@ type DatabaseConfig struct {
 
 // IndexerConfig holds indexer-specific configuration
 type IndexerConfig struct {
-	MaxWorkers int      `yaml:"max_workers,omitempty"` // Maximum number of goroutines
+	MaxWorkers int      `yaml:"max_workers,omitempty"` // Maximum number of goroutines (also concurrent embedding batches)
 	SkipTests  bool     `yaml:"skip_tests,omitempty"`  // Skip test files
 	Exclude    []string `yaml:"exclude,omitempty"`     // Exclude patterns
+
+	// Optional embeddings of long function bodies, split into overlapping
+	// statement-aligned chunks that search maps back to their function
+	ChunkBodies  bool `yaml:"chunk_bodies,omitempty"`
+	ChunkLines   int  `yaml:"chunk_lines,omitempty"`   // Maximum lines per chunk (default 40)
+	ChunkOverlap int  `yaml:"chunk_overlap,omitempty"` // Lines shared by consecutive chunks (default 10)
+
+	// Standard library interfaces to find implementations of, e.g. "error",
+	// "fmt.Stringer", "io.Reader" or "net/http.Handler" (opt-in)
+	StdlibInterfaces []string `yaml:"stdlib_interfaces,omitempty"`
+
+	// Whole-program call graph added to the call edges of each package:
+	// "" (off) | "cha" | "vta" (more precise, slower)
+	CallGraph string `yaml:"call_graph,omitempty"`
+
+	// Index _test.go files as a separate test layer: tests, benchmarks, fuzz
+	// tests and examples get the test kind and tests edges to the production
+	// symbols they call (opt-in)
+	Tests bool `yaml:"tests,omitempty"`
+
+	// Combinations of build tags and target platform to index the
+	// repository under; symbols record the contexts they exist in.
+	// Empty indexes the host's context only.
+	BuildContexts []BuildContext `yaml:"build_contexts,omitempty"`
+}
+
+// BuildContext is a set of build tags and a target platform
+type BuildContext struct {
+	Name   string   `yaml:"name,omitempty"`   // Label recorded on symbols (default: goos/goarch+tags)
+	GOOS   string   `yaml:"goos,omitempty"`   // Target operating system (default: host)
+	GOARCH string   `yaml:"goarch,omitempty"` // Target architecture (default: host)
+	Tags   []string `yaml:"tags,omitempty"`   // Build tags
+}
+
+// Label returns the name of the context, or one derived from its platform
+// and tags, e.g. "linux/amd64+integration"
+func (b BuildContext) Label() string {
+	if b.Name != "" {
+		return b.Name
+	}
+	label := strings.Trim(b.GOOS+"/"+b.GOARCH, "/")
+	if len(b.Tags) > 0 {
+		if label != "" {
+			label += "+"
+		}
+		label += strings.Join(b.Tags, ",")
+	}
+	if label == "" {
+		label = "default"
+	}
+	return label
 }
 
 // SearchConfig holds search-specific configuration
@@ -68,6 +146,40 @@ type SearchConfig struct {
 	GraphWeight     float32 `yaml:"graph_weight,omitempty"`      // Graph ranking weight (0-1)
 	EnableGraphRank bool    `yaml:"enable_graph_rank,omitempty"` // Enable graph-based ranking
 	SynonymsFile    string  `yaml:"synonyms_file,omitempty"`     // Repo-relative synonyms file
+	ANNProbes       int     `yaml:"ann_probes,omitempty"`        // ANN clusters scanned per query (0 = auto)
+
+	// Fusion of vector and keyword results: "linear" blends their scores
+	// with vector_weight/keyword_weight, "rrf" (Reciprocal Rank Fusion)
+	// weighs their ranks as weight / (rrf_k + rank)
+	Fusion string `yaml:"fusion,omitempty"`
+	RRFK   int    `yaml:"rrf_k,omitempty"`
+
+	// bm25() weights of keyword matches on the symbol name, its semantic
+	// text and its identifier tokens (split names, signature, receiver and
+	// package path)
+	BM25NameWeight   float64 `yaml:"bm25_name_weight,omitempty"`
+	BM25TextWeight   float64 `yaml:"bm25_text_weight,omitempty"`
+	BM25TokensWeight float64 `yaml:"bm25_tokens_weight,omitempty"`
+
+	// Optional rerank stage after fusion and graph ranking
+	Rerank RerankConfig `yaml:"rerank,omitempty"`
+}
+
+// RerankConfig configures a model that reorders the top search candidates
+// by their relevance to the query
+type RerankConfig struct {
+	// Protocol of the endpoint; empty disables reranking
+	//   rerank: a /rerank API (Cohere, Jina, vLLM, Xinference, gateways)
+	//   chat:   an OpenAI-compatible /chat/completions API, prompted for scores
+	Provider string `yaml:"provider,omitempty"`
+
+	BaseURL      string            `yaml:"base_url,omitempty"`      // e.g. http://localhost:8000/v1
+	APIKey       string            `yaml:"api_key,omitempty"`       // Sent as a bearer token (optional)
+	Model        string            `yaml:"model,omitempty"`         // Model name
+	Headers      map[string]string `yaml:"headers,omitempty"`       // Extra request headers
+	TopN         int               `yaml:"top_n,omitempty"`         // Candidates reranked (default 20)
+	Timeout      time.Duration     `yaml:"timeout,omitempty"`       // Time budget per query (default 5s)
+	DisableCache bool              `yaml:"disable_cache,omitempty"` // Do not reuse scores of earlier queries
 }
 
 // EvidenceConfig holds evidence pack configuration
@@ -198,14 +310,24 @@ func (c *Config) applyDefaults() error {
 		c.Embedding.Provider = "volcengine"
 	}
 
-	// Set default model
-	if c.Embedding.Model == "" {
-		c.Embedding.Model = "doubao-embedding-vision-250615"
-	}
-
-	// Set default dimensions
-	if c.Embedding.Dimensions == 0 {
-		c.Embedding.Dimensions = 2048
+	// Set default model and dimensions
+	switch c.Embedding.Provider {
+	case "openai_compatible":
+		// No default model; dimensions are detected
+	case "local":
+		if c.Embedding.Model == "" {
+			c.Embedding.Model = LocalEmbeddingModel
+		}
+		if c.Embedding.Dimensions == 0 {
+			c.Embedding.Dimensions = 1024
+		}
+	default:
+		if c.Embedding.Model == "" {
+			c.Embedding.Model = "doubao-embedding-vision-250615"
+		}
+		if c.Embedding.Dimensions == 0 {
+			c.Embedding.Dimensions = 2048
+		}
 	}
 
 	// Set default batch size
@@ -218,6 +340,12 @@ func (c *Config) applyDefaults() error {
 		c.Embedding.EncodingFormat = "float"
 	}
 
+	// Set default embedding cache path
+	if c.Embedding.CachePath == "" {
+		c.Embedding.CachePath = "~/.bcindex/cache/embeddings.db"
+	}
+	c.Embedding.CachePath = expandPath(c.Embedding.CachePath)
+
 	// Expand ~ in database path
 	if c.Database.Path != "" {
 		c.Database.Path = expandPath(c.Database.Path)
@@ -235,6 +363,15 @@ func (c *Config) applyDefaults() error {
 	if c.Indexer.MaxWorkers == 0 {
 		c.Indexer.MaxWorkers = 4
 	}
+	if c.Indexer.ChunkLines == 0 {
+		c.Indexer.ChunkLines = 40
+	}
+	if c.Indexer.ChunkOverlap == 0 {
+		c.Indexer.ChunkOverlap = 10
+	}
+	for i := range c.Indexer.BuildContexts {
+		c.Indexer.BuildContexts[i].Name = c.Indexer.BuildContexts[i].Label()
+	}
 
 	// Set default search options
 	if c.Search.DefaultTopK == 0 {
@@ -249,6 +386,23 @@ func (c *Config) applyDefaults() error {
 	if c.Search.SynonymsFile == "" {
 		c.Search.SynonymsFile = "domain_aliases.yaml"
 	}
+	if c.Search.Fusion == "" {
+		c.Search.Fusion = "linear"
+	}
+	if c.Search.RRFK == 0 {
+		c.Search.RRFK = 60
+	}
+	if c.Search.BM25NameWeight == 0 && c.Search.BM25TextWeight == 0 && c.Search.BM25TokensWeight == 0 {
+		c.Search.BM25NameWeight = 10
+		c.Search.BM25TextWeight = 1
+		c.Search.BM25TokensWeight = 5
+	}
+	if c.Search.Rerank.TopN == 0 {
+		c.Search.Rerank.TopN = 20
+	}
+	if c.Search.Rerank.Timeout == 0 {
+		c.Search.Rerank.Timeout = 5 * time.Second
+	}
 
 	// Set default evidence options
 	if c.Evidence.MaxPackages == 0 {
@@ -290,13 +444,33 @@ func (c *Config) Validate() error {
 		if c.Embedding.OpenAIAPIKey == "" {
 			return fmt.Errorf("openai provider requires openai_api_key")
 		}
+	case "openai_compatible":
+		if c.Embedding.BaseURL == "" {
+			return fmt.Errorf("openai_compatible provider requires base_url")
+		}
+		if c.Embedding.Model == "" {
+			return fmt.Errorf("openai_compatible provider requires model")
+		}
+	case "local":
+		// Runs in-process, no credentials needed
 	default:
 		return fmt.Errorf("unsupported embedding provider: %s", c.Embedding.Provider)
 	}
 
 	// Validate dimensions
-	if c.Embedding.Dimensions != 1024 && c.Embedding.Dimensions != 2048 {
-		return fmt.Errorf("dimensions must be 1024 or 2048, got: %d", c.Embedding.Dimensions)
+	switch c.Embedding.Provider {
+	case "openai_compatible":
+		if c.Embedding.Dimensions < 0 {
+			return fmt.Errorf("dimensions must not be negative, got: %d", c.Embedding.Dimensions)
+		}
+	case "local":
+		if c.Embedding.Dimensions < 64 || c.Embedding.Dimensions > 8192 {
+			return fmt.Errorf("local provider dimensions must be between 64 and 8192, got: %d", c.Embedding.Dimensions)
+		}
+	default:
+		if c.Embedding.Dimensions != 1024 && c.Embedding.Dimensions != 2048 {
+			return fmt.Errorf("dimensions must be 1024 or 2048, got: %d", c.Embedding.Dimensions)
+		}
 	}
 
 	// Validate batch size
@@ -304,6 +478,59 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("batch_size must be between 1 and 100, got: %d", c.Embedding.BatchSize)
 	}
 
+	// Validate body chunking
+	if c.Indexer.ChunkLines < 1 {
+		return fmt.Errorf("chunk_lines must be positive, got: %d", c.Indexer.ChunkLines)
+	}
+	if c.Indexer.ChunkOverlap < 0 || c.Indexer.ChunkOverlap >= c.Indexer.ChunkLines {
+		return fmt.Errorf("chunk_overlap must be between 0 and chunk_lines-1, got: %d", c.Indexer.ChunkOverlap)
+	}
+
+	// Validate call graph mode
+	switch c.Indexer.CallGraph {
+	case "", "cha", "vta":
+	default:
+		return fmt.Errorf("call_graph must be cha or vta, got: %s", c.Indexer.CallGraph)
+	}
+
+	// Validate score fusion
+	if c.Search.Fusion != "linear" && c.Search.Fusion != "rrf" {
+		return fmt.Errorf("fusion must be linear or rrf, got: %s", c.Search.Fusion)
+	}
+	if c.Search.RRFK < 1 {
+		return fmt.Errorf("rrf_k must be positive, got: %d", c.Search.RRFK)
+	}
+	if c.Search.BM25NameWeight < 0 || c.Search.BM25TextWeight < 0 || c.Search.BM25TokensWeight < 0 {
+		return fmt.Errorf("bm25 weights must not be negative")
+	}
+
+	// Validate rerank stage
+	switch c.Search.Rerank.Provider {
+	case "":
+	case "rerank", "chat":
+		if c.Search.Rerank.BaseURL == "" {
+			return fmt.Errorf("rerank requires base_url")
+		}
+		if c.Search.Rerank.Model == "" {
+			return fmt.Errorf("rerank requires model")
+		}
+		if c.Search.Rerank.TopN < 2 {
+			return fmt.Errorf("rerank top_n must be at least 2, got: %d", c.Search.Rerank.TopN)
+		}
+	default:
+		return fmt.Errorf("rerank provider must be rerank or chat, got: %s", c.Search.Rerank.Provider)
+	}
+
+	// Validate build contexts
+	contextNames := make(map[string]bool)
+	for _, bc := range c.Indexer.BuildContexts {
+		name := bc.Label()
+		if contextNames[name] {
+			return fmt.Errorf("build_contexts has duplicate name: %s", name)
+		}
+		contextNames[name] = true
+	}
+
 	return nil
 }
 
@@ -343,7 +570,7 @@ const defaultConfigTemplate = `# BCIndex Configuration
 # Default location: $HOME/.bcindex/config/bcindex.yaml
 
 embedding:
-  # Provider: "volcengine" or "openai"
+  # Provider: "volcengine", "openai", "openai_compatible" or "local"
   provider: volcengine
 
   # VolcEngine configuration
@@ -0,0 +1,35 @@
+package embedding
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Cache stores embeddings keyed by model, dimensions and text hash so that
+// unchanged texts are never sent to the provider twice
+type Cache interface {
+	// GetBatch returns the cached vectors for the given text hashes
+	GetBatch(model string, dimensions int, hashes []string) (map[string][]float32, error)
+	// PutBatch stores vectors for the given text hashes
+	PutBatch(model string, dimensions int, hashes []string, vectors [][]float32) error
+}
+
+// TextHash returns the cache key of an embedded text
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetCache enables the embedding cache for batch embedding (nil disables it)
+func (s *Service) SetCache(cache Cache) {
+	s.cache = cache
+}
+
+// cacheModel identifies the provider and model vectors were generated with
+func (s *Service) cacheModel() string {
+	model := s.cfg.Model
+	if s.cfg.Provider == "openai" && s.cfg.OpenAIModel != "" {
+		model = s.cfg.OpenAIModel
+	}
+	return s.cfg.Provider + "/" + model
+}
@@ -0,0 +1,75 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+type countingClient struct {
+	calls int
+	texts int
+}
+
+func (c *countingClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func (c *countingClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	c.calls++
+	c.texts += len(texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+func (c *countingClient) Dimensions() int { return 1 }
+
+type memoryCache map[string][]float32
+
+func (m memoryCache) GetBatch(model string, dimensions int, hashes []string) (map[string][]float32, error) {
+	found := make(map[string][]float32)
+	for _, hash := range hashes {
+		if vec, ok := m[model+hash]; ok {
+			found[hash] = vec
+		}
+	}
+	return found, nil
+}
+
+func (m memoryCache) PutBatch(model string, dimensions int, hashes []string, vectors [][]float32) error {
+	for i, hash := range hashes {
+		m[model+hash] = vectors[i]
+	}
+	return nil
+}
+
+func TestService_EmbedBatchUsesCache(t *testing.T) {
+	client := &countingClient{}
+	svc := &Service{
+		cfg:    &config.EmbeddingConfig{Provider: "test", Model: "m", Dimensions: 1, BatchSize: 2},
+		client: client,
+	}
+	svc.SetCache(memoryCache{})
+
+	if _, err := svc.EmbedBatch(context.Background(), []string{"a", "bb", "ccc"}); err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if client.texts != 3 {
+		t.Fatalf("provider embedded %d texts, want 3", client.texts)
+	}
+
+	vectors, err := svc.EmbedBatch(context.Background(), []string{"bb", "", "dddd", "a"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if client.texts != 4 {
+		t.Errorf("provider embedded %d texts, want only the new one", client.texts)
+	}
+	if vectors[0][0] != 2 || vectors[1] != nil || vectors[2][0] != 4 || vectors[3][0] != 1 {
+		t.Errorf("unexpected vectors: %v", vectors)
+	}
+}
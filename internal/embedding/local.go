@@ -0,0 +1,160 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+// LocalModel is the model name reported by the local provider
+const LocalModel = config.LocalEmbeddingModel
+
+// LocalClient implements Client fully in-process without network access.
+// Texts are split into identifier-aware words; words, word bigrams and
+// character trigrams are feature-hashed into a fixed-size vector with
+// sublinear term frequency and L2 normalization.
+type LocalClient struct {
+	dimensions int
+}
+
+// Feature weights: whole words carry most meaning, trigrams add robustness to
+// spelling and morphology (e.g. "handler" vs "handlers")
+const (
+	localWordWeight    = 1.0
+	localBigramWeight  = 0.5
+	localTrigramWeight = 0.25
+)
+
+// localStopwords are common words that carry little meaning in code search
+var localStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "to": true,
+	"in": true, "for": true, "is": true, "it": true, "on": true, "or": true,
+	"by": true, "with": true, "as": true, "be": true, "this": true, "that": true,
+	"func": true, "return": true, "returns": true, "error": true, "string": true,
+}
+
+// NewLocalClient creates a new local embedding client
+func NewLocalClient(cfg *config.EmbeddingConfig) (*LocalClient, error) {
+	if cfg.Dimensions <= 0 {
+		return nil, fmt.Errorf("local provider requires positive dimensions, got %d", cfg.Dimensions)
+	}
+	return &LocalClient{dimensions: cfg.Dimensions}, nil
+}
+
+// Embed generates an embedding for a single text
+func (c *LocalClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.vectorize(text), nil
+}
+
+// EmbedBatch generates embeddings for multiple texts
+func (c *LocalClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		embeddings[i] = c.vectorize(text)
+	}
+	return embeddings, nil
+}
+
+// Dimensions returns the dimension of the embeddings
+func (c *LocalClient) Dimensions() int {
+	return c.dimensions
+}
+
+// vectorize builds the hashed feature vector of a text
+func (c *LocalClient) vectorize(text string) []float32 {
+	counts := make(map[string]float64)
+
+	words := localWords(text)
+	for i, word := range words {
+		if !localStopwords[word] {
+			counts["w:"+word] += localWordWeight
+		}
+		if i > 0 {
+			counts["b:"+words[i-1]+" "+word] += localBigramWeight
+		}
+
+		padded := "^" + word + "$"
+		runes := []rune(padded)
+		for j := 0; j+3 <= len(runes); j++ {
+			counts["t:"+string(runes[j:j+3])] += localTrigramWeight
+		}
+	}
+
+	vector := make([]float32, c.dimensions)
+	for feature, count := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		// Signed hashing keeps collisions unbiased
+		sign := float32(1)
+		if sum&(1<<63) != 0 {
+			sign = -1
+		}
+		vector[sum%uint64(c.dimensions)] += sign * float32(1+math.Log(count))
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+
+	return vector
+}
+
+// localWords lowercases text and splits it into words, breaking identifiers
+// at camelCase, acronym, underscore and digit boundaries
+// (e.g. "parseHTTPRequest_v2" -> parse, http, request, v, 2).
+func localWords(text string) []string {
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		if len(current) > 0 {
+			prev := current[len(current)-1]
+			switch {
+			case unicode.IsDigit(r) != unicode.IsDigit(prev):
+				flush()
+			case unicode.IsUpper(r) && unicode.IsLower(prev):
+				flush()
+			case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				// End of an acronym: "HTTPRequest" -> "HTTP", "Request"
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return words
+}
@@ -0,0 +1,57 @@
+package embedding
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+func TestLocalWords(t *testing.T) {
+	got := localWords("parseHTTPRequest_v2(ctx) JSONDecoder")
+	want := []string{"parse", "http", "request", "v", "2", "ctx", "json", "decoder"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("localWords() = %v, want %v", got, want)
+	}
+}
+
+func TestLocalClient(t *testing.T) {
+	svc, err := NewService(&config.EmbeddingConfig{Provider: "local", Dimensions: 256, BatchSize: 10})
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	texts := []string{
+		"func CreateOrder(ctx context.Context, req *OrderRequest) (*Order, error)\nCreateOrder creates a new order",
+		"func NewOrder(items []Item) *Order\nNewOrder builds an order from items",
+		"func OpenDatabase(path string) (*DB, error)\nOpenDatabase opens the SQLite database",
+	}
+	vectors, err := svc.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if len(vectors) != 3 || len(vectors[0]) != 256 || svc.Dimensions() != 256 {
+		t.Fatalf("unexpected vectors: %d x %d", len(vectors), len(vectors[0]))
+	}
+
+	query, err := svc.Embed(context.Background(), "create order")
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	// Deterministic and normalized
+	again, _ := svc.Embed(context.Background(), "create order")
+	if !reflect.DeepEqual(query, again) {
+		t.Error("embeddings are not deterministic")
+	}
+	if s := Similarity(query, query); s < 0.999 || s > 1.001 {
+		t.Errorf("self similarity = %v, want 1", s)
+	}
+
+	order := Similarity(query, vectors[0])
+	database := Similarity(query, vectors[2])
+	if order <= database {
+		t.Errorf("similarity to CreateOrder (%v) should exceed OpenDatabase (%v)", order, database)
+	}
+}
@@ -109,7 +109,7 @@ func (c *OpenAIClient) EmbedBatch(ctx context.Context, texts []string) ([][]floa
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body)
 	}
 
 	var apiResp OpenAIEmbeddingResponse
@@ -0,0 +1,168 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+// defaultRequestTimeout is used when no per-request timeout is configured
+const defaultRequestTimeout = 30 * time.Second
+
+// OpenAICompatibleClient implements Client for any server speaking the OpenAI
+// /v1/embeddings protocol (Ollama, vLLM, LM Studio, self-hosted gateways)
+type OpenAICompatibleClient struct {
+	endpoint string
+	apiKey   string
+	model    string
+	headers  map[string]string
+	client   *http.Client
+
+	mu         sync.Mutex
+	dimensions int // configured, or detected from the first response
+}
+
+// NewOpenAICompatibleClient creates a new OpenAI-compatible embedding client
+func NewOpenAICompatibleClient(cfg *config.EmbeddingConfig) (*OpenAICompatibleClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai_compatible base_url is required")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("openai_compatible model is required")
+	}
+
+	timeout := cfg.RequestTimeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	return &OpenAICompatibleClient{
+		endpoint:   embeddingsEndpoint(cfg.BaseURL),
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		headers:    cfg.Headers,
+		dimensions: cfg.Dimensions,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}, nil
+}
+
+// embeddingsEndpoint appends /embeddings to a base URL such as
+// http://localhost:11434/v1 (a full .../embeddings URL is kept as is)
+func embeddingsEndpoint(baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(baseURL, "/embeddings") {
+		return baseURL
+	}
+	return baseURL + "/embeddings"
+}
+
+// Embed generates an embedding for a single text
+func (c *OpenAICompatibleClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts
+func (c *OpenAICompatibleClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	req := OpenAIEmbeddingRequest{
+		Input: texts,
+		Model: c.model,
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytesReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	for name, value := range c.headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, body)
+	}
+
+	var apiResp OpenAIEmbeddingResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(apiResp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Data))
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, data := range apiResp.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("invalid embedding index: %d", data.Index)
+		}
+		if err := c.checkDimensions(len(data.Embedding)); err != nil {
+			return nil, err
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+
+	return embeddings, nil
+}
+
+// checkDimensions records the dimension of the first vector and rejects
+// vectors of a different size afterwards
+func (c *OpenAICompatibleClient) checkDimensions(n int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dimensions == 0 {
+		c.dimensions = n
+		return nil
+	}
+	if n != c.dimensions {
+		return fmt.Errorf("embedding dimension mismatch: expected %d, got %d", c.dimensions, n)
+	}
+	return nil
+}
+
+// Dimensions returns the dimension of the embeddings (0 until detected)
+func (c *OpenAICompatibleClient) Dimensions() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dimensions
+}
@@ -0,0 +1,147 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+// newEmbeddingServer stands in for an OpenAI-compatible /v1/embeddings server.
+// It returns vectors of the given dimension in reverse order to exercise
+// index mapping.
+func newEmbeddingServer(t *testing.T, dim int, check func(r *http.Request, req OpenAIEmbeddingRequest)) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/embeddings" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		var req OpenAIEmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if check != nil {
+			check(r, req)
+		}
+
+		var resp OpenAIEmbeddingResponse
+		resp.Model = req.Model
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			vec := make([]float32, dim)
+			vec[0] = float32(len(req.Input[i]))
+			resp.Data = append(resp.Data, struct {
+				Embedding []float32 `json:"embedding"`
+				Index     int       `json:"index"`
+				Object    string    `json:"object"`
+			}{Embedding: vec, Index: i, Object: "embedding"})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOpenAICompatibleClient_EmbedBatch(t *testing.T) {
+	server := newEmbeddingServer(t, 8, func(r *http.Request, req OpenAIEmbeddingRequest) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get("X-Team"); got != "search" {
+			t.Errorf("X-Team = %q", got)
+		}
+		if req.Model != "nomic-embed-text" {
+			t.Errorf("model = %q", req.Model)
+		}
+	})
+
+	client, err := NewOpenAICompatibleClient(&config.EmbeddingConfig{
+		BaseURL: server.URL + "/v1/",
+		Model:   "nomic-embed-text",
+		APIKey:  "secret",
+		Headers: map[string]string{"X-Team": "search"},
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAICompatibleClient() error = %v", err)
+	}
+
+	if client.Dimensions() != 0 {
+		t.Errorf("Dimensions() before first request = %d, want 0", client.Dimensions())
+	}
+
+	vectors, err := client.EmbedBatch(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	for i, want := range []float32{1, 2, 3} {
+		if vectors[i][0] != want {
+			t.Errorf("vector %d belongs to the wrong input: %v", i, vectors[i])
+		}
+	}
+	if client.Dimensions() != 8 {
+		t.Errorf("Dimensions() = %d, want 8 (detected)", client.Dimensions())
+	}
+}
+
+func TestOpenAICompatibleClient_DimensionMismatch(t *testing.T) {
+	server := newEmbeddingServer(t, 8, nil)
+
+	client, err := NewOpenAICompatibleClient(&config.EmbeddingConfig{
+		BaseURL:    server.URL + "/v1",
+		Model:      "m",
+		Dimensions: 16,
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAICompatibleClient() error = %v", err)
+	}
+
+	if _, err := client.Embed(context.Background(), "text"); err == nil || !strings.Contains(err.Error(), "dimension mismatch") {
+		t.Errorf("Embed() error = %v, want dimension mismatch", err)
+	}
+}
+
+func TestOpenAICompatibleClient_RequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewOpenAICompatibleClient(&config.EmbeddingConfig{
+		BaseURL:        server.URL + "/v1/embeddings",
+		Model:          "m",
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAICompatibleClient() error = %v", err)
+	}
+
+	start := time.Now()
+	if _, err := client.Embed(context.Background(), "text"); err == nil {
+		t.Fatal("Embed() succeeded, want timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v, want the 50ms timeout to apply", elapsed)
+	}
+}
+
+func TestNewOpenAICompatibleClient_RequiresBaseURLAndModel(t *testing.T) {
+	if _, err := NewOpenAICompatibleClient(&config.EmbeddingConfig{Model: "m"}); err == nil {
+		t.Error("expected error without base_url")
+	}
+	if _, err := NewOpenAICompatibleClient(&config.EmbeddingConfig{BaseURL: "http://localhost"}); err == nil {
+		t.Error("expected error without model")
+	}
+}
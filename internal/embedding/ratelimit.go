@@ -0,0 +1,105 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a client-side limiter for requests and tokens per minute,
+// shared by all batch workers. Each limit is a token bucket that holds one
+// minute of budget and refills continuously.
+type rateLimiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+}
+
+type bucket struct {
+	capacity float64
+	perSec   float64
+	level    float64
+	updated  time.Time
+}
+
+// newRateLimiter returns nil (no limiting) when both limits are zero
+func newRateLimiter(requestsPerMinute, tokensPerMinute int) *rateLimiter {
+	if requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		requests: newBucket(requestsPerMinute),
+		tokens:   newBucket(tokensPerMinute),
+	}
+}
+
+func newBucket(perMinute int) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		level:    float64(perMinute),
+		updated:  time.Now(),
+	}
+}
+
+// reserve takes n units and returns how long the caller must wait for them
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.level += now.Sub(b.updated).Seconds() * b.perSec
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+	b.updated = now
+
+	// Requests larger than the bucket would never fit; let them drain it
+	if n > b.capacity {
+		n = b.capacity
+	}
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.perSec * float64(time.Second))
+}
+
+// wait blocks until one request carrying the given number of tokens fits
+// within the configured limits
+func (l *rateLimiter) wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	delay := l.requests.reserve(1, now)
+	if d := l.tokens.reserve(float64(tokens), now); d > delay {
+		delay = d
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// estimateTokens approximates the token count of texts (~4 bytes per token)
+func estimateTokens(texts []string) int {
+	tokens := 0
+	for _, text := range texts {
+		tokens += len(text)/4 + 1
+	}
+	return tokens
+}
@@ -0,0 +1,123 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry defaults, overridable via EmbeddingConfig
+const (
+	defaultMaxRetries     = 5
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// APIError is returned by clients when the embedding API answers with a
+// non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // from the Retry-After header, if any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// newAPIError builds an APIError from a response and its body
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(value); err == nil {
+			apiErr.RetryAfter = time.Until(at)
+		}
+	}
+	return apiErr
+}
+
+// isRetryable reports whether a failed request may succeed when repeated:
+// rate limiting, server errors and transport failures
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryDelay returns the backoff before retry attempt n (0-based): the base
+// delay doubled per attempt, capped, with jitter in [delay/2, delay]
+func retryDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base << attempt
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// embedWithRetry sends one batch to the client, waiting for the rate limiter
+// and retrying retryable failures with exponential backoff
+func (s *Service) embedWithRetry(ctx context.Context, texts []string) ([][]float32, error) {
+	maxRetries := s.cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	base := s.cfg.RetryBaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	maxDelay := s.cfg.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := s.limiter.wait(ctx, estimateTokens(texts)); err != nil {
+			return nil, err
+		}
+
+		vectors, err := s.client.EmbedBatch(ctx, texts)
+		if err == nil {
+			return vectors, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= maxRetries || !isRetryable(err) {
+			return nil, err
+		}
+
+		delay := retryDelay(attempt, base, maxDelay)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		log.Printf("Embedding request failed (attempt %d/%d): %v; retrying in %v",
+			attempt+1, maxRetries+1, err, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+func TestService_RetriesRateLimitedRequests(t *testing.T) {
+	var calls int32
+	server := newEmbeddingServer(t, 4, nil)
+	flaky := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		server.Config.Handler.ServeHTTP(w, r)
+	})
+	flakyServer := httptest.NewServer(flaky)
+	t.Cleanup(flakyServer.Close)
+
+	cfg := &config.EmbeddingConfig{
+		Provider:       "openai_compatible",
+		BaseURL:        flakyServer.URL + "/v1",
+		Model:          "m",
+		BatchSize:      10,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  5 * time.Millisecond,
+	}
+	svc, err := NewService(cfg)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	vectors, err := svc.EmbedBatch(context.Background(), []string{"a", "bb"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if len(vectors) != 2 || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("got %d vectors after %d calls, want 2 after 3", len(vectors), calls)
+	}
+
+	// Client errors are not retried
+	atomic.StoreInt32(&calls, 0)
+	badServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "bad model", http.StatusBadRequest)
+	}))
+	t.Cleanup(badServer.Close)
+	cfg.BaseURL = badServer.URL + "/v1"
+	svc, _ = NewService(cfg)
+
+	_, err = svc.EmbedBatch(context.Background(), []string{"a"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("EmbedBatch() error = %v, want APIError 400", err)
+	}
+	if calls != 1 {
+		t.Errorf("server called %d times, want 1", calls)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		want := time.Second << attempt
+		if want > 8*time.Second {
+			want = 8 * time.Second
+		}
+		got := retryDelay(attempt, time.Second, 8*time.Second)
+		if got < want/2 || got > want {
+			t.Errorf("retryDelay(%d) = %v, want within [%v, %v]", attempt, got, want/2, want)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	// 600 requests per minute = one every 100ms once the bucket is empty
+	limiter := newRateLimiter(600, 0)
+	limiter.requests.level = 0
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least ~300ms", elapsed)
+	}
+
+	if newRateLimiter(0, 0) != nil {
+		t.Error("expected no limiter without limits")
+	}
+}
+
+// failingClient fails every batch containing the text "fail"
+type failingClient struct {
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (c *failingClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, errors.New("not used")
+}
+
+func (c *failingClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	c.mu.Lock()
+	c.active++
+	if c.active > c.maxSeen {
+		c.maxSeen = c.active
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if text == "fail" {
+			return nil, errors.New("boom")
+		}
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+func (c *failingClient) Dimensions() int { return 1 }
+
+func TestService_EmbedBatchFuncPersistsCompletedBatches(t *testing.T) {
+	client := &failingClient{}
+	svc := &Service{
+		cfg:    &config.EmbeddingConfig{BatchSize: 1, MaxRetries: -1},
+		client: client,
+	}
+	svc.SetWorkers(3)
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff"}
+	var mu sync.Mutex
+	persisted := make(map[int]float32)
+	_, err := svc.EmbedBatchFunc(context.Background(), texts, func(indices []int, vectors [][]float32) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for j, i := range indices {
+			persisted[i] = vectors[j][0]
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EmbedBatchFunc() error = %v", err)
+	}
+	if len(persisted) != len(texts) || persisted[3] != 4 {
+		t.Errorf("persisted = %v, want all %d texts", persisted, len(texts))
+	}
+	if client.maxSeen < 2 || client.maxSeen > 3 {
+		t.Errorf("max concurrent batches = %d, want 2-3", client.maxSeen)
+	}
+
+	// A failing batch aborts the run, but earlier batches were delivered
+	persisted = make(map[int]float32)
+	svc.SetWorkers(1)
+	_, err = svc.EmbedBatchFunc(context.Background(), []string{"a", "bb", "fail", "dddd"}, func(indices []int, vectors [][]float32) error {
+		for j, i := range indices {
+			persisted[i] = vectors[j][0]
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("EmbedBatchFunc() succeeded, want error")
+	}
+	if len(persisted) != 2 || persisted[1] != 2 {
+		t.Errorf("persisted = %v, want the two batches before the failure", persisted)
+	}
+}
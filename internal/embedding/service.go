@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sync"
 
 	"github.com/DreamCats/bcindex/internal/config"
 )
 
 // Service provides embedding generation functionality
 type Service struct {
-	cfg    *config.EmbeddingConfig
-	client Client
+	cfg     *config.EmbeddingConfig
+	client  Client
+	cache   Cache        // optional, see SetCache
+	limiter *rateLimiter // nil when no rate limit is configured
+	workers int          // concurrent batch requests, see SetWorkers
 }
 
 // Client is the interface for embedding API clients
@@ -24,7 +28,11 @@ type Client interface {
 
 // NewService creates a new embedding service
 func NewService(cfg *config.EmbeddingConfig) (*Service, error) {
-	svc := &Service{cfg: cfg}
+	svc := &Service{
+		cfg:     cfg,
+		limiter: newRateLimiter(cfg.RequestsPerMinute, cfg.TokensPerMinute),
+		workers: 1,
+	}
 
 	var client Client
 	var err error
@@ -34,6 +42,10 @@ func NewService(cfg *config.EmbeddingConfig) (*Service, error) {
 		client, err = NewVolcEngineClient(cfg)
 	case "openai":
 		client, err = NewOpenAIClient(cfg)
+	case "openai_compatible":
+		client, err = NewOpenAICompatibleClient(cfg)
+	case "local":
+		client, err = NewLocalClient(cfg)
 	default:
 		return nil, fmt.Errorf("unsupported embedding provider: %s", cfg.Provider)
 	}
@@ -51,11 +63,38 @@ func (s *Service) Embed(ctx context.Context, text string) ([]float32, error) {
 	if text == "" {
 		return nil, fmt.Errorf("cannot embed empty text")
 	}
-	return s.client.Embed(ctx, text)
+	vectors, err := s.embedWithRetry(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+	return vectors[0], nil
 }
 
+// SetWorkers sets the number of batches embedded concurrently
+func (s *Service) SetWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	s.workers = workers
+}
+
+// BatchFunc receives vectors as soon as a batch completes. indices refer to
+// positions in the texts passed to EmbedBatchFunc. Calls are serialized.
+type BatchFunc func(indices []int, vectors [][]float32) error
+
 // EmbedBatch generates embeddings for multiple texts
 func (s *Service) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.EmbedBatchFunc(ctx, texts, nil)
+}
+
+// EmbedBatchFunc generates embeddings for multiple texts. Batches run on
+// concurrent workers with retries and rate limiting; fn (optional) is called
+// per completed batch so callers can persist progress before a later batch
+// fails.
+func (s *Service) EmbedBatchFunc(ctx context.Context, texts []string, fn BatchFunc) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
@@ -74,40 +113,155 @@ func (s *Service) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 		return nil, fmt.Errorf("no valid texts to embed")
 	}
 
+	results := make([][]float32, len(texts))
+
+	// Serve cached texts and only send the misses to the provider
+	var hashes []string
+	if s.cache != nil {
+		hashes = make([]string, len(validTexts))
+		for i, text := range validTexts {
+			hashes[i] = TextHash(text)
+		}
+
+		cached, err := s.cache.GetBatch(s.cacheModel(), s.cfg.Dimensions, hashes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+		}
+
+		missTexts := validTexts[:0:0]
+		missIndices := validIndices[:0:0]
+		missHashes := hashes[:0:0]
+		var hitIndices []int
+		var hitVectors [][]float32
+		for i, text := range validTexts {
+			if vec, ok := cached[hashes[i]]; ok {
+				results[validIndices[i]] = vec
+				hitIndices = append(hitIndices, validIndices[i])
+				hitVectors = append(hitVectors, vec)
+				continue
+			}
+			missTexts = append(missTexts, text)
+			missIndices = append(missIndices, validIndices[i])
+			missHashes = append(missHashes, hashes[i])
+		}
+		validTexts, validIndices, hashes = missTexts, missIndices, missHashes
+
+		if fn != nil && len(hitIndices) > 0 {
+			if err := fn(hitIndices, hitVectors); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Process in batches
 	batchSize := s.cfg.BatchSize
 	if batchSize <= 0 {
 		batchSize = 10
 	}
 
-	results := make([][]float32, len(texts))
-	isTTY := stdoutIsTerminal()
-	total := len(validTexts)
-
+	var batches [][2]int
 	for i := 0; i < len(validTexts); i += batchSize {
 		end := i + batchSize
 		if end > len(validTexts) {
 			end = len(validTexts)
 		}
+		batches = append(batches, [2]int{i, end})
+	}
 
-		batch := validTexts[i:end]
-		embeddings, err := s.client.EmbedBatch(ctx, batch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to embed batch %d-%d: %w", i, end, err)
-		}
+	workers := s.workers
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(batches) {
+		workers = len(batches)
+	}
 
-		// Map results back to original indices
-		for j, emb := range embeddings {
-			results[validIndices[i+j]] = emb
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	isTTY := stdoutIsTerminal()
+	total := len(validTexts)
+	processed := 0
+
+	var mu sync.Mutex // guards results, processed, firstErr and fn calls
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
 		}
+		mu.Unlock()
+		cancel()
+	}
+
+	jobs := make(chan [2]int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				start, end := job[0], job[1]
+				embeddings, err := s.embedWithRetry(ctx, validTexts[start:end])
+				if err != nil {
+					fail(fmt.Errorf("failed to embed batch %d-%d: %w", start, end, err))
+					continue
+				}
+				if len(embeddings) != end-start {
+					fail(fmt.Errorf("failed to embed batch %d-%d: expected %d embeddings, got %d", start, end, end-start, len(embeddings)))
+					continue
+				}
+
+				if s.cache != nil {
+					if err := s.cache.PutBatch(s.cacheModel(), s.cfg.Dimensions, hashes[start:end], embeddings); err != nil {
+						fail(fmt.Errorf("failed to update embedding cache: %w", err))
+						continue
+					}
+				}
+
+				mu.Lock()
+				// Map results back to original indices
+				indices := validIndices[start:end]
+				for j, emb := range embeddings {
+					results[indices[j]] = emb
+				}
+				var fnErr error
+				if fn != nil && firstErr == nil {
+					fnErr = fn(indices, embeddings)
+				}
+				processed += end - start
+				printEmbeddingProgress(isTTY, processed, total)
+				mu.Unlock()
+
+				if fnErr != nil {
+					fail(fnErr)
+				}
+			}
+		}()
+	}
 
-		printEmbeddingProgress(isTTY, end, total)
+dispatch:
+	for _, batch := range batches {
+		select {
+		case jobs <- batch:
+		case <-ctx.Done():
+			break dispatch
+		}
 	}
+	close(jobs)
+	wg.Wait()
 
-	if isTTY && total > 0 {
+	if isTTY && processed > 0 {
 		fmt.Println()
 	}
 
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil && len(batches) > 0 {
+		return nil, err
+	}
+
 	return results, nil
 }
 
@@ -151,7 +151,7 @@ func (c *VolcEngineClient) embedText(ctx context.Context, text string) ([]float3
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body)
 	}
 
 	// Parse response
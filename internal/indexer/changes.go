@@ -0,0 +1,275 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// maxLoggedFileChanges limits the per-file lines printed for a change set
+const maxLoggedFileChanges = 20
+
+// sourceFile is a Go source file found while scanning the repository
+type sourceFile struct {
+	Hash    string
+	Size    int64
+	ModTime time.Time
+}
+
+// FileChanges describes the source files that changed since the last index
+type FileChanges struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+
+	// Packages that must be re-extracted
+	Packages map[string]bool
+}
+
+// IsEmpty reports whether no files changed
+func (c *FileChanges) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Deleted) == 0
+}
+
+// scanSourceFiles hashes every Go file in the repository, keyed by path
+// relative to the repository root
+func scanSourceFiles(repoPath string) (map[string]*sourceFile, error) {
+	files := make(map[string]*sourceFile)
+
+	walkFn := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "vendor", "third_party":
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		relPath, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			log.Printf("Warning: failed to make relative path for %s: %v", path, err)
+			return nil
+		}
+
+		sum := sha256.Sum256(content)
+		files[filepath.ToSlash(relPath)] = &sourceFile{
+			Hash:    hex.EncodeToString(sum[:]),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+		return nil
+	}
+
+	if err := filepath.WalkDir(repoPath, walkFn); err != nil {
+		return nil, fmt.Errorf("failed to scan repository files: %w", err)
+	}
+
+	return files, nil
+}
+
+// findChangedFiles compares the current source files with the hashes recorded
+// by the previous run. Databases indexed before hashes were tracked fall back
+// to comparing modification times with the last index time.
+func (idx *Indexer) findChangedFiles(repoPath string, dbRepoPath string, since time.Time, current map[string]*sourceFile) (*FileChanges, error) {
+	stored, err := idx.fileStore.ListByRepo(dbRepoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load file hashes: %w", err)
+	}
+
+	fileSymbols, err := idx.symbolStore.ListFilesByRepo(dbRepoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load indexed file symbols: %w", err)
+	}
+
+	// fileSymbols contain relative paths (e.g., "internal/pkg/file.go")
+	fileToPackage := make(map[string]string, len(fileSymbols)+len(stored))
+	for _, fileSymbol := range fileSymbols {
+		fileToPackage[fileSymbol.FilePath] = fileSymbol.PackagePath
+	}
+	for relPath, file := range stored {
+		if file.PackagePath != "" && fileToPackage[relPath] == "" {
+			fileToPackage[relPath] = file.PackagePath
+		}
+	}
+
+	changes := &FileChanges{Packages: make(map[string]bool)}
+	legacy := len(stored) == 0
+
+	for relPath, file := range current {
+		_, indexed := fileToPackage[relPath]
+		prev, tracked := stored[relPath]
+
+		switch {
+		case legacy && !file.ModTime.After(since):
+			continue
+		case legacy && indexed:
+			changes.Modified = append(changes.Modified, relPath)
+		case legacy:
+			changes.Added = append(changes.Added, relPath)
+		case !tracked && !indexed:
+			changes.Added = append(changes.Added, relPath)
+		case !tracked || prev.ContentHash != file.Hash:
+			changes.Modified = append(changes.Modified, relPath)
+		default:
+			continue
+		}
+
+		// Known file: reuse its package, otherwise resolve it
+		if pkgPath := fileToPackage[relPath]; pkgPath != "" {
+			changes.Packages[pkgPath] = true
+			continue
+		}
+		pkgPath, err := idx.resolvePackagePath(repoPath, filepath.Join(repoPath, relPath))
+		if err != nil {
+			log.Printf("Warning: failed to resolve package for %s: %v", relPath, err)
+			continue
+		}
+		if pkgPath != "" {
+			changes.Packages[pkgPath] = true
+		}
+	}
+
+	for relPath, pkgPath := range fileToPackage {
+		if _, ok := current[relPath]; ok {
+			continue
+		}
+		changes.Deleted = append(changes.Deleted, relPath)
+		if pkgPath != "" {
+			changes.Packages[pkgPath] = true
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Modified)
+	sort.Strings(changes.Deleted)
+
+	return changes, nil
+}
+
+// logFileChanges prints a summary of the detected file changes
+func logFileChanges(changes *FileChanges) {
+	log.Printf("Detected %d added, %d modified, %d deleted file(s)",
+		len(changes.Added), len(changes.Modified), len(changes.Deleted))
+
+	if len(changes.Added)+len(changes.Modified)+len(changes.Deleted) > maxLoggedFileChanges {
+		return
+	}
+	for _, path := range changes.Added {
+		log.Printf("  + %s", path)
+	}
+	for _, path := range changes.Modified {
+		log.Printf("  ~ %s", path)
+	}
+	for _, path := range changes.Deleted {
+		log.Printf("  - %s", path)
+	}
+}
+
+// recordFileHashes stores the hashes of the scanned files so the next run can
+// detect changes by content
+func (idx *Indexer) recordFileHashes(dbRepoPath string, current map[string]*sourceFile) error {
+	fileSymbols, err := idx.symbolStore.ListFilesByRepo(dbRepoPath)
+	if err != nil {
+		return fmt.Errorf("failed to load indexed file symbols: %w", err)
+	}
+	fileToPackage := make(map[string]string, len(fileSymbols))
+	for _, fileSymbol := range fileSymbols {
+		fileToPackage[fileSymbol.FilePath] = fileSymbol.PackagePath
+	}
+
+	files := make([]*store.FileHash, 0, len(current))
+	for relPath, file := range current {
+		files = append(files, &store.FileHash{
+			RepoPath:    dbRepoPath,
+			FilePath:    relPath,
+			PackagePath: fileToPackage[relPath],
+			ContentHash: file.Hash,
+			Size:        file.Size,
+		})
+	}
+
+	if err := idx.fileStore.ReplaceByRepo(dbRepoPath, files); err != nil {
+		return fmt.Errorf("failed to record file hashes: %w", err)
+	}
+	return nil
+}
+
+// sourceLines caches file contents for body excerpts
+type sourceLines struct {
+	repoPath string
+	files    map[string][]string
+}
+
+func newSourceLines(repoPath string) *sourceLines {
+	return &sourceLines{repoPath: repoPath, files: make(map[string][]string)}
+}
+
+// lines returns lines start..end (1-based, inclusive) of a file, or an empty
+// string if unavailable
+func (s *sourceLines) lines(filePath string, start, end int) string {
+	if filePath == "" || start <= 0 {
+		return ""
+	}
+
+	lines, ok := s.files[filePath]
+	if !ok {
+		path := filePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.repoPath, path)
+		}
+		content, err := os.ReadFile(path)
+		if err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		s.files[filePath] = lines
+	}
+
+	if end < start {
+		end = start
+	}
+	if start > len(lines) {
+		return ""
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return strings.Join(lines[start-1:end], "\n")
+}
+
+// symbolContentHash hashes what an embedding depends on: the symbol's kind,
+// signature and doc comment plus the rendered embedding text
+func symbolContentHash(sym *store.Symbol, text string) string {
+	h := sha256.New()
+	for _, part := range []string{sym.Kind, sym.Signature, sym.DocComment, text} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
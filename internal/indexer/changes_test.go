@@ -0,0 +1,166 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindChangedFiles(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, "a", "a.go"), "package a\n")
+	writeFile(t, filepath.Join(repo, "b", "b.go"), "package b\n")
+	writeFile(t, filepath.Join(repo, "c", "c.go"), "package c\n")
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	idx := &Indexer{symbolStore: store.NewSymbolStore(db), fileStore: store.NewFileStore(db)}
+
+	var files []*store.Symbol
+	for _, pkg := range []string{"a", "b", "c"} {
+		files = append(files, &store.Symbol{
+			ID: "example.com/" + pkg + ":file:" + pkg + ".go", RepoPath: repo, Kind: store.KindFile,
+			PackagePath: "example.com/" + pkg, PackageName: pkg, Name: pkg + ".go", FilePath: pkg + "/" + pkg + ".go",
+		})
+	}
+	if err := idx.symbolStore.CreateBatch(files); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	initial, err := scanSourceFiles(repo)
+	if err != nil {
+		t.Fatalf("scanSourceFiles() error = %v", err)
+	}
+	if err := idx.recordFileHashes(repo, initial); err != nil {
+		t.Fatalf("recordFileHashes() error = %v", err)
+	}
+
+	// Touching a file without changing it is not a change, even with an old index time
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(repo, "a", "a.go"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	current, _ := scanSourceFiles(repo)
+	changes, err := idx.findChangedFiles(repo, repo, time.Now(), current)
+	if err != nil {
+		t.Fatalf("findChangedFiles() error = %v", err)
+	}
+	if !changes.IsEmpty() {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+
+	// Content changes are detected even if the mtime is older than the last index
+	writeFile(t, filepath.Join(repo, "b", "b.go"), "package b\n\nfunc B() {}\n")
+	past := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(repo, "b", "b.go"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(repo, "c", "c.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	current, _ = scanSourceFiles(repo)
+	changes, err = idx.findChangedFiles(repo, repo, time.Now(), current)
+	if err != nil {
+		t.Fatalf("findChangedFiles() error = %v", err)
+	}
+	if !reflect.DeepEqual(changes.Modified, []string{"b/b.go"}) || !reflect.DeepEqual(changes.Deleted, []string{"c/c.go"}) || len(changes.Added) != 0 {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	want := map[string]bool{"example.com/b": true, "example.com/c": true}
+	if !reflect.DeepEqual(changes.Packages, want) {
+		t.Errorf("Packages = %v, want %v", changes.Packages, want)
+	}
+}
+
+func TestSymbolContentHash(t *testing.T) {
+	sym := &store.Symbol{Kind: "func", Signature: "func F()", DocComment: "F does things."}
+
+	base := symbolContentHash(sym, "func F() {}")
+	if base != symbolContentHash(sym, "func F() {}") {
+		t.Fatal("hash is not deterministic")
+	}
+	if base == symbolContentHash(sym, "func F() { return }") {
+		t.Error("text change did not change the hash")
+	}
+
+	changed := *sym
+	changed.DocComment = "F does other things."
+	if base == symbolContentHash(&changed, "func F() {}") {
+		t.Error("doc change did not change the hash")
+	}
+}
+
+func TestChangedCallNeighbors(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	idx := &Indexer{symbolStore: store.NewSymbolStore(db), edgeStore: store.NewEdgeStore(db), vectorStore: store.NewVectorStore(db)}
+
+	var symbols []*store.Symbol
+	for _, id := range []string{"a:func:A", "b:func:B", "b:func:C", "c:func:D"} {
+		symbols = append(symbols, &store.Symbol{ID: id, RepoPath: "/repo", Kind: store.KindFunc, PackagePath: id[:1], PackageName: id[:1], Name: id[len(id)-1:]})
+	}
+	if err := idx.symbolStore.CreateBatch(symbols); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+	if err := idx.vectorStore.InsertBatchWithHashes([]string{"b:func:B"}, [][]float32{{1, 0}}, []string{"h"}, "test"); err != nil {
+		t.Fatalf("failed to insert vector: %v", err)
+	}
+	calls := func(from, to string) *store.Edge {
+		return &store.Edge{FromID: from, ToID: to, EdgeType: store.EdgeTypeCalls}
+	}
+	if err := idx.edgeStore.CreateBatch([]*store.Edge{calls("a:func:A", "b:func:B"), calls("c:func:D", "a:func:A")}); err != nil {
+		t.Fatalf("failed to create edges: %v", err)
+	}
+
+	// Package a is re-extracted: A now calls C instead of B; D still calls A
+	packages := map[string]bool{"a": true}
+	previous, err := idx.callEdges(packages)
+	if err != nil {
+		t.Fatalf("callEdges() error = %v", err)
+	}
+	if err := idx.edgeStore.DeleteBySymbol("a:func:A"); err != nil {
+		t.Fatalf("failed to delete edges: %v", err)
+	}
+	if err := idx.edgeStore.CreateBatch([]*store.Edge{calls("a:func:A", "b:func:C"), calls("c:func:D", "a:func:A")}); err != nil {
+		t.Fatalf("failed to create edges: %v", err)
+	}
+
+	previousVectors := make(map[string]*store.StoredVector)
+	neighbors, err := idx.changedCallNeighbors(packages, previous, previousVectors)
+	if err != nil {
+		t.Fatalf("changedCallNeighbors() error = %v", err)
+	}
+	var ids []string
+	for _, sym := range neighbors {
+		ids = append(ids, sym.ID)
+	}
+	if want := []string{"b:func:B", "b:func:C"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("neighbors = %v, want %v", ids, want)
+	}
+	if vector := previousVectors["b:func:B"]; vector == nil || vector.ContentHash != "h" {
+		t.Errorf("previous vector of B = %+v, want its stored vector", vector)
+	}
+}
@@ -0,0 +1,236 @@
+package indexer
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"path/filepath"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// lineRange is an inclusive, 1-based range of source lines
+type lineRange struct {
+	start int
+	end   int
+}
+
+func (r lineRange) lines() int {
+	return r.end - r.start + 1
+}
+
+// chunkFuncBody splits a function body into statement-aligned chunks of at
+// most maxLines lines (a single larger statement without nested blocks
+// becomes its own chunk). Consecutive chunks repeat the trailing statements
+// of the previous chunk that fit into overlap lines.
+func chunkFuncBody(fset *token.FileSet, body *ast.BlockStmt, maxLines, overlap int) []lineRange {
+	if body == nil {
+		return nil
+	}
+	units := statementUnits(fset, body.List, maxLines)
+
+	var chunks []lineRange
+	for i := 0; i < len(units); {
+		chunk := units[i]
+		j := i + 1
+		for j < len(units) && units[j].end-chunk.start+1 <= maxLines {
+			chunk.end = units[j].end
+			j++
+		}
+		chunks = append(chunks, chunk)
+		if j >= len(units) {
+			break
+		}
+
+		// Step back over trailing units that fit into the overlap,
+		// always advancing by at least one unit
+		next := j
+		for next-1 > i && chunk.end-units[next-1].start+1 <= overlap {
+			next--
+		}
+		i = next
+	}
+
+	return chunks
+}
+
+// statementUnits returns the line ranges of statements, descending into the
+// blocks of statements longer than maxLines. The header of a split statement
+// (e.g. "for _, x := range xs {") is kept as its own unit.
+func statementUnits(fset *token.FileSet, stmts []ast.Stmt, maxLines int) []lineRange {
+	var units []lineRange
+	for _, stmt := range stmts {
+		r := nodeLines(fset, stmt)
+		children := childStatements(stmt)
+		if r.lines() <= maxLines || len(children) == 0 {
+			units = append(units, r)
+			continue
+		}
+
+		if first := nodeLines(fset, children[0]).start; first > r.start {
+			units = append(units, lineRange{start: r.start, end: first - 1})
+		}
+		units = append(units, statementUnits(fset, children, maxLines)...)
+	}
+	return units
+}
+
+// childStatements returns the statements nested directly in a statement
+func childStatements(stmt ast.Stmt) []ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		return s.List
+	case *ast.IfStmt:
+		children := append([]ast.Stmt{}, s.Body.List...)
+		if s.Else != nil {
+			children = append(children, s.Else)
+		}
+		return children
+	case *ast.ForStmt:
+		return s.Body.List
+	case *ast.RangeStmt:
+		return s.Body.List
+	case *ast.SwitchStmt:
+		return s.Body.List
+	case *ast.TypeSwitchStmt:
+		return s.Body.List
+	case *ast.SelectStmt:
+		return s.Body.List
+	case *ast.CaseClause:
+		return s.Body
+	case *ast.CommClause:
+		return s.Body
+	case *ast.LabeledStmt:
+		return childStatements(s.Stmt)
+	case *ast.GoStmt:
+		return funcLitStatements(s.Call.Fun)
+	case *ast.DeferStmt:
+		return funcLitStatements(s.Call.Fun)
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			return funcLitStatements(call.Fun)
+		}
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 {
+			return funcLitStatements(s.Rhs[0])
+		}
+	}
+	return nil
+}
+
+// funcLitStatements returns the body of a function literal
+// (e.g. "go func() { ... }()" or "fn := func() { ... }")
+func funcLitStatements(expr ast.Expr) []ast.Stmt {
+	if lit, ok := expr.(*ast.FuncLit); ok {
+		return lit.Body.List
+	}
+	return nil
+}
+
+func nodeLines(fset *token.FileSet, node ast.Node) lineRange {
+	return lineRange{
+		start: fset.Position(node.Pos()).Line,
+		end:   fset.Position(node.End()).Line,
+	}
+}
+
+// funcDecls parses source files on demand and finds function declarations by
+// the line they start on
+type funcDecls struct {
+	repoPath string
+	fset     *token.FileSet
+	files    map[string]map[int]*ast.FuncDecl
+}
+
+func newFuncDecls(repoPath string) *funcDecls {
+	return &funcDecls{
+		repoPath: repoPath,
+		fset:     token.NewFileSet(),
+		files:    make(map[string]map[int]*ast.FuncDecl),
+	}
+}
+
+// at returns the function declared at line of a file, or nil
+func (f *funcDecls) at(filePath string, line int) *ast.FuncDecl {
+	decls, ok := f.files[filePath]
+	if !ok {
+		path := filePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(f.repoPath, path)
+		}
+		file, err := parser.ParseFile(f.fset, path, nil, parser.SkipObjectResolution)
+		if err == nil {
+			decls = make(map[int]*ast.FuncDecl)
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					decls[f.fset.Position(fn.Pos()).Line] = fn
+				}
+			}
+		}
+		f.files[filePath] = decls
+	}
+	return decls[line]
+}
+
+// indexChunks embeds long function bodies as overlapping chunks linked to
+// their symbol. Unchanged chunks are served from the embedding cache.
+func (idx *Indexer) indexChunks(ctx context.Context, repoPath string, symbols []*store.Symbol) error {
+	maxLines := idx.cfg.Indexer.ChunkLines
+	overlap := idx.cfg.Indexer.ChunkOverlap
+
+	sources := newSourceLines(repoPath)
+	decls := newFuncDecls(repoPath)
+
+	var chunks []*store.Chunk
+	var texts []string
+	var chunked []string
+
+	for _, sym := range symbols {
+		if (sym.Kind != "func" && sym.Kind != "method") || store.IsTestSymbol(sym) || sym.LineEnd-sym.LineStart+1 <= maxLines {
+			continue
+		}
+		decl := decls.at(sym.FilePath, sym.LineStart)
+		if decl == nil {
+			continue
+		}
+
+		for _, r := range chunkFuncBody(decls.fset, decl.Body, maxLines, overlap) {
+			chunks = append(chunks, &store.Chunk{
+				ID:        store.ChunkID(sym.ID, r.start, r.end),
+				SymbolID:  sym.ID,
+				LineStart: r.start,
+				LineEnd:   r.end,
+			})
+			// The signature gives each chunk the context of its function
+			texts = append(texts, sym.Signature+"\n"+sources.lines(sym.FilePath, r.start, r.end))
+		}
+		chunked = append(chunked, sym.ID)
+	}
+
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	// Drop chunks of a previous run with other chunk settings
+	if err := idx.vectorStore.DeleteChunks(chunked); err != nil {
+		return err
+	}
+
+	log.Printf("Embedding %d body chunks of %d functions", len(chunks), len(chunked))
+	model := idx.cfg.Embedding.Model
+	persist := func(indices []int, vectors [][]float32) error {
+		batch := make([]*store.Chunk, len(indices))
+		for j, i := range indices {
+			chunks[i].Vector = vectors[j]
+			batch[j] = chunks[i]
+		}
+		return idx.vectorStore.InsertChunks(batch, model)
+	}
+
+	if _, err := idx.embedService.EmbedBatchFunc(ctx, texts, persist); err != nil {
+		return err
+	}
+	return nil
+}
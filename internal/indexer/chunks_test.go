@@ -0,0 +1,63 @@
+package indexer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestChunkFuncBody(t *testing.T) {
+	// Lines 4-13: ten one-line statements, 14-25: a loop, 26: return
+	var src strings.Builder
+	src.WriteString("package p\n\nfunc F() int {\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&src, "\tx%d := %d\n", i, i)
+	}
+	src.WriteString("\tfor i := 0; i < 3; i++ {\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&src, "\t\tprintln(i, %d)\n", i)
+	}
+	src.WriteString("\t}\n\treturn 0\n}\n")
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src.String(), 0)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	body := file.Decls[0].(*ast.FuncDecl).Body
+
+	chunks := chunkFuncBody(fset, body, 8, 3)
+	want := []lineRange{
+		{4, 11},  // x0..x7
+		{9, 16},  // x5..x9, loop header (the loop is split), println 0..1
+		{14, 21}, // loop header, println 0..6
+		{19, 26}, // println 4..9, closing brace, return
+	}
+	if fmt.Sprint(chunks) != fmt.Sprint(want) {
+		t.Errorf("chunks = %v, want %v", chunks, want)
+	}
+
+	// Every statement line is covered
+	for line := 4; line <= 26; line++ {
+		if line == 25 {
+			continue // closing brace of the loop
+		}
+		covered := false
+		for _, c := range chunks {
+			if line >= c.start && line <= c.end {
+				covered = true
+			}
+		}
+		if !covered {
+			t.Errorf("line %d is not covered by %v", line, chunks)
+		}
+	}
+
+	// A body that fits is a single chunk
+	if chunks := chunkFuncBody(fset, body, 100, 10); len(chunks) != 1 || chunks[0] != (lineRange{4, 26}) {
+		t.Errorf("chunks = %v, want [{4 26}]", chunks)
+	}
+}
@@ -0,0 +1,303 @@
+package indexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/DreamCats/bcindex/internal/ast"
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/semantic"
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// Defaults for the parts of the embedding text
+const (
+	defaultBodyExcerptLines = 30
+	defaultMaxRelatedNames  = 10
+	packageCardExcerptLines = 2 // Role and Responsibilities
+)
+
+// DefaultEmbeddingTemplate renders the text embedded for a symbol unless
+// embedding.text_template overrides it
+const DefaultEmbeddingTemplate = `{{.Card}}
+{{- if .Receiver}}Receiver: {{.Receiver}}
+{{end}}
+{{- if .PackageCard}}Package: {{.Package}}
+{{.PackageCard}}
+{{end}}
+{{- if .Callers}}Called by: {{join .Callers ", "}}
+{{end}}
+{{- if .Callees}}Calls: {{join .Callees ", "}}
+{{end}}
+{{- if .Body}}Body:
+{{.Body}}
+{{end}}`
+
+// EmbedTextData is the data available to the embedding text template
+type EmbedTextData struct {
+	ID        string
+	Kind      string
+	Name      string
+	Package   string
+	Signature string
+	Doc       string
+	Receiver  string // receiver type of methods, without '*'
+
+	Card        string   // symbol card without package context
+	PackageCard string   // first lines of the package card
+	Body        string   // first body_excerpt_lines lines of the source
+	Callers     []string // names of calling symbols
+	Callees     []string // names of called symbols
+}
+
+// embedTextBuilder renders embedding texts from indexed symbols, edges and
+// packages, so the same text can be rebuilt later for debugging
+type embedTextBuilder struct {
+	tmpl         *template.Template
+	bodyLines    int
+	maxRelated   int
+	semanticGen  *semantic.Generator
+	packageStore *store.PackageStore
+	edgeStore    *store.EdgeStore
+
+	packageCards map[string]string // package card excerpts, see reset
+}
+
+// newEmbedTextBuilder parses the configured template
+func newEmbedTextBuilder(cfg *config.EmbeddingConfig, semanticGen *semantic.Generator, packageStore *store.PackageStore, edgeStore *store.EdgeStore) (*embedTextBuilder, error) {
+	text := cfg.TextTemplate
+	if strings.TrimSpace(text) == "" {
+		text = DefaultEmbeddingTemplate
+	}
+
+	tmpl, err := template.New("embedding").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse embedding text_template: %w", err)
+	}
+
+	bodyLines := cfg.BodyExcerptLines
+	if bodyLines == 0 {
+		bodyLines = defaultBodyExcerptLines
+	}
+	maxRelated := cfg.MaxRelatedNames
+	if maxRelated == 0 {
+		maxRelated = defaultMaxRelatedNames
+	}
+
+	return &embedTextBuilder{
+		tmpl:         tmpl,
+		bodyLines:    bodyLines,
+		maxRelated:   maxRelated,
+		semanticGen:  semanticGen,
+		packageStore: packageStore,
+		edgeStore:    edgeStore,
+		packageCards: make(map[string]string),
+	}, nil
+}
+
+// reset drops cached package cards, which change between indexing runs
+func (b *embedTextBuilder) reset() {
+	b.packageCards = make(map[string]string)
+}
+
+// build renders the embedding text of a stored symbol
+func (b *embedTextBuilder) build(sym *store.Symbol, sources *sourceLines) (string, error) {
+	data, err := b.data(sym, sources)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	if err := b.tmpl.Execute(&text, data); err != nil {
+		return "", fmt.Errorf("failed to render embedding text for %s: %w", sym.ID, err)
+	}
+	return strings.TrimSpace(text.String()), nil
+}
+
+// data collects the template data of a symbol
+func (b *embedTextBuilder) data(sym *store.Symbol, sources *sourceLines) (*EmbedTextData, error) {
+	data := &EmbedTextData{
+		ID:        sym.ID,
+		Kind:      sym.Kind,
+		Name:      sym.Name,
+		Package:   sym.PackagePath,
+		Signature: sym.Signature,
+		Doc:       sym.DocComment,
+		Receiver:  symbolReceiver(sym),
+	}
+
+	// The card without package context; the package gets its own excerpt
+	card := &ast.ExtractedSymbol{
+		Kind:       sym.Kind,
+		Signature:  sym.Signature,
+		DocComment: sym.DocComment,
+	}
+	if sym.TypeDetails != nil {
+		card.Members = sym.TypeDetails.Members
+	}
+	if sym.Kind == store.KindField {
+		name := symbolIDName(sym.ID)
+		if dot := strings.LastIndex(name, "."); dot > 0 {
+			card.ParentID = sym.PackagePath + ":struct:" + name[:dot]
+		}
+	}
+	data.Card = b.semanticGen.GenerateSymbolCard(card, "")
+
+	pkgCard, err := b.packageCard(sym.PackagePath)
+	if err != nil {
+		return nil, err
+	}
+	data.PackageCard = pkgCard
+
+	if b.bodyLines > 0 && sources != nil {
+		end := sym.LineEnd
+		if end >= sym.LineStart+b.bodyLines {
+			end = sym.LineStart + b.bodyLines - 1
+		}
+		data.Body = sources.lines(sym.FilePath, sym.LineStart, end)
+	}
+
+	if b.maxRelated > 0 && (sym.Kind == "func" || sym.Kind == "method") {
+		incoming, err := b.edgeStore.GetIncoming(sym.ID, "calls")
+		if err != nil {
+			return nil, err
+		}
+		outgoing, err := b.edgeStore.GetOutgoing(sym.ID, "calls")
+		if err != nil {
+			return nil, err
+		}
+
+		callers := make([]string, len(incoming))
+		for i, edge := range incoming {
+			callers[i] = edge.FromID
+		}
+		callees := make([]string, len(outgoing))
+		for i, edge := range outgoing {
+			callees[i] = edge.ToID
+		}
+		data.Callers = relatedNames(callers, b.maxRelated)
+		data.Callees = relatedNames(callees, b.maxRelated)
+	}
+
+	return data, nil
+}
+
+// packageCard returns the first lines of a package's card
+func (b *embedTextBuilder) packageCard(pkgPath string) (string, error) {
+	if card, ok := b.packageCards[pkgPath]; ok {
+		return card, nil
+	}
+
+	pkg, err := b.packageStore.Get(pkgPath)
+	if err != nil {
+		return "", err
+	}
+
+	var card string
+	if pkg != nil {
+		lines := strings.Split(strings.TrimSpace(pkg.Summary), "\n")
+		if len(lines) > packageCardExcerptLines {
+			lines = lines[:packageCardExcerptLines]
+		}
+		card = strings.Join(lines, "\n")
+	}
+
+	b.packageCards[pkgPath] = card
+	return card, nil
+}
+
+// symbolIDName returns the name part of a symbol ID ("pkg:kind:name"),
+// e.g. "Indexer.Close" for a method
+func symbolIDName(id string) string {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) < 3 {
+		return id
+	}
+	name := parts[2]
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+	return name
+}
+
+// symbolReceiver returns the receiver type of a method
+func symbolReceiver(sym *store.Symbol) string {
+	if sym.Kind != "method" {
+		return ""
+	}
+	name := symbolIDName(sym.ID)
+	if dot := strings.LastIndex(name, "."); dot > 0 {
+		return name[:dot]
+	}
+	return ""
+}
+
+// relatedNames turns function and method IDs (including interface methods)
+// into sorted unique names,
+// keeping at most limit so the text stays stable across runs
+func relatedNames(ids []string, limit int) []string {
+	seen := make(map[string]bool, len(ids))
+	var names []string
+	for _, id := range ids {
+		parts := strings.SplitN(id, ":", 3)
+		if len(parts) < 3 || (parts[1] != "func" && parts[1] != "method" && parts[1] != "interface-method") {
+			continue
+		}
+		name := symbolIDName(id)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	return names
+}
+
+// EmbeddingTextInfo describes the embedding text of an indexed symbol
+type EmbeddingTextInfo struct {
+	Text     string
+	Stored   bool // a vector is stored for the symbol
+	UpToDate bool // the stored vector was generated from Text
+}
+
+// EmbeddingText rebuilds the text embedded for an indexed symbol from the
+// database with the current template
+func (idx *Indexer) EmbeddingText(symbolID string) (*EmbeddingTextInfo, error) {
+	sym, err := idx.symbolStore.Get(symbolID)
+	if err != nil {
+		return nil, err
+	}
+	if sym == nil {
+		return nil, fmt.Errorf("symbol not found: %s", symbolID)
+	}
+
+	repoPath := sym.RepoPath
+	if repoPath == "" {
+		repoPath = idx.cfg.Repo.Path
+	}
+
+	idx.textBuilder.reset()
+	text, err := idx.textBuilder.build(sym, newSourceLines(repoPath))
+	if err != nil {
+		return nil, err
+	}
+	info := &EmbeddingTextInfo{Text: text}
+
+	stored, err := idx.vectorStore.GetByPackage(sym.PackagePath)
+	if err != nil {
+		return nil, err
+	}
+	if vector, ok := stored[sym.ID]; ok {
+		info.Stored = true
+		info.UpToDate = vector.ContentHash == symbolContentHash(sym, text) && vector.Model == idx.cfg.Embedding.Model
+	}
+
+	return info, nil
+}
@@ -0,0 +1,163 @@
+package indexer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/semantic"
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+func TestEmbedTextBuilder(t *testing.T) {
+	repo := t.TempDir()
+	var body strings.Builder
+	body.WriteString("package a\n\n// Close releases resources\nfunc (s *Server) Close() error {\n")
+	for i := 0; i < 50; i++ {
+		body.WriteString("\ts.release()\n")
+	}
+	body.WriteString("\treturn nil\n}\n")
+	writeFile(t, filepath.Join(repo, "a", "a.go"), body.String())
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	packageStore := store.NewPackageStore(db)
+	edgeStore := store.NewEdgeStore(db)
+	if err := packageStore.Create(&store.Package{
+		Path: "example.com/a", Name: "a", RepoPath: repo,
+		Summary: "Role: infrastructure\nResponsibilities: serves requests\nKey Types: Server\n",
+	}); err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	sym := &store.Symbol{
+		ID: "example.com/a:method:Server.Close", RepoPath: repo, Kind: "method", PackagePath: "example.com/a",
+		Name: "Close", Signature: "func (s *Server) Close() error", DocComment: "Close releases resources",
+		FilePath: "a/a.go", LineStart: 4, LineEnd: 56,
+	}
+	if err := store.NewSymbolStore(db).CreateBatch([]*store.Symbol{
+		sym,
+		{ID: "example.com/a:func:main", RepoPath: repo, Kind: "func", PackagePath: "example.com/a", Name: "main"},
+		{ID: "example.com/a:method:Server.release", RepoPath: repo, Kind: "method", PackagePath: "example.com/a", Name: "release"},
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+	if err := edgeStore.CreateBatch([]*store.Edge{
+		{FromID: "example.com/a:func:main", ToID: "example.com/a:method:Server.Close", EdgeType: "calls"},
+		{FromID: "example.com/a:func:main", ToID: "example.com/a:method:Server.Close", EdgeType: "calls"},
+		{FromID: "example.com/a:method:Server.Close", ToID: "example.com/a:method:Server.release", EdgeType: "calls"},
+	}); err != nil {
+		t.Fatalf("failed to create edges: %v", err)
+	}
+
+	cfg := &config.EmbeddingConfig{BodyExcerptLines: 5}
+	builder, err := newEmbedTextBuilder(cfg, semantic.NewGenerator(), packageStore, edgeStore)
+	if err != nil {
+		t.Fatalf("newEmbedTextBuilder() error = %v", err)
+	}
+
+	text, err := builder.build(sym, newSourceLines(repo))
+	if err != nil {
+		t.Fatalf("build() error = %v", err)
+	}
+	for _, want := range []string{
+		"Signature: func (s *Server) Close() error",
+		"Documentation: Close releases resources",
+		"Receiver: Server",
+		"Responsibilities: serves requests",
+		"Called by: main\n",
+		"Calls: Server.release",
+		"Body:\nfunc (s *Server) Close() error {",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text is missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Key Types") {
+		t.Errorf("package card excerpt is not bounded:\n%s", text)
+	}
+	if n := strings.Count(text, "s.release()"); n != 4 {
+		t.Errorf("body excerpt has %d call lines, want 4", n)
+	}
+
+	// Custom template
+	cfg = &config.EmbeddingConfig{TextTemplate: "{{.Kind}} {{.Name}} of {{.Receiver}}"}
+	builder, err = newEmbedTextBuilder(cfg, semantic.NewGenerator(), packageStore, edgeStore)
+	if err != nil {
+		t.Fatalf("newEmbedTextBuilder() error = %v", err)
+	}
+	if text, _ := builder.build(sym, nil); text != "method Close of Server" {
+		t.Errorf("custom template text = %q", text)
+	}
+
+	cfg = &config.EmbeddingConfig{TextTemplate: "{{.Name"}
+	if _, err := newEmbedTextBuilder(cfg, semantic.NewGenerator(), packageStore, edgeStore); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestEmbedTextBuilder_ValueSymbols(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, "a", "a.go"), "package a\n\n// Log levels\nconst (\n\tLevelDebug Level = iota\n\tLevelInfo\n)\n\ntype Config struct {\n\tBatchSize int // Texts per request\n}\n")
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	builder, err := newEmbedTextBuilder(&config.EmbeddingConfig{}, semantic.NewGenerator(), store.NewPackageStore(db), store.NewEdgeStore(db))
+	if err != nil {
+		t.Fatalf("newEmbedTextBuilder() error = %v", err)
+	}
+
+	enum := &store.Symbol{
+		ID: "example.com/a:const:LevelDebug", Kind: "const", PackagePath: "example.com/a", Name: "LevelDebug",
+		Signature: "const LevelDebug Level = 0", DocComment: "Log levels", FilePath: "a/a.go", LineStart: 5, LineEnd: 7,
+		TypeDetails: &store.TypeDetails{Members: []string{"LevelDebug", "LevelInfo"}},
+	}
+	field := &store.Symbol{
+		ID: "example.com/a:field:Config.BatchSize", Kind: "field", PackagePath: "example.com/a", Name: "BatchSize",
+		Signature: "BatchSize int", DocComment: "Texts per request", FilePath: "a/a.go", LineStart: 10, LineEnd: 10, Exported: true,
+	}
+
+	sources := newSourceLines(repo)
+	for sym, wants := range map[*store.Symbol][]string{
+		enum:  {"Enumeration of LevelDebug, LevelInfo", "Body:\n\tLevelDebug Level = iota\n\tLevelInfo\n)"},
+		field: {"Field of struct Config", "Documentation: Texts per request"},
+	} {
+		text, err := builder.build(sym, sources)
+		if err != nil {
+			t.Fatalf("build(%s) error = %v", sym.ID, err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(text, want) {
+				t.Errorf("text of %s is missing %q:\n%s", sym.Name, want, text)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		sym  *store.Symbol
+		want bool
+	}{
+		{enum, true},
+		{field, true},
+		{&store.Symbol{Kind: "const", Name: "LevelInfo", TypeDetails: &store.TypeDetails{Group: enum.ID}}, false},
+		{&store.Symbol{Kind: "var", Name: "_"}, false},
+		{&store.Symbol{Kind: "field", Name: "batch"}, false},
+		{&store.Symbol{Kind: "field", Name: "Level", Signature: "embedded Level", Exported: true}, false},
+		{&store.Symbol{Kind: "type", Name: "Severity", Signature: "type Severity = Level"}, true},
+		{&store.Symbol{Kind: "file", Name: "a.go"}, false},
+		{&store.Symbol{Kind: "test", Name: "TestLevel", FilePath: "log/level_test.go"}, false},
+		{&store.Symbol{Kind: "func", Name: "newLogger", FilePath: "log/level_test.go"}, false},
+	} {
+		if got := embeddable(tc.sym); got != tc.want {
+			t.Errorf("embeddable(%s %s) = %v, want %v", tc.sym.Kind, tc.sym.Name, got, tc.want)
+		}
+	}
+}
@@ -3,11 +3,11 @@ package indexer
 import (
 	"context"
 	"fmt"
-	"io/fs"
 	"log"
-	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +30,14 @@ type Indexer struct {
 	edgeStore    *store.EdgeStore
 	vectorStore  *store.VectorStore
 	repoStore    *store.RepositoryStore
+	fileStore    *store.FileStore
+	textBuilder  *embedTextBuilder
+
+	// Embedding cache, possibly in a database shared with other repositories
+	cacheDB        *store.DB
+	embeddingCache *store.EmbeddingCache
+
+	force bool // rebuild from scratch instead of indexing incrementally
 }
 
 // NewIndexer creates a new indexer
@@ -47,27 +55,75 @@ func NewIndexer(cfg *config.Config) (*Indexer, error) {
 		return nil, fmt.Errorf("failed to create embedding service: %w", err)
 	}
 
+	embedService.SetWorkers(cfg.Indexer.MaxWorkers)
+
+	// Open the embedding cache (a shared database unless it is the index itself)
+	var cacheDB *store.DB
+	var embeddingCache *store.EmbeddingCache
+	if !cfg.Embedding.DisableCache && cfg.Embedding.CachePath != "" {
+		if cfg.Embedding.CachePath == cfg.Database.Path {
+			embeddingCache = store.NewEmbeddingCache(db)
+		} else if cacheDB, err = store.Open(cfg.Embedding.CachePath); err != nil {
+			log.Printf("Warning: embedding cache disabled: %v", err)
+			cacheDB = nil
+		} else {
+			embeddingCache = store.NewEmbeddingCache(cacheDB)
+		}
+		if embeddingCache != nil {
+			embedService.SetCache(embeddingCache)
+		}
+	}
+
 	// Create stores
 	symbolStore := store.NewSymbolStore(db)
 	packageStore := store.NewPackageStore(db)
 	edgeStore := store.NewEdgeStore(db)
 	vectorStore := store.NewVectorStore(db)
+	vectorStore.SetProbes(cfg.Search.ANNProbes)
 	repoStore := store.NewRepositoryStore(db)
+	fileStore := store.NewFileStore(db)
+
+	pipeline := ast.NewPipeline()
+	pipeline.SetStdlibInterfaces(cfg.Indexer.StdlibInterfaces)
+	pipeline.SetCallGraph(cfg.Indexer.CallGraph)
+	pipeline.SetTests(cfg.Indexer.Tests)
+	pipeline.SetBuildContexts(buildContexts(cfg.Indexer.BuildContexts))
+
+	semanticGen := semantic.NewGenerator()
+	textBuilder, err := newEmbedTextBuilder(&cfg.Embedding, semanticGen, packageStore, edgeStore)
+	if err != nil {
+		if cacheDB != nil {
+			cacheDB.Close()
+		}
+		db.Close()
+		return nil, err
+	}
 
 	return &Indexer{
 		cfg:          cfg,
 		db:           db,
-		pipeline:     ast.NewPipeline(),
+		pipeline:     pipeline,
 		embedService: embedService,
-		semanticGen:  semantic.NewGenerator(),
+		semanticGen:  semanticGen,
 		symbolStore:  symbolStore,
 		packageStore: packageStore,
 		edgeStore:    edgeStore,
 		vectorStore:  vectorStore,
 		repoStore:    repoStore,
+		fileStore:    fileStore,
+		textBuilder:  textBuilder,
+
+		cacheDB:        cacheDB,
+		embeddingCache: embeddingCache,
 	}, nil
 }
 
+// SetForce makes the next IndexRepository rebuild the index from scratch.
+// Embeddings are still served from the embedding cache.
+func (idx *Indexer) SetForce(force bool) {
+	idx.force = force
+}
+
 // IndexRepository indexes a repository with embeddings
 func (idx *Indexer) IndexRepository(ctx context.Context, repoPath string) error {
 	startTime := time.Now()
@@ -84,10 +140,22 @@ func (idx *Indexer) IndexRepository(ctx context.Context, repoPath string) error
 		return fmt.Errorf("failed to load repository metadata: %w", err)
 	}
 
+	// Hash source files up front so recorded hashes match what was extracted
+	sourceFiles, err := scanSourceFiles(repoPath)
+	if err != nil {
+		return err
+	}
+
 	var symbols []*ast.ExtractedSymbol
 	var edges []*ast.Edge
+	var previousVectors map[string]*store.StoredVector
 
-	if repoMeta == nil || repoMeta.LastIndexedAt == nil || repoMeta.LastIndexedAt.IsZero() {
+	// Packages re-extracted by an incremental run and their calls edges
+	// before it, to find callers and callees whose embedding text changed
+	var extractedPackages map[string]bool
+	var previousCalls map[string]*store.Edge
+
+	if idx.force || repoMeta == nil || repoMeta.LastIndexedAt == nil || repoMeta.LastIndexedAt.IsZero() {
 		if err := idx.resetRepository(targetRepoPath); err != nil {
 			return err
 		}
@@ -101,32 +169,91 @@ func (idx *Indexer) IndexRepository(ctx context.Context, repoPath string) error
 		log.Printf("Extracted %d symbols and %d relations", len(symbols), len(edges))
 	} else {
 		log.Printf("Incremental indexing since %s", repoMeta.LastIndexedAt.UTC().Format(time.RFC3339))
-		changedPackages, err := idx.findChangedPackages(repoPath, targetRepoPath, *repoMeta.LastIndexedAt)
+		changes, err := idx.findChangedFiles(repoPath, targetRepoPath, *repoMeta.LastIndexedAt, sourceFiles)
 		if err != nil {
-			return fmt.Errorf("failed to detect changed packages: %w", err)
+			return fmt.Errorf("failed to detect changed files: %w", err)
 		}
 
-		if len(changedPackages) == 0 {
+		if changes.IsEmpty() || len(changes.Packages) == 0 {
 			log.Printf("No package changes detected for %s", targetRepoPath)
+			if err := idx.recordFileHashes(targetRepoPath, sourceFiles); err != nil {
+				return err
+			}
 			if err := idx.updateRepositoryMeta(targetRepoPath); err != nil {
 				return err
 			}
 			return nil
 		}
 
-		log.Printf("Detected %d changed package(s)", len(changedPackages))
-		if err := idx.clearPackages(changedPackages); err != nil {
-			return err
+		logFileChanges(changes)
+		if idx.cfg.Indexer.Tests {
+			// Files of an external test package resolve to the package
+			// they test
+			for pkgPath := range changes.Packages {
+				changes.Packages[pkgPath+"_test"] = true
+			}
 		}
+		log.Printf("Re-extracting %d changed package(s)", len(changes.Packages))
 
-		for pkgPath := range changedPackages {
-			pkgSymbols, pkgEdges, err := idx.pipeline.ExtractPackageWithRelationsByPath(pkgPath, repoPath)
+		// Keep existing vectors so unchanged symbols are not re-embedded
+		previousVectors = make(map[string]*store.StoredVector)
+		for pkgPath := range changes.Packages {
+			vectors, err := idx.vectorStore.GetByPackage(pkgPath)
 			if err != nil {
-				log.Printf("Warning: failed to extract package %s: %v", pkgPath, err)
-				continue
+				return fmt.Errorf("failed to load embeddings for package %s: %w", pkgPath, err)
+			}
+			for id, vector := range vectors {
+				previousVectors[id] = vector
 			}
-			symbols = append(symbols, pkgSymbols...)
-			edges = append(edges, pkgEdges...)
+		}
+
+		extractedPackages = make(map[string]bool)
+		for pkgPath := range changes.Packages {
+			extractedPackages[pkgPath] = true
+		}
+		previousCalls, err = idx.callEdges(changes.Packages)
+		if err != nil {
+			return err
+		}
+
+		if err := idx.clearPackages(changes.Packages); err != nil {
+			return err
+		}
+
+		// The whole repository is loaded so implementations in other
+		// packages are re-checked against the changed ones
+		symbols, edges, err = idx.pipeline.ExtractPackagesWithRelations(repoPath, changes.Packages)
+		if err != nil {
+			return fmt.Errorf("failed to extract changed packages: %w", err)
+		}
+
+		// Symbols outside the changed packages (standard library
+		// interfaces) are rebuilt on each run
+		rebuilt := make(map[string]bool)
+		for _, sym := range symbols {
+			if !changes.Packages[sym.PackagePath] && !rebuilt[sym.PackagePath] {
+				rebuilt[sym.PackagePath] = true
+				vectors, err := idx.vectorStore.GetByPackage(sym.PackagePath)
+				if err != nil {
+					return fmt.Errorf("failed to load embeddings for package %s: %w", sym.PackagePath, err)
+				}
+				for id, vector := range vectors {
+					previousVectors[id] = vector
+				}
+			}
+		}
+		rebuiltCalls, err := idx.callEdges(rebuilt)
+		if err != nil {
+			return err
+		}
+		for key, edge := range rebuiltCalls {
+			previousCalls[key] = edge
+		}
+		for pkgPath := range rebuilt {
+			extractedPackages[pkgPath] = true
+		}
+		if err := idx.clearPackages(rebuilt); err != nil {
+			return err
 		}
 
 		log.Printf("Extracted %d symbols and %d relations from changed packages", len(symbols), len(edges))
@@ -158,12 +285,45 @@ func (idx *Indexer) IndexRepository(ctx context.Context, repoPath string) error
 		return fmt.Errorf("failed to store edges: %w", err)
 	}
 
-	// Step 6: Generate and store embeddings
+	// Step 6: Generate and store embeddings. Symbols in other packages list
+	// their callers and callees in their embedding text, so those at the far
+	// end of added or removed calls edges are embedded again too.
+	toEmbed := symbolData
+	if extractedPackages != nil {
+		neighbors, err := idx.changedCallNeighbors(extractedPackages, previousCalls, previousVectors)
+		if err != nil {
+			return err
+		}
+		if len(neighbors) > 0 {
+			log.Printf("Updating embeddings of %d symbol(s) whose callers or callees changed", len(neighbors))
+			toEmbed = append(append([]*store.Symbol{}, symbolData...), neighbors...)
+		}
+	}
+
 	log.Printf("Generating embeddings")
-	if err := idx.indexEmbeddings(ctx, symbols); err != nil {
+	if err := idx.indexEmbeddings(ctx, repoPath, toEmbed, previousVectors); err != nil {
 		return fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
+	if idx.cfg.Indexer.ChunkBodies {
+		if err := idx.indexChunks(ctx, repoPath, symbolData); err != nil {
+			return fmt.Errorf("failed to embed body chunks: %w", err)
+		}
+	}
+
+	// Step 7: Build or refresh the ANN index
+	rebuilt, err := idx.vectorStore.EnsureIndex()
+	if err != nil {
+		return fmt.Errorf("failed to build vector index: %w", err)
+	}
+	if rebuilt {
+		log.Printf("Rebuilt vector index")
+	}
+
+	if err := idx.recordFileHashes(targetRepoPath, sourceFiles); err != nil {
+		return err
+	}
+
 	if err := idx.updateRepositoryMeta(targetRepoPath); err != nil {
 		return err
 	}
@@ -193,92 +353,11 @@ func (idx *Indexer) resetRepository(repoPath string) error {
 	if err := idx.symbolStore.DeleteByRepo(repoPath); err != nil {
 		return fmt.Errorf("failed to clear symbols: %w", err)
 	}
-
-	return nil
-}
-
-func (idx *Indexer) findChangedPackages(repoPath string, dbRepoPath string, since time.Time) (map[string]bool, error) {
-	changed := make(map[string]bool)
-	fileSymbols, err := idx.symbolStore.ListFilesByRepo(dbRepoPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load indexed file symbols: %w", err)
-	}
-
-	// fileSymbols contain relative paths (e.g., "internal/pkg/file.go")
-	// We need to check if these files exist by converting to absolute paths
-	fileToPackage := make(map[string]string, len(fileSymbols))
-	for _, fileSymbol := range fileSymbols {
-		fileToPackage[fileSymbol.FilePath] = fileSymbol.PackagePath
-	}
-
-	// Check if indexed files still exist
-	for relFilePath, pkgPath := range fileToPackage {
-		absFilePath := filepath.Join(repoPath, relFilePath)
-		if _, err := os.Stat(absFilePath); err != nil {
-			if os.IsNotExist(err) {
-				changed[pkgPath] = true
-				continue
-			}
-			return nil, fmt.Errorf("failed to stat file %s: %w", absFilePath, err)
-		}
-	}
-
-	walkFn := func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if d.IsDir() {
-			switch d.Name() {
-			case ".git", "vendor", "third_party":
-				return fs.SkipDir
-			}
-			return nil
-		}
-
-		if filepath.Ext(path) != ".go" {
-			return nil
-		}
-
-		info, err := d.Info()
-		if err != nil {
-			return nil
-		}
-
-		if info.ModTime().After(since) {
-			// Convert absolute path to relative for lookup
-			relPath, err := filepath.Rel(repoPath, path)
-			if err != nil {
-				log.Printf("Warning: failed to make relative path for %s: %v", path, err)
-				return nil
-			}
-			relPath = filepath.ToSlash(relPath)
-
-			// Check if we already know this file's package
-			if pkgPath, ok := fileToPackage[relPath]; ok && pkgPath != "" {
-				changed[pkgPath] = true
-				return nil
-			}
-
-			// New file or changed file, resolve its package
-			pkgPath, err := idx.resolvePackagePath(repoPath, path)
-			if err != nil {
-				log.Printf("Warning: failed to resolve package for %s: %v", path, err)
-				return nil
-			}
-			if pkgPath != "" {
-				changed[pkgPath] = true
-			}
-		}
-
-		return nil
-	}
-
-	if err := filepath.WalkDir(repoPath, walkFn); err != nil {
-		return nil, fmt.Errorf("failed to scan repository files: %w", err)
+	if err := idx.fileStore.DeleteByRepo(repoPath); err != nil {
+		return fmt.Errorf("failed to clear file hashes: %w", err)
 	}
 
-	return changed, nil
+	return nil
 }
 
 func (idx *Indexer) resolvePackagePath(repoRoot string, filePath string) (string, error) {
@@ -326,6 +405,76 @@ func (idx *Indexer) clearPackages(packagePaths map[string]bool) error {
 	return nil
 }
 
+// callEdges returns the calls edges from or to the symbols of the given
+// packages, keyed by caller and callee
+func (idx *Indexer) callEdges(packagePaths map[string]bool) (map[string]*store.Edge, error) {
+	edges := make(map[string]*store.Edge)
+	for pkgPath := range packagePaths {
+		pkgEdges, err := idx.edgeStore.GetByPackage(pkgPath, store.EdgeTypeCalls)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load calls edges for package %s: %w", pkgPath, err)
+		}
+		for _, edge := range pkgEdges {
+			edges[edge.FromID+" "+edge.ToID] = edge
+		}
+	}
+	return edges, nil
+}
+
+// changedCallNeighbors returns the symbols outside the re-extracted packages
+// at either end of a calls edge that was added or removed, comparing the
+// current edges of the packages with previous. Their stored vectors are added
+// to previousVectors so unchanged texts keep their embedding.
+func (idx *Indexer) changedCallNeighbors(packagePaths map[string]bool, previous map[string]*store.Edge, previousVectors map[string]*store.StoredVector) ([]*store.Symbol, error) {
+	current, err := idx.callEdges(packagePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	ends := make(map[string]bool)
+	for key, edge := range previous {
+		if current[key] == nil {
+			ends[edge.FromID] = true
+			ends[edge.ToID] = true
+		}
+	}
+	for key, edge := range current {
+		if previous[key] == nil {
+			ends[edge.FromID] = true
+			ends[edge.ToID] = true
+		}
+	}
+
+	var neighbors []*store.Symbol
+	loaded := make(map[string]bool)
+	for id := range ends {
+		sym, err := idx.symbolStore.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if sym == nil || packagePaths[sym.PackagePath] {
+			continue // Removed, or embedded with its package
+		}
+
+		if !loaded[sym.PackagePath] {
+			loaded[sym.PackagePath] = true
+			vectors, err := idx.vectorStore.GetByPackage(sym.PackagePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load embeddings for package %s: %w", sym.PackagePath, err)
+			}
+			for vectorID, vector := range vectors {
+				previousVectors[vectorID] = vector
+			}
+		}
+		neighbors = append(neighbors, sym)
+	}
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		return neighbors[i].ID < neighbors[j].ID
+	})
+	return neighbors, nil
+}
+
 func (idx *Indexer) updateRepositoryMeta(repoPath string) error {
 	symbolCount, err := idx.symbolStore.CountByRepo(repoPath)
 	if err != nil {
@@ -376,8 +525,9 @@ func (idx *Indexer) prepareSymbols(extracted []*ast.ExtractedSymbol) []*store.Sy
 		}
 	}
 
+	// Package cards describe production code only
 	for _, sym := range extracted {
-		if sym.Kind != "package" {
+		if sym.Kind != "package" && !ast.IsTestSymbol(sym) {
 			pkgSymbols[sym.PackagePath] = append(pkgSymbols[sym.PackagePath], sym)
 		}
 	}
@@ -385,19 +535,21 @@ func (idx *Indexer) prepareSymbols(extracted []*ast.ExtractedSymbol) []*store.Sy
 	// Generate semantic text for each symbol
 	for i, sym := range extracted {
 		symbols[i] = &store.Symbol{
-			ID:          sym.ID,
-			RepoPath:    idx.cfg.Repo.Path,
-			Kind:        sym.Kind,
-			PackagePath: sym.PackagePath,
-			PackageName: sym.PackageName,
-			Name:        sym.Name,
-			Signature:   sym.Signature,
-			FilePath:    sym.FilePath,
-			LineStart:   sym.LineStart,
-			LineEnd:     sym.LineEnd,
-			DocComment:  sym.DocComment,
-			Exported:    sym.Exported,
+			ID:            sym.ID,
+			RepoPath:      idx.cfg.Repo.Path,
+			Kind:          sym.Kind,
+			PackagePath:   sym.PackagePath,
+			PackageName:   sym.PackageName,
+			Name:          sym.Name,
+			Signature:     sym.Signature,
+			FilePath:      sym.FilePath,
+			LineStart:     sym.LineStart,
+			LineEnd:       sym.LineEnd,
+			DocComment:    sym.DocComment,
+			Exported:      sym.Exported,
+			BuildContexts: sym.BuildContexts,
 		}
+		symbols[i].TypeDetails = typeDetails(sym)
 
 		// Generate semantic text
 		if sym.Kind == "package" {
@@ -435,6 +587,29 @@ func (idx *Indexer) prepareSymbols(extracted []*ast.ExtractedSymbol) []*store.Sy
 	return symbols
 }
 
+// typeDetails returns the details of a symbol kept in TypeDetails: type
+// parameters and enumeration membership, or nil
+func typeDetails(sym *ast.ExtractedSymbol) *store.TypeDetails {
+	if len(sym.TypeParams) == 0 && sym.Group == "" && len(sym.Members) == 0 {
+		return nil
+	}
+
+	details := &store.TypeDetails{Group: sym.Group, Members: sym.Members}
+	for _, param := range sym.TypeParams {
+		details.TypeParams = append(details.TypeParams, store.TypeParam{Name: param.Name, Constraint: param.Constraint})
+	}
+	return details
+}
+
+// buildContexts converts the configured build contexts for the pipeline
+func buildContexts(contexts []config.BuildContext) []ast.BuildContext {
+	var result []ast.BuildContext
+	for _, bc := range contexts {
+		result = append(result, ast.BuildContext{Name: bc.Label(), GOOS: bc.GOOS, GOARCH: bc.GOARCH, Tags: bc.Tags})
+	}
+	return result
+}
+
 // preparePackages prepares package records
 func (idx *Indexer) preparePackages(symbols []*ast.ExtractedSymbol) []*store.Package {
 	pkgMap := make(map[string]*store.Package)
@@ -451,13 +626,13 @@ func (idx *Indexer) preparePackages(symbols []*ast.ExtractedSymbol) []*store.Pac
 	}
 
 	for _, sym := range symbols {
-		if sym.Kind != "package" {
+		if sym.Kind != "package" && !ast.IsTestSymbol(sym) {
 			pkgSymbols[sym.PackagePath] = append(pkgSymbols[sym.PackagePath], sym)
 		}
 	}
 
 	for _, sym := range symbols {
-		if sym.Kind != "package" {
+		if sym.Kind != "package" || ast.IsTestSymbol(sym) {
 			continue
 		}
 
@@ -483,7 +658,7 @@ func (idx *Indexer) preparePackages(symbols []*ast.ExtractedSymbol) []*store.Pac
 
 		// Count symbols in this package
 		for _, s := range symbols {
-			if s.PackagePath == sym.PackagePath {
+			if s.PackagePath == sym.PackagePath && !ast.IsTestSymbol(s) {
 				pkg.SymbolCount++
 			}
 		}
@@ -500,13 +675,15 @@ func (idx *Indexer) preparePackages(symbols []*ast.ExtractedSymbol) []*store.Pac
 	return packages
 }
 
-// indexEmbeddings generates and stores embeddings for symbols
-func (idx *Indexer) indexEmbeddings(ctx context.Context, symbols []*ast.ExtractedSymbol) error {
+// indexEmbeddings generates and stores embeddings for stored symbols. Their
+// edges and packages must already be stored, since the embedding text
+// includes callers, callees and the package card. Vectors in previous whose
+// content hash still matches are reused instead of re-embedded.
+func (idx *Indexer) indexEmbeddings(ctx context.Context, repoPath string, symbols []*store.Symbol, previous map[string]*store.StoredVector) error {
 	// Filter symbols that should be embedded (skip packages and files)
-	toEmbed := make([]*ast.ExtractedSymbol, 0)
+	toEmbed := make([]*store.Symbol, 0)
 	for _, sym := range symbols {
-		if sym.Kind == "func" || sym.Kind == "method" ||
-			sym.Kind == "struct" || sym.Kind == "interface" {
+		if embeddable(sym) {
 			toEmbed = append(toEmbed, sym)
 		}
 	}
@@ -515,37 +692,101 @@ func (idx *Indexer) indexEmbeddings(ctx context.Context, symbols []*ast.Extracte
 		return nil
 	}
 
-	// Get symbol IDs and prepare semantic texts
-	// We need to retrieve the symbols from the store to get the semantic text
+	idx.textBuilder.reset()
+	sources := newSourceLines(repoPath)
+	model := idx.cfg.Embedding.Model
+
 	symbolIDs := make([]string, len(toEmbed))
-	texts := make([]string, len(toEmbed))
+	hashes := make([]string, len(toEmbed))
+	vectors := make([][]float32, len(toEmbed))
+
+	// Texts that need a fresh embedding, and where their vectors go
+	var texts []string
+	var pending []int
 
 	for i, sym := range toEmbed {
+		text, err := idx.textBuilder.build(sym, sources)
+		if err != nil {
+			return err
+		}
+
 		symbolIDs[i] = sym.ID
-		// Use signature + doc for embedding (semantic text is in the store, not ExtractedSymbol)
-		text := sym.Signature
-		if sym.DocComment != "" {
-			text += "\n" + sym.DocComment
+		hashes[i] = symbolContentHash(sym, text)
+
+		if prev, ok := previous[sym.ID]; ok && prev.ContentHash == hashes[i] && prev.Model == model {
+			vectors[i] = prev.Vector
+			continue
+		}
+
+		texts = append(texts, text)
+		pending = append(pending, i)
+	}
+
+	// Store reused vectors right away
+	if len(texts) < len(toEmbed) {
+		reusedIDs := make([]string, 0, len(toEmbed)-len(texts))
+		reusedVectors := make([][]float32, 0, len(toEmbed)-len(texts))
+		reusedHashes := make([]string, 0, len(toEmbed)-len(texts))
+		for i := range toEmbed {
+			if vectors[i] != nil {
+				reusedIDs = append(reusedIDs, symbolIDs[i])
+				reusedVectors = append(reusedVectors, vectors[i])
+				reusedHashes = append(reusedHashes, hashes[i])
+			}
 		}
-		texts[i] = text
+		log.Printf("Reusing %d embeddings of unchanged symbols", len(reusedIDs))
+		if err := idx.vectorStore.InsertBatchWithHashes(reusedIDs, reusedVectors, reusedHashes, model); err != nil {
+			return fmt.Errorf("failed to store embeddings: %w", err)
+		}
+	}
+
+	if len(texts) == 0 {
+		return nil
 	}
 
-	// Generate embeddings in batch
+	// Persist every completed batch, so a failed run keeps its progress and
+	// the next run resumes from the embedding cache and stored vectors
 	log.Printf("Generating embeddings for %d symbols", len(texts))
-	embeddings, err := idx.embedService.EmbedBatch(ctx, texts)
-	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+	persist := func(indices []int, embeddings [][]float32) error {
+		ids := make([]string, len(indices))
+		batchHashes := make([]string, len(indices))
+		for j, textIndex := range indices {
+			i := pending[textIndex]
+			ids[j] = symbolIDs[i]
+			batchHashes[j] = hashes[i]
+		}
+		if err := idx.vectorStore.InsertBatchWithHashes(ids, embeddings, batchHashes, model); err != nil {
+			return fmt.Errorf("failed to store embeddings: %w", err)
+		}
+		return nil
 	}
 
-	// Store embeddings
-	log.Printf("Storing embeddings")
-	if err := idx.vectorStore.InsertBatch(symbolIDs, embeddings, idx.cfg.Embedding.Model); err != nil {
-		return fmt.Errorf("failed to store embeddings: %w", err)
+	if _, err := idx.embedService.EmbedBatchFunc(ctx, texts, persist); err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
 	return nil
 }
 
+// embeddable reports whether a symbol gets an embedding: functions, methods,
+// types, consts and vars, and exported named struct fields. An enumeration
+// is embedded once, through its first member. The test layer is not embedded.
+func embeddable(sym *store.Symbol) bool {
+	if store.IsTestSymbol(sym) {
+		return false
+	}
+	switch sym.Kind {
+	case store.KindFunc, store.KindMethod, store.KindStruct, store.KindInterface, store.KindType:
+		return true
+	case store.KindConst, store.KindVar:
+		return sym.Name != "_" && (sym.TypeDetails == nil || sym.TypeDetails.Group == "")
+	case store.KindField:
+		return sym.Exported && !strings.HasPrefix(sym.Signature, "embedded ")
+	default:
+		return false
+	}
+}
+
 // convertEdges converts ast.Edge to store.Edge
 func (idx *Indexer) convertEdges(astEdges []*ast.Edge) []*store.Edge {
 	edges := make([]*store.Edge, len(astEdges))
@@ -556,8 +797,12 @@ func (idx *Indexer) convertEdges(astEdges []*ast.Edge) []*store.Edge {
 			EdgeType:   e.EdgeType,
 			Weight:     e.Weight,
 			ImportPath: e.ImportPath,
+			Provenance: e.Provenance,
 			CreatedAt:  time.Now(),
 		}
+		if e.Line > 0 {
+			edges[i].Sites = []store.EdgeSite{{FilePath: e.FilePath, Line: e.Line, Column: e.Column}}
+		}
 	}
 	return edges
 }
@@ -576,13 +821,55 @@ func (idx *Indexer) extractKeywords(sym *ast.ExtractedSymbol) []string {
 		keywords = append(keywords, sym.Kind)
 	}
 
-	// TODO: Add more sophisticated keyword extraction
+	// Add the types of values and fields, the struct of fields, the members
+	// of enumerations and the text of string constants
+	for _, typ := range []string{sym.ValueType, sym.FieldType} {
+		if name := strings.TrimLeft(typ, "*[]."); name != "" {
+			keywords = append(keywords, name)
+		}
+	}
+	if sym.Kind == "field" && sym.ParentID != "" {
+		keywords = append(keywords, sym.ParentID[strings.LastIndex(sym.ParentID, ":")+1:])
+	}
+	for _, member := range sym.Members {
+		if member != sym.Name {
+			keywords = append(keywords, member)
+		}
+	}
+	if sym.Kind == "const" {
+		if text, err := strconv.Unquote(sym.Value); err == nil && text != "" {
+			keywords = append(keywords, text)
+		}
+	}
+
+	// Add the words of identifiers so "vector store" matches VectorStore.
+	// The host of the package path (e.g. github.com) matches every symbol
+	// and is left out.
+	pkgPath := sym.PackagePath
+	if host, rest, ok := strings.Cut(pkgPath, "/"); ok && strings.Contains(host, ".") {
+		pkgPath = rest
+	}
+	seen := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		seen[keyword] = true
+	}
+	for _, text := range []string{sym.Name, sym.Signature, sym.Receiver, pkgPath} {
+		for _, token := range store.IdentifierTokens(text) {
+			if !seen[token] {
+				seen[token] = true
+				keywords = append(keywords, token)
+			}
+		}
+	}
 
 	return keywords
 }
 
 // Close closes the indexer and releases resources
 func (idx *Indexer) Close() error {
+	if idx.cacheDB != nil {
+		idx.cacheDB.Close()
+	}
 	return idx.db.Close()
 }
 
@@ -596,6 +883,16 @@ func (idx *Indexer) GetEmbedService() *embedding.Service {
 	return idx.embedService
 }
 
+// GetEmbeddingCache returns the embedding cache (nil when disabled)
+func (idx *Indexer) GetEmbeddingCache() *store.EmbeddingCache {
+	return idx.embeddingCache
+}
+
+// GetRerankCache returns the cache of rerank scores, kept in the index
+func (idx *Indexer) GetRerankCache() *store.RerankCache {
+	return store.NewRerankCache(idx.db)
+}
+
 // GetRepoStore returns the repository store
 func (idx *Indexer) GetRepoStore() *store.RepositoryStore {
 	return idx.repoStore
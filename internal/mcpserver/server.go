@@ -13,6 +13,7 @@ import (
 
 	"github.com/DreamCats/bcindex/internal/config"
 	"github.com/DreamCats/bcindex/internal/indexer"
+	"github.com/DreamCats/bcindex/internal/rerank"
 	"github.com/DreamCats/bcindex/internal/retrieval"
 	"github.com/DreamCats/bcindex/internal/store"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -44,7 +45,15 @@ func (s *Server) Run(ctx context.Context) error {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "bcindex_locate",
-		Description: "Locate symbols, files, or APIs (quick lookup for definitions/usages).",
+		Description: `Locate symbols, files, or APIs (quick lookup for definitions/usages).
+
+Query qualifiers (the remaining words are searched; qualifiers alone list matches):
+- kind:method,func (-kind: excludes)
+- recv:*VectorStore (methods of a receiver type, * for pointer receivers)
+- pkg:internal/store/... (-pkg: excludes)
+- file:*_test.go (name or path glob, dir/... for a tree; -file: excludes)
+- calls:EmbedBatch (callers; Name, Type.Method or pkg.Name)
+- implements:embedding.Client (implementations of an interface)`,
 	}, s.searchTool)
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -58,6 +67,7 @@ Filters:
   - "extension": Prefer interfaces, middleware, extension points
 - kind_filter: Filter by symbol type (func, method, struct, interface, type)
 - layer_filter: Filter by architectural layer (handler, service, repository, domain, middleware, util)
+- build_contexts: Only symbols compiled in these build contexts (e.g. windows, linux/arm64)
 
 Use filters to get precise context for your task, reducing noise and token usage.`,
 	}, s.evidenceTool)
@@ -70,9 +80,13 @@ Supported edge types:
 - implements: Find types implementing an interface
 - imports: Find packages importing a package
 - embeds: Find types embedding a struct
-- references: Find references to a symbol
+- references: Find all uses of a type, const, var, field or function value (incoming), or what a declaration uses (outgoing)
+- dispatches: Find the concrete methods run by an interface method call (outgoing), or the interface methods a method is called through (incoming)
+- calls: Find callers (incoming) or callees (outgoing) of a function
+- constrains: Find the generic functions and types constrained by an interface (incoming), or the constraints of a generic declaration (outgoing)
+- tests: Find the tests, benchmarks, fuzz tests and examples covering a symbol, directly or through test helpers (incoming), or what a test covers (outgoing); requires indexer.tests
 
-NOTE: For function call hierarchy (who calls this function), use get_call_hierarchy from byte-lsp-mcp instead.`,
+Call and reference edges list every site (file, line, column) with a one-line source preview; count is the number of sites. Edges are ordered by count.`,
 	}, s.refsTool)
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -94,6 +108,18 @@ Options:
 - include_line_no: Include line numbers in output (default: true)`,
 	}, s.readTool)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name: "bcindex_similar",
+		Description: `Find code similar to a symbol or a snippet ("more like this"), ranked by embedding similarity.
+
+Input (one of):
+- symbol_id: An indexed symbol (e.g. from bcindex_locate)
+- file_path + line: The innermost declaration at that line
+- snippet: Code or text to embed, e.g. to find duplicates of a block
+
+The symbol itself is never returned. Functions are compared with functions and methods, types with types, unless kind_filter is set. Use exclude_same_package to find other implementations elsewhere.`,
+	}, s.similarTool)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name: "bcindex_status",
 		Description: `Check the status of the bcindex for a repository.
@@ -130,6 +156,10 @@ func (s *Server) searchTool(ctx context.Context, _ *mcp.CallToolRequest, input S
 	if input.VectorOnly && input.KeywordOnly {
 		return nil, SearchOutput{}, fmt.Errorf("vector_only and keyword_only cannot both be true")
 	}
+	parsed, err := retrieval.ParseQuery(input.Query)
+	if err != nil {
+		return nil, SearchOutput{}, err
+	}
 
 	repoPath := input.Repo
 	if repoPath == "" {
@@ -161,9 +191,14 @@ func (s *Server) searchTool(ctx context.Context, _ *mcp.CallToolRequest, input S
 		idx.GetEmbedService(),
 		expander,
 	)
+	configureReranker(cfg, idx, retriever)
 
 	opts := buildSearchOptions(cfg, input.TopK, input.IncludeUnexported, input.VectorOnly, input.KeywordOnly)
-	results, err := retriever.Search(ctx, input.Query, opts)
+	opts.PackagePath = input.PackagePath
+	opts.Kinds = input.KindFilter
+	opts.BuildContexts = input.BuildContexts
+	parsed.Apply(&opts)
+	results, err := retriever.Search(ctx, parsed.Text, opts)
 	if err != nil {
 		return nil, SearchOutput{}, err
 	}
@@ -211,6 +246,7 @@ func (s *Server) evidenceTool(ctx context.Context, _ *mcp.CallToolRequest, input
 		idx.GetEmbedService(),
 		expander,
 	)
+	configureReranker(cfg, idx, retriever)
 
 	evidenceBuilder := retriever.GetEvidenceBuilder()
 	evidenceBuilder.SetMaxPackages(pickInt(input.MaxPackages, cfg.Evidence.MaxPackages))
@@ -223,6 +259,7 @@ func (s *Server) evidenceTool(ctx context.Context, _ *mcp.CallToolRequest, input
 	opts.Intent = input.Intent
 	opts.Kinds = input.KindFilter
 	opts.LayerFilter = input.LayerFilter
+	opts.BuildContexts = input.BuildContexts
 	pack, err := retriever.SearchAsEvidencePack(ctx, input.Query, opts)
 	if err != nil {
 		return nil, EvidenceOutput{}, err
@@ -255,88 +292,97 @@ func (s *Server) refsTool(ctx context.Context, _ *mcp.CallToolRequest, input Ref
 
 	symbolStore, _, edgeStore, _ := idx.GetStores()
 
-	var symbols []*store.Symbol
-	if input.SymbolID != "" {
-		sym, err := symbolStore.Get(input.SymbolID)
-		if err != nil {
-			return nil, RefsOutput{}, err
-		}
-		if sym != nil {
-			symbols = []*store.Symbol{sym}
-		}
-	} else {
-		limit := input.TopK
-		if limit <= 0 {
-			limit = 20
-		}
-		symbols, err = symbolStore.FindByName(input.SymbolName, cfg.Repo.Path, input.PackagePath, limit)
-		if err != nil {
-			return nil, RefsOutput{}, err
-		}
+	finder := retrieval.NewRefFinder(symbolStore, edgeStore, cfg.Repo.Path)
+	result, err := finder.Find(retrieval.RefQuery{
+		SymbolID:    input.SymbolID,
+		SymbolName:  input.SymbolName,
+		PackagePath: input.PackagePath,
+		EdgeType:    input.EdgeType,
+		Direction:   input.Direction,
+		Limit:       input.TopK,
+	})
+	if err != nil {
+		return nil, RefsOutput{}, err
+	}
+
+	edges := make([]RefEdge, 0, len(result.Refs))
+	for _, ref := range result.Refs {
+		edges = append(edges, toRefEdge(ref))
 	}
 
 	output := RefsOutput{
 		SymbolID:   input.SymbolID,
 		SymbolName: input.SymbolName,
-		Direction:  normalizeDirection(input.Direction),
+		Direction:  result.Direction,
 		EdgeType:   strings.TrimSpace(input.EdgeType),
-		Symbols:    mapRefSymbols(symbols),
-		Edges:      []RefEdge{},
-		Count:      0,
+		Symbols:    mapRefSymbols(result.Symbols),
+		Edges:      edges,
+		Count:      len(edges),
 	}
 
-	if len(symbols) == 0 {
-		return nil, output, nil
-	}
+	return nil, output, nil
+}
 
-	direction := output.Direction
-	if direction == "" {
-		direction = "incoming"
-		output.Direction = direction
+func (s *Server) similarTool(ctx context.Context, _ *mcp.CallToolRequest, input SimilarInput) (*mcp.CallToolResult, SimilarOutput, error) {
+	if input.SymbolID == "" && input.FilePath == "" && strings.TrimSpace(input.Snippet) == "" {
+		return nil, SimilarOutput{}, fmt.Errorf("symbol_id, file_path or snippet is required")
 	}
-	if direction != "incoming" && direction != "outgoing" && direction != "both" {
-		return nil, RefsOutput{}, fmt.Errorf("invalid direction: %s", direction)
+	if input.SymbolID == "" && input.FilePath != "" && input.Line <= 0 {
+		return nil, SimilarOutput{}, fmt.Errorf("line is required with file_path")
 	}
 
-	edgeType := output.EdgeType
-	if edgeType != "" && !isValidEdgeType(edgeType) {
-		return nil, RefsOutput{}, fmt.Errorf("invalid edge_type: %s", edgeType)
+	repoPath := input.Repo
+	if repoPath == "" {
+		repoPath = s.defaultRepo
 	}
 
-	edgeMap := make(map[string]RefEdge)
-	for _, sym := range symbols {
-		if sym == nil {
-			continue
-		}
-
-		if direction == "incoming" || direction == "both" {
-			incoming, err := edgeStore.GetIncoming(sym.ID, edgeType)
-			if err != nil {
-				return nil, RefsOutput{}, err
-			}
-			collectRefEdges(edgeMap, incoming, symbolStore)
-		}
+	cfg, err := prepareConfig(s.baseConfig, repoPath)
+	if err != nil {
+		return nil, SimilarOutput{}, err
+	}
 
-		if direction == "outgoing" || direction == "both" {
-			outgoing, err := edgeStore.GetOutgoing(sym.ID, edgeType)
-			if err != nil {
-				return nil, RefsOutput{}, err
-			}
-			collectRefEdges(edgeMap, outgoing, symbolStore)
-		}
+	idx, err := indexer.NewIndexer(cfg)
+	if err != nil {
+		return nil, SimilarOutput{}, err
 	}
+	defer idx.Close()
 
-	edges := make([]RefEdge, 0, len(edgeMap))
-	for _, edge := range edgeMap {
-		edges = append(edges, edge)
+	filePath := input.FilePath
+	if filePath != "" {
+		filePath = filepath.ToSlash(filepath.Clean(filePath))
 	}
 
-	if input.TopK > 0 && len(edges) > input.TopK {
-		edges = edges[:input.TopK]
+	symbolStore, _, _, vectorStore := idx.GetStores()
+
+	finder := retrieval.NewSimilarFinder(vectorStore, symbolStore, idx.GetEmbedService())
+	result, err := finder.Find(ctx, retrieval.SimilarQuery{
+		SymbolID:           input.SymbolID,
+		FilePath:           filePath,
+		Line:               input.Line,
+		Snippet:            input.Snippet,
+		RepoPath:           cfg.Repo.Path,
+		Kinds:              input.KindFilter,
+		ExcludeSamePackage: input.ExcludeSamePackage,
+		Limit:              input.TopK,
+	})
+	if err != nil {
+		return nil, SimilarOutput{}, err
 	}
 
-	output.Edges = edges
-	output.Count = len(edges)
+	output := SimilarOutput{
+		Count:   len(result.Neighbors),
+		Results: make([]SimilarItem, 0, len(result.Neighbors)),
+	}
+	if result.Source != nil {
+		source := toRefSymbol(result.Source)
+		output.Source = &source
+	}
+	for _, neighbor := range result.Neighbors {
+		output.Results = append(output.Results, SimilarItem{
+			RefSymbol:  toRefSymbol(neighbor.Symbol),
+			Similarity: neighbor.Similarity,
+		})
+	}
 
 	return nil, output, nil
 }
@@ -789,6 +835,14 @@ func buildSearchOptions(cfg *config.Config, topK int, includeUnexported bool, ve
 	opts.KeywordWeight = cfg.Search.KeywordWeight
 	opts.GraphWeight = cfg.Search.GraphWeight
 	opts.EnableGraphRank = cfg.Search.EnableGraphRank
+	opts.RepoPath = cfg.Repo.Path
+	opts.Fusion = cfg.Search.Fusion
+	opts.RRFK = cfg.Search.RRFK
+	opts.KeywordColumns = store.BM25Weights{
+		Name:         cfg.Search.BM25NameWeight,
+		SemanticText: cfg.Search.BM25TextWeight,
+		Tokens:       cfg.Search.BM25TokensWeight,
+	}
 
 	if topK > 0 {
 		opts.TopK = topK
@@ -809,6 +863,29 @@ func buildSearchOptions(cfg *config.Config, topK int, includeUnexported bool, ve
 	return opts
 }
 
+// configureReranker enables the rerank stage of the retriever when a rerank
+// provider is configured
+func configureReranker(cfg *config.Config, idx *indexer.Indexer, retriever *retrieval.HybridRetriever) {
+	if cfg.Search.Rerank.Provider == "" {
+		return
+	}
+
+	client, err := rerank.NewClient(&cfg.Search.Rerank)
+	if err != nil {
+		log.Printf("Warning: rerank disabled: %v", err)
+		return
+	}
+
+	var cache retrieval.RerankCache
+	if !cfg.Search.Rerank.DisableCache {
+		cache = idx.GetRerankCache()
+	}
+	retriever.SetReranker(client, cache, retrieval.RerankOptions{
+		TopN:   cfg.Search.Rerank.TopN,
+		Budget: cfg.Search.Rerank.Timeout,
+	})
+}
+
 func mapSearchResults(results []retrieval.SearchResult) []SearchResultItem {
 	items := make([]SearchResultItem, 0, len(results))
 	for _, result := range results {
@@ -831,8 +908,11 @@ func mapSearchResults(results []retrieval.SearchResult) []SearchResultItem {
 				Keyword:  result.KeywordScore,
 				Graph:    result.GraphScore,
 				Combined: result.CombinedScore,
+				Rerank:   result.RerankScore,
 			},
-			Reasons: result.Reason,
+			Reasons:      result.Reason,
+			MatchedLines: result.MatchedLines,
+			Contexts:     sym.BuildContexts,
 		})
 	}
 	return items
@@ -885,29 +965,6 @@ func toEvidenceOutput(pack *store.EvidencePack) EvidenceOutput {
 	}
 }
 
-func normalizeDirection(direction string) string {
-	if direction == "" {
-		return ""
-	}
-	return strings.ToLower(strings.TrimSpace(direction))
-}
-
-func isValidEdgeType(edgeType string) bool {
-	switch edgeType {
-	case store.EdgeTypeImplements,
-		store.EdgeTypeImports,
-		store.EdgeTypeReferences,
-		store.EdgeTypeEmbeds:
-		return true
-	case store.EdgeTypeCalls:
-		// calls edge type is not supported in bcindex_refs
-		// use get_call_hierarchy from byte-lsp-mcp instead
-		return false
-	default:
-		return false
-	}
-}
-
 func mapRefSymbols(symbols []*store.Symbol) []RefSymbol {
 	if len(symbols) == 0 {
 		return []RefSymbol{}
@@ -917,55 +974,56 @@ func mapRefSymbols(symbols []*store.Symbol) []RefSymbol {
 		if sym == nil {
 			continue
 		}
-		out = append(out, RefSymbol{
-			ID:          sym.ID,
-			Name:        sym.Name,
-			Kind:        sym.Kind,
-			PackagePath: sym.PackagePath,
-			FilePath:    sym.FilePath,
-			Line:        sym.LineStart,
-			Signature:   sym.Signature,
-		})
+		out = append(out, toRefSymbol(sym))
 	}
 	return out
 }
 
-func collectRefEdges(edgeMap map[string]RefEdge, edges []*store.Edge, symbolStore *store.SymbolStore) {
-	for _, edge := range edges {
-		if edge == nil {
-			continue
-		}
-		key := fmt.Sprintf("%s|%s|%s", edge.FromID, edge.ToID, edge.EdgeType)
-		if _, exists := edgeMap[key]; exists {
-			continue
-		}
+func toRefSymbol(sym *store.Symbol) RefSymbol {
+	return RefSymbol{
+		ID:          sym.ID,
+		Name:        sym.Name,
+		Kind:        sym.Kind,
+		PackagePath: sym.PackagePath,
+		FilePath:    sym.FilePath,
+		Line:        sym.LineStart,
+		Signature:   sym.Signature,
+	}
+}
 
-		fromSym, _ := symbolStore.Get(edge.FromID)
-		toSym, _ := symbolStore.Get(edge.ToID)
-		if fromSym == nil || toSym == nil {
-			continue
-		}
+func toRefEdge(ref *retrieval.Ref) RefEdge {
+	sites := make([]RefSite, 0, len(ref.Sites))
+	for _, site := range ref.Sites {
+		sites = append(sites, RefSite{
+			FilePath: site.FilePath,
+			Line:     site.Line,
+			Column:   site.Column,
+			Preview:  site.Preview,
+		})
+	}
 
-		edgeMap[key] = RefEdge{
-			EdgeType: edge.EdgeType,
-			From: RefEndpoint{
-				ID:          fromSym.ID,
-				Name:        fromSym.Name,
-				Kind:        fromSym.Kind,
-				PackagePath: fromSym.PackagePath,
-				FilePath:    fromSym.FilePath,
-				Line:        fromSym.LineStart,
-			},
-			To: RefEndpoint{
-				ID:          toSym.ID,
-				Name:        toSym.Name,
-				Kind:        toSym.Kind,
-				PackagePath: toSym.PackagePath,
-				FilePath:    toSym.FilePath,
-				Line:        toSym.LineStart,
-			},
-			ImportPath: edge.ImportPath,
-		}
+	return RefEdge{
+		EdgeType:   ref.Edge.EdgeType,
+		Provenance: ref.Edge.Provenance,
+		From: RefEndpoint{
+			ID:          ref.From.ID,
+			Name:        ref.From.Name,
+			Kind:        ref.From.Kind,
+			PackagePath: ref.From.PackagePath,
+			FilePath:    ref.From.FilePath,
+			Line:        ref.From.LineStart,
+		},
+		To: RefEndpoint{
+			ID:          ref.To.ID,
+			Name:        ref.To.Name,
+			Kind:        ref.To.Kind,
+			PackagePath: ref.To.PackagePath,
+			FilePath:    ref.To.FilePath,
+			Line:        ref.To.LineStart,
+		},
+		ImportPath: ref.Edge.ImportPath,
+		Count:      ref.Edge.Count,
+		Sites:      sites,
 	}
 }
 
@@ -1,15 +1,21 @@
 package mcpserver
 
-import "github.com/DreamCats/bcindex/internal/store"
+import (
+	"github.com/DreamCats/bcindex/internal/retrieval"
+	"github.com/DreamCats/bcindex/internal/store"
+)
 
 // SearchInput defines inputs for the bcindex_locate MCP tool.
 type SearchInput struct {
-	Query             string `json:"query" jsonschema:"search query (natural language or keywords)"`
-	Repo              string `json:"repo,omitempty" jsonschema:"repository root path (optional)"`
-	TopK              int    `json:"top_k,omitempty" jsonschema:"number of results to return"`
-	VectorOnly        bool   `json:"vector_only,omitempty" jsonschema:"use vector search only"`
-	KeywordOnly       bool   `json:"keyword_only,omitempty" jsonschema:"use keyword search only"`
-	IncludeUnexported bool   `json:"include_unexported,omitempty" jsonschema:"include unexported symbols"`
+	Query             string   `json:"query" jsonschema:"search query (natural language or keywords) with optional qualifiers: kind: recv: pkg: file: calls: implements: (-kind: -pkg: -file: exclude)"`
+	Repo              string   `json:"repo,omitempty" jsonschema:"repository root path (optional)"`
+	TopK              int      `json:"top_k,omitempty" jsonschema:"number of results to return"`
+	VectorOnly        bool     `json:"vector_only,omitempty" jsonschema:"use vector search only"`
+	KeywordOnly       bool     `json:"keyword_only,omitempty" jsonschema:"use keyword search only"`
+	IncludeUnexported bool     `json:"include_unexported,omitempty" jsonschema:"include unexported symbols"`
+	PackagePath       string   `json:"package_path,omitempty" jsonschema:"only search this package and its sub-packages (full or repo-relative path)"`
+	KindFilter        []string `json:"kind_filter,omitempty" jsonschema:"filter by symbol kind: func, method, struct, interface, type, const, var, field, test"`
+	BuildContexts     []string `json:"build_contexts,omitempty" jsonschema:"only symbols compiled in any of these build contexts (names from indexer.build_contexts)"`
 }
 
 // SearchScores includes per-signal scores for a result.
@@ -18,21 +24,24 @@ type SearchScores struct {
 	Keyword  float32 `json:"keyword"`
 	Graph    float64 `json:"graph"`
 	Combined float32 `json:"combined"`
+	Rerank   float64 `json:"rerank,omitempty"` // Set when the rerank stage ran
 }
 
 // SearchResultItem is a compact representation of a search result.
 type SearchResultItem struct {
-	ID           string       `json:"id"`
-	Name         string       `json:"name"`
-	Kind         string       `json:"kind"`
-	PackagePath  string       `json:"package_path"`
-	FilePath     string       `json:"file_path"`
-	Line         int          `json:"line"`
-	Signature    string       `json:"signature,omitempty"`
-	DocComment   string       `json:"doc_comment,omitempty"`
-	SemanticText string       `json:"semantic_text,omitempty"`
-	Scores       SearchScores `json:"scores"`
-	Reasons      []string     `json:"reasons,omitempty"`
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	Kind         string               `json:"kind"`
+	PackagePath  string               `json:"package_path"`
+	FilePath     string               `json:"file_path"`
+	Line         int                  `json:"line"`
+	Signature    string               `json:"signature,omitempty"`
+	DocComment   string               `json:"doc_comment,omitempty"`
+	SemanticText string               `json:"semantic_text,omitempty"`
+	Scores       SearchScores         `json:"scores"`
+	Reasons      []string             `json:"reasons,omitempty"`
+	MatchedLines *retrieval.LineRange `json:"matched_lines,omitempty"`  // Body lines that matched
+	Contexts     []string             `json:"build_contexts,omitempty"` // Build contexts the symbol is compiled in
 }
 
 // SearchOutput is the output for bcindex_locate.
@@ -53,8 +62,9 @@ type EvidenceInput struct {
 	MaxLines          int      `json:"max_lines,omitempty" jsonschema:"max total lines across snippets"`
 	IncludeUnexported bool     `json:"include_unexported,omitempty" jsonschema:"include unexported symbols"`
 	Intent            string   `json:"intent,omitempty" jsonschema:"query intent: design (architecture/interfaces), implementation (concrete code/details), extension (interfaces/middleware)"`
-	KindFilter        []string `json:"kind_filter,omitempty" jsonschema:"filter by symbol kind: func, method, struct, interface, type"`
+	KindFilter        []string `json:"kind_filter,omitempty" jsonschema:"filter by symbol kind: func, method, struct, interface, type, const, var, field, test"`
 	LayerFilter       []string `json:"layer_filter,omitempty" jsonschema:"filter by architectural layer: handler, service, repository, domain, middleware, util"`
+	BuildContexts     []string `json:"build_contexts,omitempty" jsonschema:"only symbols compiled in any of these build contexts (names from indexer.build_contexts)"`
 }
 
 // EvidenceMetadata is MCP-friendly metadata with string timestamps.
@@ -82,7 +92,7 @@ type RefsInput struct {
 	SymbolName  string `json:"symbol_name,omitempty" jsonschema:"symbol name (exact match)"`
 	PackagePath string `json:"package_path,omitempty" jsonschema:"filter by package path (optional)"`
 	Repo        string `json:"repo,omitempty" jsonschema:"repository root path (optional)"`
-	EdgeType    string `json:"edge_type,omitempty" jsonschema:"references|implements|imports|embeds (NOTE: for calls, use get_call_hierarchy instead)"`
+	EdgeType    string `json:"edge_type,omitempty" jsonschema:"calls|references|implements|imports|embeds|dispatches|constrains|tests"`
 	Direction   string `json:"direction,omitempty" jsonschema:"incoming|outgoing|both"`
 	TopK        int    `json:"top_k,omitempty" jsonschema:"max edges to return"`
 }
@@ -114,6 +124,17 @@ type RefEdge struct {
 	From       RefEndpoint `json:"from"`
 	To         RefEndpoint `json:"to"`
 	ImportPath string      `json:"import_path,omitempty"`
+	Provenance string      `json:"provenance,omitempty"` // static | cha | vta
+	Count      int         `json:"count"`                // number of sites
+	Sites      []RefSite   `json:"sites,omitempty"`
+}
+
+// RefSite is a call or reference site of an edge.
+type RefSite struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Preview  string `json:"preview,omitempty"`
 }
 
 // RefsOutput is the output for bcindex_refs.
@@ -127,6 +148,31 @@ type RefsOutput struct {
 	Edges      []RefEdge   `json:"edges"`
 }
 
+// SimilarInput defines inputs for the bcindex_similar MCP tool.
+type SimilarInput struct {
+	SymbolID           string   `json:"symbol_id,omitempty" jsonschema:"find code similar to this symbol (preferred)"`
+	FilePath           string   `json:"file_path,omitempty" jsonschema:"file relative to repository root; with line, selects the declaration at that line"`
+	Line               int      `json:"line,omitempty" jsonschema:"1-based line inside the declaration (with file_path)"`
+	Snippet            string   `json:"snippet,omitempty" jsonschema:"code or text to find similar code for (when no symbol is given)"`
+	Repo               string   `json:"repo,omitempty" jsonschema:"repository root path (optional)"`
+	TopK               int      `json:"top_k,omitempty" jsonschema:"max neighbors to return (default 10)"`
+	ExcludeSamePackage bool     `json:"exclude_same_package,omitempty" jsonschema:"skip neighbors in the symbol's own package"`
+	KindFilter         []string `json:"kind_filter,omitempty" jsonschema:"neighbor kinds (default: kinds comparable to the symbol)"`
+}
+
+// SimilarItem is a neighbor with its cosine similarity.
+type SimilarItem struct {
+	RefSymbol
+	Similarity float32 `json:"similarity"`
+}
+
+// SimilarOutput is the output for bcindex_similar.
+type SimilarOutput struct {
+	Source  *RefSymbol    `json:"source,omitempty"` // Nil for snippets
+	Count   int           `json:"count"`
+	Results []SimilarItem `json:"results"`
+}
+
 // ReadInput defines inputs for the bcindex_read MCP tool.
 type ReadInput struct {
 	SymbolID      string `json:"symbol_id,omitempty" jsonschema:"read code by symbol id (from bcindex_locate results)"`
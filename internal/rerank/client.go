@@ -0,0 +1,239 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+// Client scores documents by their relevance to a query through a /rerank
+// endpoint or an OpenAI-compatible chat model
+type Client struct {
+	provider string
+	endpoint string
+	apiKey   string
+	model    string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewClient creates a rerank client; the time budget is applied per call
+// through the context
+func NewClient(cfg *config.RerankConfig) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("rerank base_url is required")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("rerank model is required")
+	}
+
+	var endpoint string
+	switch cfg.Provider {
+	case "rerank":
+		endpoint = joinEndpoint(cfg.BaseURL, "/rerank")
+	case "chat":
+		endpoint = joinEndpoint(cfg.BaseURL, "/chat/completions")
+	default:
+		return nil, fmt.Errorf("unsupported rerank provider: %s", cfg.Provider)
+	}
+
+	return &Client{
+		provider: cfg.Provider,
+		endpoint: endpoint,
+		apiKey:   cfg.APIKey,
+		model:    cfg.Model,
+		headers:  cfg.Headers,
+		client:   &http.Client{},
+	}, nil
+}
+
+// joinEndpoint appends path to a base URL such as http://localhost:8000/v1
+// (a URL already ending in path is kept as is)
+func joinEndpoint(baseURL, path string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(baseURL, path) {
+		return baseURL
+	}
+	return baseURL + path
+}
+
+// Model returns the model name, which keys cached scores
+func (c *Client) Model() string {
+	return c.provider + "/" + c.model
+}
+
+// Rerank returns a relevance score for each document, in document order
+func (c *Client) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	if c.provider == "chat" {
+		return c.rerankChat(ctx, query, documents)
+	}
+	return c.rerankAPI(ctx, query, documents)
+}
+
+// rerankRequest is the body of a /rerank request
+type rerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+}
+
+// rerankResponse is the body of a /rerank response
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// rerankAPI scores documents through a /rerank endpoint
+func (c *Client) rerankAPI(ctx context.Context, query string, documents []string) ([]float64, error) {
+	var resp rerankResponse
+	req := rerankRequest{Model: c.model, Query: query, Documents: documents}
+	if err := c.post(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Results) != len(documents) {
+		return nil, fmt.Errorf("expected %d rerank scores, got %d", len(documents), len(resp.Results))
+	}
+	scores := make([]float64, len(documents))
+	seen := make([]bool, len(documents))
+	for _, result := range resp.Results {
+		if result.Index < 0 || result.Index >= len(documents) {
+			return nil, fmt.Errorf("invalid rerank index: %d", result.Index)
+		}
+		if seen[result.Index] {
+			return nil, fmt.Errorf("duplicate rerank index: %d", result.Index)
+		}
+		seen[result.Index] = true
+		scores[result.Index] = result.RelevanceScore
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("missing rerank score for document %d", i)
+		}
+	}
+	return scores, nil
+}
+
+// chatMessage is a message of a chat completion
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatRequest is the body of a /chat/completions request
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+// chatResponse is the body of a /chat/completions response
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// chatPrompt asks a chat model for one score per document
+const chatPrompt = `You rank Go code search results. Rate how relevant each numbered candidate is to the query, from 0 (unrelated) to 1 (exactly what was asked for).
+Reply with JSON only, in candidate order: {"scores": [<score of 0>, <score of 1>, ...]}`
+
+// rerankChat scores documents by prompting a chat model
+func (c *Client) rerankChat(ctx context.Context, query string, documents []string) ([]float64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Query: %s\n", query)
+	for i, doc := range documents {
+		fmt.Fprintf(&b, "\n[%d]\n%s\n", i, doc)
+	}
+
+	var resp chatResponse
+	req := chatRequest{
+		Model: c.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: chatPrompt},
+			{Role: "user", Content: b.String()},
+		},
+	}
+	if err := c.post(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no chat completion returned")
+	}
+
+	var reply struct {
+		Scores []float64 `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFence(resp.Choices[0].Message.Content)), &reply); err != nil {
+		return nil, fmt.Errorf("failed to parse rerank scores: %w", err)
+	}
+	if len(reply.Scores) != len(documents) {
+		return nil, fmt.Errorf("expected %d rerank scores, got %d", len(documents), len(reply.Scores))
+	}
+	return reply.Scores, nil
+}
+
+// stripCodeFence removes a Markdown code fence around a model reply
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	if i := strings.Index(content, "\n"); i >= 0 {
+		content = content[i+1:] // Language tag
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "```"))
+}
+
+// post sends a JSON request and decodes the JSON response into out
+func (c *Client) post(ctx context.Context, in, out interface{}) error {
+	reqBody, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	for name, value := range c.headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rerank request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/config"
+)
+
+func TestClient_RerankAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rerank" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req rerankRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Model != "bge-reranker" || req.Query != "open db" || len(req.Documents) != 2 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		// Results come back sorted by relevance, not by index
+		w.Write([]byte(`{"results":[{"index":1,"relevance_score":0.9},{"index":0,"relevance_score":0.2}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&config.RerankConfig{
+		Provider: "rerank",
+		BaseURL:  server.URL + "/v1/",
+		APIKey:   "secret",
+		Model:    "bge-reranker",
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	scores, err := client.Rerank(context.Background(), "open db", []string{"func Close()", "func Open()"})
+	if err != nil {
+		t.Fatalf("Rerank: %v", err)
+	}
+	if len(scores) != 2 || scores[0] != 0.2 || scores[1] != 0.9 {
+		t.Errorf("scores = %v, want [0.2 0.9]", scores)
+	}
+}
+
+func TestClient_RerankChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.Messages) != 2 || !strings.Contains(req.Messages[1].Content, "[1]\nfunc Open()") {
+			t.Errorf("unexpected messages: %+v", req.Messages)
+		}
+		reply := "```json\n{\"scores\": [0.1, 0.8]}\n```"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{
+				map[string]interface{}{"message": map[string]string{"role": "assistant", "content": reply}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&config.RerankConfig{Provider: "chat", BaseURL: server.URL + "/v1", Model: "qwen"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	scores, err := client.Rerank(context.Background(), "open db", []string{"func Close()", "func Open()"})
+	if err != nil {
+		t.Fatalf("Rerank: %v", err)
+	}
+	if len(scores) != 2 || scores[0] != 0.1 || scores[1] != 0.8 {
+		t.Errorf("scores = %v, want [0.1 0.8]", scores)
+	}
+}
+
+func TestClient_RerankInvalidResults(t *testing.T) {
+	responses := map[string]string{
+		"missing score":   `{"results":[{"index":0,"relevance_score":0.5}]}`,
+		"duplicate index": `{"results":[{"index":0,"relevance_score":0.5},{"index":0,"relevance_score":0.4}]}`,
+		"invalid index":   `{"results":[{"index":0,"relevance_score":0.5},{"index":2,"relevance_score":0.4}]}`,
+	}
+	for name, response := range responses {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(response))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(&config.RerankConfig{Provider: "rerank", BaseURL: server.URL, Model: "m"})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			if _, err := client.Rerank(context.Background(), "q", []string{"a", "b"}); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
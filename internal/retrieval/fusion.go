@@ -0,0 +1,52 @@
+package retrieval
+
+import "sort"
+
+// Score fusion methods
+const (
+	FusionLinear = "linear" // weighted sum of vector and keyword scores
+	FusionRRF    = "rrf"    // Reciprocal Rank Fusion
+)
+
+// DefaultRRFK is the rank constant of Reciprocal Rank Fusion; larger values
+// flatten the advantage of top ranks
+const DefaultRRFK = 60
+
+// rankPositions returns the 1-based rank of each symbol by score, best first
+func rankPositions(scores map[string]*scoredSymbol) map[string]int {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := scores[ids[i]].score, scores[ids[j]].score
+		if a != b {
+			return a > b
+		}
+		return ids[i] < ids[j]
+	})
+
+	ranks := make(map[string]int, len(ids))
+	for i, id := range ids {
+		ranks[id] = i + 1
+	}
+	return ranks
+}
+
+// rrfScore fuses the ranks of a symbol in the vector and keyword lists (0
+// when absent) as sum(weight / (k + rank)), scaled so that ranking first in
+// both lists scores 1
+func rrfScore(vectorRank, keywordRank int, vectorWeight, keywordWeight float32, k int) float32 {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	var score float64
+	if vectorRank > 0 {
+		score += float64(vectorWeight) / float64(k+vectorRank)
+	}
+	if keywordRank > 0 {
+		score += float64(keywordWeight) / float64(k+keywordRank)
+	}
+	return float32(score * float64(k+1) / float64(vectorWeight+keywordWeight))
+}
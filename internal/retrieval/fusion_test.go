@@ -0,0 +1,48 @@
+package retrieval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRankPositions(t *testing.T) {
+	ranks := rankPositions(map[string]*scoredSymbol{
+		"b": {score: 0.5},
+		"a": {score: 0.9},
+		"c": {score: 0.5},
+	})
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	for id, rank := range want {
+		if ranks[id] != rank {
+			t.Errorf("rank of %s = %d, want %d", id, ranks[id], rank)
+		}
+	}
+}
+
+func TestRRFScore(t *testing.T) {
+	tests := []struct {
+		name                        string
+		vectorRank, keywordRank     int
+		vectorWeight, keywordWeight float32
+		want                        float64
+	}{
+		{"first in both", 1, 1, 0.5, 0.5, 1},
+		{"first in one list", 1, 0, 0.5, 0.5, 0.5},
+		{"second in both", 2, 2, 0.5, 0.5, 61.0 / 62.0},
+		{"weighted keyword", 0, 1, 0.25, 0.75, 0.75},
+		{"absent", 0, 0, 0.5, 0.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rrfScore(tt.vectorRank, tt.keywordRank, tt.vectorWeight, tt.keywordWeight, DefaultRRFK)
+			if math.Abs(float64(got)-tt.want) > 1e-6 {
+				t.Errorf("rrfScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A vector hit ranked first beats a keyword hit ranked tenth
+	if rrfScore(1, 0, 0.5, 0.5, DefaultRRFK) <= rrfScore(0, 10, 0.5, 0.5, DefaultRRFK) {
+		t.Error("rank 1 should outscore rank 10 at equal weights")
+	}
+}
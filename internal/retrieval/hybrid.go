@@ -10,6 +10,10 @@ import (
 	"github.com/DreamCats/bcindex/internal/store"
 )
 
+// chunkCandidateFactor widens the chunk search since several chunks of one
+// function may rank ahead of other functions
+const chunkCandidateFactor = 3
+
 // HybridRetriever provides hybrid search combining vector and keyword search
 type HybridRetriever struct {
 	vectorStore     *store.VectorStore
@@ -20,6 +24,10 @@ type HybridRetriever struct {
 	expander        *SynonymsExpander
 	graphRanker     *GraphRanker
 	evidenceBuilder *EvidenceBuilder
+
+	reranker      Reranker
+	rerankCache   RerankCache
+	rerankOptions RerankOptions
 }
 
 // NewHybridRetriever creates a new hybrid retriever
@@ -54,11 +62,26 @@ type SearchOptions struct {
 	GraphWeight     float32  // Weight for graph-based ranking (0-1)
 	ExportedOnly    bool     // Only return exported symbols
 	Kinds           []string // Filter by symbol kinds
-	PackagePath     string   // Filter by package path
+	PackagePath     string   // Filter by package path prefix (full or repo-relative)
+	RepoPath        string   // Restrict results to this repository
 	IncludePackages bool     // Also return package-level results
 	EnableGraphRank bool     // Enable graph-based ranking
 	LayerFilter     []string // Filter by architectural layer (handler, service, repository, domain, middleware, util)
 	Intent          string   // Query intent: design, implementation, extension
+	BuildContexts   []string // Filter by build context names (any of)
+
+	// Qualifiers of a structured query (see ParseQuery)
+	ExcludeKinds        []string // Skip symbols of these kinds
+	ExcludePackages     []string // Skip these packages and their sub-packages
+	FilePatterns        []string // Keep symbols of files matching any pattern
+	ExcludeFilePatterns []string // Skip symbols of files matching any pattern
+	Receiver            string   // Keep methods of this receiver type ("*T" for pointer receivers)
+	Calls               []string // Keep callers of these symbols
+	Implements          []string // Keep implementations of these interfaces
+
+	Fusion         string            // Score fusion: FusionLinear (default) or FusionRRF
+	RRFK           int               // Rank constant for FusionRRF (default DefaultRRFK)
+	KeywordColumns store.BM25Weights // bm25() weights of the full-text columns
 }
 
 // DefaultSearchOptions returns default search options
@@ -71,10 +94,14 @@ func DefaultSearchOptions() SearchOptions {
 		ExportedOnly:    true,
 		Kinds:           nil,
 		PackagePath:     "",
+		RepoPath:        "",
 		IncludePackages: false,
 		EnableGraphRank: true,
 		LayerFilter:     nil,
 		Intent:          "",
+		Fusion:          FusionLinear,
+		RRFK:            DefaultRRFK,
+		KeywordColumns:  store.DefaultBM25Weights(),
 	}
 }
 
@@ -88,6 +115,14 @@ type SearchResult struct {
 	CombinedScore float32        // Final combined score
 	Reason        []string       // Explanation of why this result was returned
 	GraphFeatures *GraphFeatures // Graph-based features (if graph ranking enabled)
+	MatchedLines  *LineRange     // Body chunk that matched, if a chunk scored best
+	RerankScore   float64        // Relevance score of the rerank stage (if reranked)
+}
+
+// LineRange is an inclusive range of source lines
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 // Search performs hybrid search
@@ -106,6 +141,25 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 	opts.VectorWeight /= totalWeight
 	opts.KeywordWeight /= totalWeight
 
+	// Filters are pushed into candidate selection so narrow filters
+	// still produce a full candidate list
+	filters := buildSearchFilters(opts)
+
+	// Graph qualifiers restrict candidates to the symbols they resolve to
+	ids, constrained, err := h.resolveGraphConstraints(opts)
+	if err != nil {
+		return nil, err
+	}
+	if constrained && len(ids) == 0 {
+		return nil, nil
+	}
+	filters.SymbolIDs = ids
+
+	// A query of qualifiers only lists the matching symbols
+	if strings.TrimSpace(query) == "" {
+		return h.listFiltered(ctx, filters, opts)
+	}
+
 	queryForEmbed := query
 	queryForFTS := query
 	if h.expander != nil {
@@ -121,7 +175,6 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 	// Step 1: Generate query embedding
 	var queryVector []float32
 	if opts.VectorWeight > 0 {
-		var err error
 		queryVector, err = h.embedService.Embed(ctx, queryForEmbed)
 		if err != nil {
 			return nil, fmt.Errorf("failed to embed query: %w", err)
@@ -131,7 +184,7 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 	// Step 2: Vector search
 	vectorResults := make(map[string]*scoredSymbol)
 	if opts.VectorWeight > 0 && queryVector != nil {
-		vResults, err := h.vectorStore.Search(queryVector, opts.TopK*2, h.symbolStore)
+		vResults, err := h.vectorStore.SearchByFilters(queryVector, opts.TopK*2, filters, h.symbolStore)
 		if err != nil {
 			return nil, fmt.Errorf("vector search failed: %w", err)
 		}
@@ -142,22 +195,25 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 				score:  r.Score,
 			}
 		}
+
+		if err := h.mergeChunkResults(vectorResults, queryVector, opts.TopK*2, filters); err != nil {
+			return nil, err
+		}
 	}
 
 	// Step 3: Keyword search using FTS
 	keywordResults := make(map[string]*scoredSymbol)
 	if opts.KeywordWeight > 0 {
-		kResults, err := h.symbolStore.SearchFTS(queryForFTS, opts.TopK*2)
+		kResults, err := h.symbolStore.SearchFTSScored(queryForFTS, opts.TopK*2, filters, opts.KeywordColumns)
 		if err != nil {
 			return nil, fmt.Errorf("keyword search failed: %w", err)
 		}
 
-		for i, sym := range kResults {
-			// Simple scoring based on rank
-			score := float32(1.0 - float64(i)/float64(len(kResults)))
-			keywordResults[sym.ID] = &scoredSymbol{
-				symbol: sym,
-				score:  score,
+		// bm25() relevance mapped to [0, 1)
+		for _, r := range kResults {
+			keywordResults[r.SymbolID] = &scoredSymbol{
+				symbol: r.Symbol,
+				score:  r.Score,
 			}
 		}
 	}
@@ -174,6 +230,7 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 			symbol:       result.symbol,
 			vectorScore:  result.score,
 			keywordScore: 0,
+			matchedLines: result.matchedLines,
 		}
 	}
 
@@ -194,47 +251,25 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 	}
 
 	// Step 5: Apply filters and compute final scores
+	var vectorRanks, keywordRanks map[string]int
+	if opts.Fusion == FusionRRF {
+		vectorRanks = rankPositions(vectorResults)
+		keywordRanks = rankPositions(keywordResults)
+	}
+
 	var results []SearchResult
 	for _, combined := range combinedScores {
-		// Apply filters
-		if opts.ExportedOnly && !combined.symbol.Exported {
-			continue
-		}
-
-		if len(opts.Kinds) > 0 {
-			kindMatch := false
-			for _, kind := range opts.Kinds {
-				if combined.symbol.Kind == kind {
-					kindMatch = true
-					break
-				}
-			}
-			if !kindMatch {
-				continue
-			}
-		}
-
-		if opts.PackagePath != "" && combined.symbol.PackagePath != opts.PackagePath {
+		// Apply filters (candidates are pre-filtered; this keeps exact semantics)
+		if !filters.Matches(combined.symbol) {
 			continue
 		}
 
-		// Apply layer filter if specified
-		if len(opts.LayerFilter) > 0 {
-			layer := detectLayerFromPath(combined.symbol.PackagePath)
-			layerMatch := false
-			for _, l := range opts.LayerFilter {
-				if layer == l {
-					layerMatch = true
-					break
-				}
-			}
-			if !layerMatch {
-				continue
-			}
-		}
-
 		// Compute combined score
 		finalScore := opts.VectorWeight*combined.vectorScore + opts.KeywordWeight*combined.keywordScore
+		if opts.Fusion == FusionRRF {
+			id := combined.symbol.ID
+			finalScore = rrfScore(vectorRanks[id], keywordRanks[id], opts.VectorWeight, opts.KeywordWeight, opts.RRFK)
+		}
 
 		result := SearchResult{
 			Symbol:        combined.symbol,
@@ -242,11 +277,17 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 			KeywordScore:  combined.keywordScore,
 			CombinedScore: finalScore,
 			Reason:        h.generateReasons(combined),
+			MatchedLines:  combined.matchedLines,
 		}
 
 		results = append(results, result)
 	}
 
+	return h.rankResults(ctx, query, results, opts), nil
+}
+
+// rankResults orders scored candidates and keeps the top K
+func (h *HybridRetriever) rankResults(ctx context.Context, query string, results []SearchResult, opts SearchOptions) []SearchResult {
 	// Step 6: Apply graph-based ranking if enabled
 	if opts.EnableGraphRank && len(results) > 0 {
 		results = h.applyGraphRanking(results, query, opts)
@@ -257,12 +298,15 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 		return results[i].CombinedScore > results[j].CombinedScore
 	})
 
+	// Step 8: Optionally rerank the leading candidates
+	results = h.rerank(ctx, query, results)
+
 	// Keep top K
 	if len(results) > opts.TopK {
 		results = results[:opts.TopK]
 	}
 
-	// Step 8: Optionally load package information
+	// Step 9: Optionally load package information
 	if opts.IncludePackages {
 		for i := range results {
 			if pkg, err := h.packageStore.Get(results[i].Symbol.PackagePath); err == nil {
@@ -271,13 +315,47 @@ func (h *HybridRetriever) Search(ctx context.Context, query string, opts SearchO
 		}
 	}
 
-	return results, nil
+	return results
+}
+
+// listFiltered returns the symbols matching the filters of a query that
+// has qualifiers but no free text, ranked by the graph
+func (h *HybridRetriever) listFiltered(ctx context.Context, filters store.SearchFilters, opts SearchOptions) ([]SearchResult, error) {
+	if !hasSelectors(opts) {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	symbols, err := h.symbolStore.ListByFilters(filters, opts.TopK*4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list symbols: %w", err)
+	}
+
+	var results []SearchResult
+	for _, sym := range symbols {
+		results = append(results, SearchResult{
+			Symbol:        sym,
+			CombinedScore: 1,
+			Reason:        []string{"Matches query qualifiers"},
+		})
+	}
+
+	return h.rankResults(ctx, "", results, opts), nil
+}
+
+// hasSelectors reports whether opts select symbols beyond the repository
+// and visibility defaults
+func hasSelectors(opts SearchOptions) bool {
+	return len(opts.Kinds) > 0 || opts.PackagePath != "" || len(opts.LayerFilter) > 0 ||
+		len(opts.BuildContexts) > 0 || len(opts.ExcludeKinds) > 0 || len(opts.ExcludePackages) > 0 ||
+		len(opts.FilePatterns) > 0 || len(opts.ExcludeFilePatterns) > 0 || opts.Receiver != "" ||
+		len(opts.Calls) > 0 || len(opts.Implements) > 0
 }
 
 // scoredSymbol holds a symbol with its score
 type scoredSymbol struct {
-	symbol *store.Symbol
-	score  float32
+	symbol       *store.Symbol
+	score        float32
+	matchedLines *LineRange // set when a body chunk gave the score
 }
 
 // combinedResult holds combined search information
@@ -285,6 +363,47 @@ type combinedResult struct {
 	symbol       *store.Symbol
 	vectorScore  float32
 	keywordScore float32
+	matchedLines *LineRange
+}
+
+// mergeChunkResults collapses body chunk hits onto their owning symbols. A
+// symbol keeps the best of its own and its chunks' similarity, and records
+// the line range of the chunk when that wins.
+func (h *HybridRetriever) mergeChunkResults(vectorResults map[string]*scoredSymbol, queryVector []float32, topK int, filters store.SearchFilters) error {
+	chunks, err := h.vectorStore.SearchChunks(queryVector, topK*chunkCandidateFactor, filters)
+	if err != nil {
+		return fmt.Errorf("chunk search failed: %w", err)
+	}
+
+	// Results are sorted, so the first chunk of each symbol is its best
+	for _, chunk := range chunks {
+		existing, ok := vectorResults[chunk.SymbolID]
+		if ok && (existing.matchedLines != nil || existing.score >= chunk.Score) {
+			continue
+		}
+
+		lines := &LineRange{Start: chunk.LineStart, End: chunk.LineEnd}
+		if ok {
+			existing.score = chunk.Score
+			existing.matchedLines = lines
+			continue
+		}
+
+		sym, err := h.symbolStore.Get(chunk.SymbolID)
+		if err != nil {
+			return fmt.Errorf("failed to load symbol %s: %w", chunk.SymbolID, err)
+		}
+		if sym == nil {
+			continue
+		}
+		vectorResults[chunk.SymbolID] = &scoredSymbol{
+			symbol:       sym,
+			score:        chunk.Score,
+			matchedLines: lines,
+		}
+	}
+
+	return nil
 }
 
 // generateReasons generates explanation for why a result was returned
@@ -303,6 +422,10 @@ func (h *HybridRetriever) generateReasons(combined *combinedResult) []string {
 		reasons = append(reasons, "Partial keyword match")
 	}
 
+	if combined.matchedLines != nil {
+		reasons = append(reasons, fmt.Sprintf("Body match at lines %d-%d", combined.matchedLines.Start, combined.matchedLines.End))
+	}
+
 	if combined.symbol.Exported {
 		reasons = append(reasons, "Exported API")
 	}
@@ -372,6 +495,7 @@ func (h *HybridRetriever) applyGraphRanking(results []SearchResult, query string
 				CombinedScore: combinedScore,
 				GraphFeatures: ranked.Features,
 				Reason:        h.mergeReasons(original.Reason, ranked.Reason),
+				MatchedLines:  original.MatchedLines,
 			}
 
 			newResults = append(newResults, result)
@@ -427,38 +551,41 @@ func (h *HybridRetriever) GetEvidenceBuilder() *EvidenceBuilder {
 	return h.evidenceBuilder
 }
 
-// detectLayerFromPath detects the architectural layer from package path
-func detectLayerFromPath(pkgPath string) string {
-	path := strings.ToLower(pkgPath)
-
-	if strings.Contains(path, "/handler/") || strings.Contains(path, "/controller/") ||
-		strings.Contains(path, "/api/") || strings.Contains(path, "/http/") {
-		return "handler"
-	}
-
-	if strings.Contains(path, "/service/") || strings.Contains(path, "/usecase/") ||
-		strings.Contains(path, "/business/") {
-		return "service"
+// buildSearchFilters converts search options into store-level filters
+func buildSearchFilters(opts SearchOptions) store.SearchFilters {
+	filters := store.SearchFilters{
+		RepoPath:      opts.RepoPath,
+		Kinds:         opts.Kinds,
+		ExportedOnly:  opts.ExportedOnly,
+		PackagePath:   opts.PackagePath,
+		ExcludeTests:  true,
+		BuildContexts: opts.BuildContexts,
+
+		ExcludeKinds:        opts.ExcludeKinds,
+		ExcludePackages:     opts.ExcludePackages,
+		FilePatterns:        opts.FilePatterns,
+		ExcludeFilePatterns: opts.ExcludeFilePatterns,
+		Receiver:            opts.Receiver,
+	}
+
+	// Test symbols are only returned when asked for by kind or file
+	for _, kind := range opts.Kinds {
+		if kind == store.KindTest {
+			filters.ExcludeTests = false
+		}
 	}
-
-	if strings.Contains(path, "/repository/") || strings.Contains(path, "/repo/") ||
-		strings.Contains(path, "/dao/") || strings.Contains(path, "/storage/") {
-		return "repository"
+	if len(opts.FilePatterns) > 0 {
+		filters.ExcludeTests = false
 	}
 
-	if strings.Contains(path, "/domain/") || strings.Contains(path, "/entity/") ||
-		strings.Contains(path, "/model/") {
-		return "domain"
-	}
-
-	if strings.Contains(path, "/middleware/") || strings.Contains(path, "/filter/") {
-		return "middleware"
-	}
+	// Layers are classed from package paths in SQL, exactly like
+	// detectLayerFromPath, so candidates are not cut before the layer check
+	filters.Layers = opts.LayerFilter
 
-	if strings.Contains(path, "/util/") || strings.Contains(path, "/helper/") ||
-		strings.Contains(path, "/common/") {
-		return "util"
-	}
+	return filters
+}
 
-	return "unknown"
+// detectLayerFromPath detects the architectural layer from package path
+func detectLayerFromPath(pkgPath string) string {
+	return store.PathLayer(pkgPath)
 }
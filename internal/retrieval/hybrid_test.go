@@ -0,0 +1,145 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+func TestBuildSearchFilters(t *testing.T) {
+	opts := DefaultSearchOptions()
+	opts.RepoPath = "/repo"
+	opts.PackagePath = "internal/store"
+	opts.Kinds = []string{"func"}
+	opts.LayerFilter = []string{"service", "util"}
+	opts.BuildContexts = []string{"linux"}
+
+	filters := buildSearchFilters(opts)
+
+	if filters.RepoPath != "/repo" || filters.PackagePath != "internal/store" || !filters.ExportedOnly {
+		t.Errorf("unexpected filters: %+v", filters)
+	}
+	if !reflect.DeepEqual(filters.Kinds, []string{"func"}) {
+		t.Errorf("Kinds = %v, want [func]", filters.Kinds)
+	}
+	if !reflect.DeepEqual(filters.BuildContexts, []string{"linux"}) {
+		t.Errorf("BuildContexts = %v, want [linux]", filters.BuildContexts)
+	}
+	if !filters.ExcludeTests {
+		t.Error("ExcludeTests = false, want true without the test kind")
+	}
+
+	if !reflect.DeepEqual(filters.Layers, []string{"service", "util"}) {
+		t.Errorf("Layers = %v, want [service util]", filters.Layers)
+	}
+
+	opts.Kinds = []string{"func", "test"}
+	if filters := buildSearchFilters(opts); filters.ExcludeTests {
+		t.Error("ExcludeTests = true, want false with the test kind")
+	}
+}
+
+func TestHybridRetriever_MergeChunkResults(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := store.NewSymbolStore(db)
+	vectorStore := store.NewVectorStore(db)
+	if err := symbolStore.CreateBatch([]*store.Symbol{
+		{ID: "p:func:Short", RepoPath: "/repo", Kind: "func", PackagePath: "p", Name: "Short"},
+		{ID: "p:func:Long", RepoPath: "/repo", Kind: "func", PackagePath: "p", Name: "Long"},
+		{ID: "p:func:Other", RepoPath: "/repo", Kind: "func", PackagePath: "p", Name: "Other"},
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+	if err := vectorStore.InsertChunks([]*store.Chunk{
+		{ID: "p:func:Long#L10-40", SymbolID: "p:func:Long", LineStart: 10, LineEnd: 40, Vector: []float32{0.6, 0.8}},
+		{ID: "p:func:Long#L30-60", SymbolID: "p:func:Long", LineStart: 30, LineEnd: 60, Vector: []float32{1, 0}},
+		{ID: "p:func:Other#L5-20", SymbolID: "p:func:Other", LineStart: 5, LineEnd: 20, Vector: []float32{0, 1}},
+	}, "test"); err != nil {
+		t.Fatalf("failed to insert chunks: %v", err)
+	}
+
+	h := NewHybridRetriever(vectorStore, symbolStore, store.NewPackageStore(db), store.NewEdgeStore(db), nil, nil)
+
+	short, _ := symbolStore.Get("p:func:Short")
+	other, _ := symbolStore.Get("p:func:Other")
+	results := map[string]*scoredSymbol{
+		"p:func:Short": {symbol: short, score: 0.5},
+		"p:func:Other": {symbol: other, score: 0.9}, // beats its chunk
+	}
+	if err := h.mergeChunkResults(results, []float32{1, 0}, 10, store.SearchFilters{}); err != nil {
+		t.Fatalf("mergeChunkResults() error = %v", err)
+	}
+
+	long := results["p:func:Long"]
+	if long == nil || long.symbol == nil || long.score < 0.99 {
+		t.Fatalf("Long = %+v, want a hit from its best chunk", long)
+	}
+	if !reflect.DeepEqual(long.matchedLines, &LineRange{Start: 30, End: 60}) {
+		t.Errorf("Long matched lines = %+v, want 30-60", long.matchedLines)
+	}
+	if results["p:func:Other"].score != 0.9 || results["p:func:Other"].matchedLines != nil {
+		t.Errorf("Other = %+v, want its own score kept", results["p:func:Other"])
+	}
+	if len(results) != 3 {
+		t.Errorf("got %d results, want one per symbol", len(results))
+	}
+}
+
+func TestHybridRetriever_KeywordScoresUseBM25(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Unrelated symbols in another package give "retry" a meaningful
+	// inverse document frequency; the search is restricted to package p
+	symbols := []*store.Symbol{
+		{ID: "p:func:Send", RepoPath: "/repo", Kind: "func", PackagePath: "p", Name: "Send", Exported: true, SemanticText: "sends a request and will retry it with backoff"},
+		{ID: "p:func:Retry", RepoPath: "/repo", Kind: "func", PackagePath: "p", Name: "Retry", Exported: true, SemanticText: "calls a function again"},
+	}
+	for i := 0; i < 50; i++ {
+		symbols = append(symbols, &store.Symbol{ID: fmt.Sprintf("q:func:Other%d", i), RepoPath: "/repo", Kind: "func", PackagePath: "q", Name: fmt.Sprintf("Other%d", i), Exported: true, SemanticText: "does unrelated work"})
+	}
+	symbolStore := store.NewSymbolStore(db)
+	if err := symbolStore.CreateBatch(symbols); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	h := NewHybridRetriever(store.NewVectorStore(db), symbolStore, store.NewPackageStore(db), store.NewEdgeStore(db), nil, nil)
+	opts := DefaultSearchOptions()
+	opts.VectorWeight = 0
+	opts.KeywordWeight = 1
+	opts.EnableGraphRank = false
+	opts.PackagePath = "p"
+
+	results, err := h.Search(context.Background(), "retry", opts)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Symbol.ID != "p:func:Retry" {
+		t.Fatalf("Search() = %+v, want the name match first", results)
+	}
+	if results[0].KeywordScore <= 0.5 || results[1].KeywordScore >= 0.5 {
+		t.Errorf("keyword scores = %v, %v, want a strong name match and a weak description match",
+			results[0].KeywordScore, results[1].KeywordScore)
+	}
+
+	opts.Fusion = FusionRRF
+	results, err = h.Search(context.Background(), "retry", opts)
+	if err != nil {
+		t.Fatalf("Search(rrf) error = %v", err)
+	}
+	if len(results) != 2 || results[0].Symbol.ID != "p:func:Retry" || results[0].CombinedScore < 0.999 {
+		t.Errorf("Search(rrf) = %+v, want Retry first with score 1", results)
+	}
+}
@@ -0,0 +1,299 @@
+package retrieval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// Query qualifiers
+const (
+	QualifierKind       = "kind"       // kind:method, kind:func,method
+	QualifierReceiver   = "recv"       // recv:*VectorStore (pointer), recv:VectorStore (any)
+	QualifierPackage    = "pkg"        // pkg:internal/store/...
+	QualifierCalls      = "calls"      // calls:EmbedBatch, calls:Client.EmbedBatch
+	QualifierImplements = "implements" // implements:embedding.Client
+	QualifierFile       = "file"       // file:*_test.go, file:internal/store/*.go
+)
+
+// maxGraphTargets bounds the symbols a graph qualifier may resolve to
+const maxGraphTargets = 100
+
+// ParsedQuery is a search query split into free text and qualifiers
+type ParsedQuery struct {
+	Text string // Free text for vector and keyword search
+
+	Kinds           []string
+	ExcludeKinds    []string
+	Receiver        string
+	PackagePath     string
+	ExcludePackages []string
+	Files           []string
+	ExcludeFiles    []string
+	Calls           []string // Keep callers of these symbols
+	Implements      []string // Keep implementations of these interfaces
+}
+
+// ParseQuery pulls qualifiers such as "kind:method recv:*VectorStore
+// -pkg:cmd/..." out of a query. A leading "-" negates kind:, pkg: and
+// file:. Values may be quoted; kind: accepts a comma-separated list. Words
+// with any other prefix stay in the free text.
+func ParseQuery(query string) (*ParsedQuery, error) {
+	q := &ParsedQuery{}
+	var text []string
+
+	for _, term := range splitQueryTerms(query) {
+		negated := strings.HasPrefix(term, "-")
+		key, value, ok := strings.Cut(strings.TrimPrefix(term, "-"), ":")
+		if !ok || !isQualifier(key) {
+			text = append(text, term)
+			continue
+		}
+
+		value = strings.Trim(value, `"`)
+		if value == "" {
+			return nil, fmt.Errorf("missing value for %s:", key)
+		}
+		if negated && key != QualifierKind && key != QualifierPackage && key != QualifierFile {
+			return nil, fmt.Errorf("%s: cannot be negated", key)
+		}
+
+		switch key {
+		case QualifierKind:
+			for _, kind := range strings.Split(value, ",") {
+				kind = strings.TrimSpace(kind)
+				if !isValidKind(kind) {
+					return nil, fmt.Errorf("invalid kind: %s", kind)
+				}
+				if negated {
+					q.ExcludeKinds = append(q.ExcludeKinds, kind)
+				} else {
+					q.Kinds = append(q.Kinds, kind)
+				}
+			}
+		case QualifierReceiver:
+			if q.Receiver != "" {
+				return nil, fmt.Errorf("recv: may only be given once")
+			}
+			q.Receiver = value
+		case QualifierPackage:
+			if negated {
+				q.ExcludePackages = append(q.ExcludePackages, value)
+			} else if q.PackagePath != "" {
+				return nil, fmt.Errorf("pkg: may only be given once; use -pkg: to exclude packages")
+			} else {
+				q.PackagePath = value
+			}
+		case QualifierFile:
+			if negated {
+				q.ExcludeFiles = append(q.ExcludeFiles, value)
+			} else {
+				q.Files = append(q.Files, value)
+			}
+		case QualifierCalls:
+			q.Calls = append(q.Calls, value)
+		case QualifierImplements:
+			q.Implements = append(q.Implements, value)
+		}
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// HasQualifiers reports whether the query has any qualifier
+func (q *ParsedQuery) HasQualifiers() bool {
+	return len(q.Kinds) > 0 || len(q.ExcludeKinds) > 0 || q.Receiver != "" ||
+		q.PackagePath != "" || len(q.ExcludePackages) > 0 || len(q.Files) > 0 ||
+		len(q.ExcludeFiles) > 0 || len(q.Calls) > 0 || len(q.Implements) > 0
+}
+
+// Apply copies the qualifiers into search options; they take precedence
+// over kinds and package path already set
+func (q *ParsedQuery) Apply(opts *SearchOptions) {
+	if len(q.Kinds) > 0 {
+		opts.Kinds = q.Kinds
+	}
+	if q.PackagePath != "" {
+		opts.PackagePath = q.PackagePath
+	}
+	if q.Receiver != "" {
+		opts.Receiver = q.Receiver
+		if len(opts.Kinds) == 0 {
+			opts.Kinds = []string{store.KindMethod}
+		}
+	}
+	opts.ExcludeKinds = append(opts.ExcludeKinds, q.ExcludeKinds...)
+	opts.ExcludePackages = append(opts.ExcludePackages, q.ExcludePackages...)
+	opts.FilePatterns = append(opts.FilePatterns, q.Files...)
+	opts.ExcludeFilePatterns = append(opts.ExcludeFilePatterns, q.ExcludeFiles...)
+	opts.Calls = append(opts.Calls, q.Calls...)
+	opts.Implements = append(opts.Implements, q.Implements...)
+}
+
+// splitQueryTerms splits a query at spaces outside double quotes
+func splitQueryTerms(query string) []string {
+	var terms []string
+	var b strings.Builder
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			b.WriteRune(r)
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n'):
+			if b.Len() > 0 {
+				terms = append(terms, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		terms = append(terms, b.String())
+	}
+	return terms
+}
+
+func isQualifier(key string) bool {
+	switch key {
+	case QualifierKind, QualifierReceiver, QualifierPackage, QualifierCalls, QualifierImplements, QualifierFile:
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidKind reports whether kind is a known symbol kind
+func isValidKind(kind string) bool {
+	switch kind {
+	case store.KindPackage, store.KindFile, store.KindInterface, store.KindStruct, store.KindType,
+		store.KindFunc, store.KindMethod, store.KindConst, store.KindVar, store.KindField, store.KindTest:
+		return true
+	default:
+		return false
+	}
+}
+
+// symbolRef is a symbol named by a graph qualifier: Name, Type.Method,
+// pkg.Name or pkg.Type.Method, where pkg is a package name or path
+type symbolRef struct {
+	qualifier string // Package name or receiver type, when ambiguous
+	pkg       string
+	receiver  string
+	name      string
+}
+
+// parseSymbolRef splits a graph qualifier value into its parts. Dots of the
+// package path before its last slash are kept ("github.com/org/repo/pkg.T").
+func parseSymbolRef(ref string) symbolRef {
+	slash := strings.LastIndex(ref, "/")
+	head, tail := ref[:slash+1], ref[slash+1:]
+	parts := strings.Split(tail, ".")
+	name := parts[len(parts)-1]
+
+	switch {
+	case len(parts) == 1:
+		return symbolRef{pkg: strings.TrimSuffix(head, "/"), name: name}
+	case len(parts) == 2 && head == "":
+		return symbolRef{qualifier: parts[0], name: name}
+	case len(parts) == 2:
+		return symbolRef{pkg: head + parts[0], name: name}
+	default:
+		return symbolRef{pkg: head + parts[0], receiver: parts[len(parts)-2], name: name}
+	}
+}
+
+// matches reports whether sym is the referenced symbol
+func (r symbolRef) matches(sym *store.Symbol) bool {
+	if sym.Name != r.name {
+		return false
+	}
+	receiver, _ := store.ReceiverOf(sym.Signature)
+	inPackage := func(pkg string) bool {
+		return sym.PackageName == pkg || store.PackagePathMatches(sym.PackagePath, pkg)
+	}
+
+	if r.qualifier != "" && receiver != r.qualifier && !inPackage(r.qualifier) {
+		return false
+	}
+	if r.pkg != "" && !inPackage(r.pkg) {
+		return false
+	}
+	if r.receiver != "" && receiver != r.receiver {
+		return false
+	}
+	return true
+}
+
+// resolveGraphConstraints returns the symbols allowed by the calls: and
+// implements: qualifiers of opts, resolved through the edge store. The
+// second result is false when there is no such qualifier. Symbols must
+// satisfy every qualifier; a qualifier naming several symbols accepts a
+// match on any of them.
+func (h *HybridRetriever) resolveGraphConstraints(opts SearchOptions) ([]string, bool, error) {
+	if len(opts.Calls) == 0 && len(opts.Implements) == 0 {
+		return nil, false, nil
+	}
+
+	var allowed map[string]bool
+	constrain := func(refs []string, edgeType string) error {
+		for _, ref := range refs {
+			ids, err := h.incomingSources(ref, edgeType, opts.RepoPath)
+			if err != nil {
+				return err
+			}
+			if allowed == nil {
+				allowed = ids
+				continue
+			}
+			for id := range allowed {
+				if !ids[id] {
+					delete(allowed, id)
+				}
+			}
+		}
+		return nil
+	}
+	if err := constrain(opts.Calls, store.EdgeTypeCalls); err != nil {
+		return nil, true, err
+	}
+	if err := constrain(opts.Implements, store.EdgeTypeImplements); err != nil {
+		return nil, true, err
+	}
+
+	ids := make([]string, 0, len(allowed))
+	for id := range allowed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, true, nil
+}
+
+// incomingSources returns the symbols with an edge of edgeType to any
+// symbol named by ref
+func (h *HybridRetriever) incomingSources(ref string, edgeType string, repoPath string) (map[string]bool, error) {
+	target := parseSymbolRef(ref)
+	candidates, err := h.symbolStore.FindByName(target.name, repoPath, "", maxGraphTargets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+
+	sources := make(map[string]bool)
+	for _, sym := range candidates {
+		if !target.matches(sym) {
+			continue
+		}
+		edges, err := h.edgeStore.GetIncoming(sym.ID, edgeType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s edges of %s: %w", edgeType, sym.ID, err)
+		}
+		for _, edge := range edges {
+			sources[edge.FromID] = true
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,167 @@
+package retrieval
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  ParsedQuery
+	}{
+		{
+			query: "kind:method recv:*VectorStore nearest neighbors",
+			want:  ParsedQuery{Text: "nearest neighbors", Kinds: []string{"method"}, Receiver: "*VectorStore"},
+		},
+		{
+			query: `pkg:internal/store/... -pkg:cmd/... file:"*_test.go" -file:gen_*.go open`,
+			want: ParsedQuery{
+				Text:            "open",
+				PackagePath:     "internal/store/...",
+				ExcludePackages: []string{"cmd/..."},
+				Files:           []string{"*_test.go"},
+				ExcludeFiles:    []string{"gen_*.go"},
+			},
+		},
+		{
+			query: "calls:EmbedBatch implements:embedding.Client -kind:field,var",
+			want:  ParsedQuery{Calls: []string{"EmbedBatch"}, Implements: []string{"embedding.Client"}, ExcludeKinds: []string{"field", "var"}},
+		},
+		{
+			// Unknown prefixes, phrases and negated words stay free text
+			query: `name:Open "retry with backoff" -flaky`,
+			want:  ParsedQuery{Text: `name:Open "retry with backoff" -flaky`},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) error = %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.query, *got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"kind:widget", "kind:", "-calls:Open", "pkg:a pkg:b", "recv:A recv:B"} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q) error = nil, want an error", query)
+		}
+	}
+}
+
+func TestParsedQuery_Apply(t *testing.T) {
+	q, err := ParseQuery("recv:Server -pkg:cmd/... handle")
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+
+	opts := DefaultSearchOptions()
+	opts.PackagePath = "internal"
+	q.Apply(&opts)
+
+	if opts.Receiver != "Server" || !reflect.DeepEqual(opts.Kinds, []string{"method"}) {
+		t.Errorf("Receiver = %q, Kinds = %v, want Server methods", opts.Receiver, opts.Kinds)
+	}
+	if opts.PackagePath != "internal" || !reflect.DeepEqual(opts.ExcludePackages, []string{"cmd/..."}) {
+		t.Errorf("PackagePath = %q, ExcludePackages = %v", opts.PackagePath, opts.ExcludePackages)
+	}
+}
+
+func TestParseSymbolRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want symbolRef
+	}{
+		{"EmbedBatch", symbolRef{name: "EmbedBatch"}},
+		{"embedding.Client", symbolRef{qualifier: "embedding", name: "Client"}},
+		{"VolcEngineClient.EmbedBatch", symbolRef{qualifier: "VolcEngineClient", name: "EmbedBatch"}},
+		{"embedding.VolcEngineClient.EmbedBatch", symbolRef{pkg: "embedding", receiver: "VolcEngineClient", name: "EmbedBatch"}},
+		{"github.com/org/repo/embedding.Client", symbolRef{pkg: "github.com/org/repo/embedding", name: "Client"}},
+	}
+	for _, tt := range tests {
+		if got := parseSymbolRef(tt.ref); got != tt.want {
+			t.Errorf("parseSymbolRef(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestHybridRetriever_SearchGraphQualifiers(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := store.NewSymbolStore(db)
+	edgeStore := store.NewEdgeStore(db)
+	symbol := func(pkg, kind, name, signature string) *store.Symbol {
+		return &store.Symbol{
+			ID: "r/" + pkg + ":" + kind + ":" + name, RepoPath: "/repo", Kind: kind, PackagePath: "r/" + pkg,
+			PackageName: pkg, Name: name, Signature: signature, FilePath: pkg + "/" + strings.ToLower(name) + ".go",
+			Exported: true, SemanticText: "embeds text",
+		}
+	}
+	if err := symbolStore.CreateBatch([]*store.Symbol{
+		symbol("embedding", "interface", "Client", "type Client interface"),
+		symbol("embedding", "struct", "LocalClient", "type LocalClient struct"),
+		symbol("embedding", "struct", "Service", "type Service struct"),
+		symbol("embedding", "method", "EmbedBatch", "func (c *LocalClient) EmbedBatch(texts []string) error"),
+		symbol("indexer", "func", "Run", "func Run() error"),
+		symbol("cmd", "func", "Main", "func Main()"),
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+	if err := edgeStore.CreateBatch([]*store.Edge{
+		{FromID: "r/embedding:struct:LocalClient", ToID: "r/embedding:interface:Client", EdgeType: store.EdgeTypeImplements, Weight: 10},
+		{FromID: "r/indexer:func:Run", ToID: "r/embedding:method:EmbedBatch", EdgeType: store.EdgeTypeCalls, Weight: 5},
+		{FromID: "r/cmd:func:Main", ToID: "r/embedding:method:EmbedBatch", EdgeType: store.EdgeTypeCalls, Weight: 5},
+	}); err != nil {
+		t.Fatalf("failed to create edges: %v", err)
+	}
+
+	h := NewHybridRetriever(store.NewVectorStore(db), symbolStore, store.NewPackageStore(db), edgeStore, nil, nil)
+	search := func(query string) []string {
+		t.Helper()
+		q, err := ParseQuery(query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) error = %v", query, err)
+		}
+		opts := DefaultSearchOptions()
+		opts.VectorWeight = 0
+		opts.KeywordWeight = 1
+		opts.RepoPath = "/repo"
+		q.Apply(&opts)
+
+		results, err := h.Search(context.Background(), q.Text, opts)
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", query, err)
+		}
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.Symbol.ID)
+		}
+		return ids
+	}
+
+	if got := search("implements:embedding.Client"); !reflect.DeepEqual(got, []string{"r/embedding:struct:LocalClient"}) {
+		t.Errorf("implements:embedding.Client = %v, want LocalClient", got)
+	}
+	if got := search("calls:LocalClient.EmbedBatch -pkg:cmd/... embeds"); !reflect.DeepEqual(got, []string{"r/indexer:func:Run"}) {
+		t.Errorf("calls:LocalClient.EmbedBatch -pkg:cmd = %v, want Run", got)
+	}
+	if got := search("calls:Missing text"); len(got) != 0 {
+		t.Errorf("calls:Missing = %v, want none", got)
+	}
+	if got := search("recv:*LocalClient"); !reflect.DeepEqual(got, []string{"r/embedding:method:EmbedBatch"}) {
+		t.Errorf("recv:*LocalClient = %v, want EmbedBatch", got)
+	}
+}
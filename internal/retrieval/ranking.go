@@ -126,7 +126,8 @@ func (r *GraphRanker) computeFeatures(sym *store.Symbol) *GraphFeatures {
 	return feat
 }
 
-// computePageRank computes PageRank for symbols using the call graph
+// computePageRank computes PageRank for symbols using the call graph, where
+// calls through an interface flow on to its implementations
 func (r *GraphRanker) computePageRank(symbolIDs []string) map[string]float64 {
 	// Build adjacency list
 	idSet := make(map[string]bool)
@@ -139,12 +140,16 @@ func (r *GraphRanker) computePageRank(symbolIDs []string) map[string]float64 {
 	incoming := make(map[string][]string) // id -> list of IDs that point to it
 
 	for _, id := range symbolIDs {
-		edges, err := r.edgeStore.GetOutgoing(id, store.EdgeTypeCalls)
+		calls, err := r.edgeStore.GetOutgoing(id, store.EdgeTypeCalls)
+		if err != nil {
+			continue
+		}
+		dispatches, err := r.edgeStore.GetOutgoing(id, store.EdgeTypeDispatches)
 		if err != nil {
 			continue
 		}
 
-		for _, edge := range edges {
+		for _, edge := range append(calls, dispatches...) {
 			if idSet[edge.ToID] {
 				outgoing[id] = append(outgoing[id], edge.ToID)
 				incoming[edge.ToID] = append(incoming[edge.ToID], id)
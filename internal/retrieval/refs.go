@@ -0,0 +1,216 @@
+package retrieval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// Reference directions
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+	DirectionBoth     = "both"
+)
+
+// RefQuery selects the edges of a symbol
+type RefQuery struct {
+	SymbolID    string // Preferred over SymbolName
+	SymbolName  string // Exact name match
+	PackagePath string // Optional filter for SymbolName
+	EdgeType    string // Empty for all edge types
+	Direction   string // incoming (default) | outgoing | both
+	Limit       int    // Maximum number of edges (0 for all)
+}
+
+// RefSite is a call or reference site with its source line
+type RefSite struct {
+	store.EdgeSite
+	Preview string `json:"preview,omitempty"` // Trimmed source line
+}
+
+// Ref is an edge with both symbols and its sites
+type Ref struct {
+	Edge  *store.Edge   `json:"edge"`
+	From  *store.Symbol `json:"from"`
+	To    *store.Symbol `json:"to"`
+	Sites []RefSite     `json:"sites,omitempty"`
+}
+
+// RefResult holds the symbols matched by a query and their edges
+type RefResult struct {
+	Symbols   []*store.Symbol `json:"symbols"`
+	Direction string          `json:"direction"`
+	Refs      []*Ref          `json:"refs"`
+}
+
+// RefFinder looks up the relationships of symbols with their source sites
+type RefFinder struct {
+	symbolStore *store.SymbolStore
+	edgeStore   *store.EdgeStore
+	repoPath    string
+}
+
+// NewRefFinder creates a finder; repoPath resolves the relative file paths
+// of sites for previews
+func NewRefFinder(symbolStore *store.SymbolStore, edgeStore *store.EdgeStore, repoPath string) *RefFinder {
+	return &RefFinder{
+		symbolStore: symbolStore,
+		edgeStore:   edgeStore,
+		repoPath:    repoPath,
+	}
+}
+
+// Find returns the edges of the queried symbols, most frequent first
+func (f *RefFinder) Find(q RefQuery) (*RefResult, error) {
+	if q.SymbolID == "" && q.SymbolName == "" {
+		return nil, fmt.Errorf("symbol id or name is required")
+	}
+
+	direction := strings.ToLower(strings.TrimSpace(q.Direction))
+	if direction == "" {
+		direction = DirectionIncoming
+	}
+	if direction != DirectionIncoming && direction != DirectionOutgoing && direction != DirectionBoth {
+		return nil, fmt.Errorf("invalid direction: %s", q.Direction)
+	}
+
+	edgeType := strings.TrimSpace(q.EdgeType)
+	if edgeType != "" && !IsValidEdgeType(edgeType) {
+		return nil, fmt.Errorf("invalid edge type: %s", edgeType)
+	}
+
+	var symbols []*store.Symbol
+	if q.SymbolID != "" {
+		sym, err := f.symbolStore.Get(q.SymbolID)
+		if err != nil {
+			return nil, err
+		}
+		if sym != nil {
+			symbols = []*store.Symbol{sym}
+		}
+	} else {
+		var err error
+		symbols, err = f.symbolStore.FindByName(q.SymbolName, f.repoPath, q.PackagePath, 20)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &RefResult{Symbols: symbols, Direction: direction}
+
+	var edges []*store.Edge
+	seen := make(map[int64]bool)
+	for _, sym := range symbols {
+		if direction == DirectionIncoming || direction == DirectionBoth {
+			incoming, err := f.edgeStore.GetIncoming(sym.ID, edgeType)
+			if err != nil {
+				return nil, err
+			}
+			edges = append(edges, incoming...)
+		}
+		if direction == DirectionOutgoing || direction == DirectionBoth {
+			outgoing, err := f.edgeStore.GetOutgoing(sym.ID, edgeType)
+			if err != nil {
+				return nil, err
+			}
+			edges = append(edges, outgoing...)
+		}
+	}
+
+	for _, edge := range edges {
+		if seen[edge.ID] {
+			continue
+		}
+		seen[edge.ID] = true
+
+		fromSym, err := f.symbolStore.Get(edge.FromID)
+		if err != nil {
+			return nil, err
+		}
+		toSym, err := f.symbolStore.Get(edge.ToID)
+		if err != nil {
+			return nil, err
+		}
+		if fromSym == nil || toSym == nil {
+			continue // Edge to a symbol that is no longer indexed
+		}
+		result.Refs = append(result.Refs, &Ref{Edge: edge, From: fromSym, To: toSym})
+	}
+
+	sort.Slice(result.Refs, func(i, j int) bool {
+		a, b := result.Refs[i].Edge, result.Refs[j].Edge
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.EdgeType != b.EdgeType {
+			return a.EdgeType < b.EdgeType
+		}
+		if a.FromID != b.FromID {
+			return a.FromID < b.FromID
+		}
+		return a.ToID < b.ToID
+	})
+	if q.Limit > 0 && len(result.Refs) > q.Limit {
+		result.Refs = result.Refs[:q.Limit]
+	}
+
+	// Sites are loaded for the returned edges only
+	sources := make(map[string][]string)
+	for _, ref := range result.Refs {
+		sites, err := f.edgeStore.GetSites(ref.Edge.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, site := range sites {
+			ref.Sites = append(ref.Sites, RefSite{
+				EdgeSite: site,
+				Preview:  f.preview(sources, site.FilePath, site.Line),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// preview returns a line of a file with surrounding whitespace removed,
+// caching file contents in sources
+func (f *RefFinder) preview(sources map[string][]string, filePath string, line int) string {
+	lines, ok := sources[filePath]
+	if !ok {
+		path := filePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(f.repoPath, path)
+		}
+		if content, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		sources[filePath] = lines
+	}
+
+	if line <= 0 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
+
+// IsValidEdgeType reports whether edgeType is a known edge type
+func IsValidEdgeType(edgeType string) bool {
+	switch edgeType {
+	case store.EdgeTypeCalls,
+		store.EdgeTypeDispatches,
+		store.EdgeTypeImplements,
+		store.EdgeTypeImports,
+		store.EdgeTypeReferences,
+		store.EdgeTypeEmbeds,
+		store.EdgeTypeConstrains,
+		store.EdgeTypeTests:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,65 @@
+package retrieval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+func TestRefFinder_Find(t *testing.T) {
+	repo := t.TempDir()
+	source := "package p\n\nfunc Caller() {\n\tSearch()\n\tif ok := Search(); ok {\n\t}\n}\n"
+	if err := os.WriteFile(filepath.Join(repo, "p.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := store.NewSymbolStore(db)
+	edgeStore := store.NewEdgeStore(db)
+	if err := symbolStore.CreateBatch([]*store.Symbol{
+		{ID: "p:func:Search", RepoPath: repo, Kind: "func", PackagePath: "p", Name: "Search"},
+		{ID: "p:func:Caller", RepoPath: repo, Kind: "func", PackagePath: "p", Name: "Caller"},
+		{ID: "p:func:Other", RepoPath: repo, Kind: "func", PackagePath: "p", Name: "Other"},
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+	if err := edgeStore.CreateBatch([]*store.Edge{
+		{FromID: "p:func:Other", ToID: "p:func:Search", EdgeType: store.EdgeTypeCalls, Weight: 5},
+		{FromID: "p:func:Caller", ToID: "p:func:Search", EdgeType: store.EdgeTypeCalls, Weight: 5,
+			Sites: []store.EdgeSite{{FilePath: "p.go", Line: 4, Column: 2}}},
+		{FromID: "p:func:Caller", ToID: "p:func:Search", EdgeType: store.EdgeTypeCalls, Weight: 5,
+			Sites: []store.EdgeSite{{FilePath: "p.go", Line: 5, Column: 11}}},
+	}); err != nil {
+		t.Fatalf("failed to create edges: %v", err)
+	}
+
+	finder := NewRefFinder(symbolStore, edgeStore, repo)
+	result, err := finder.Find(RefQuery{SymbolName: "Search", EdgeType: "calls"})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	if result.Direction != DirectionIncoming || len(result.Refs) != 2 {
+		t.Fatalf("Find() = %d %s refs, want 2 incoming", len(result.Refs), result.Direction)
+	}
+
+	// The caller with the most sites comes first
+	ref := result.Refs[0]
+	if ref.From.ID != "p:func:Caller" || ref.Edge.Count != 2 || len(ref.Sites) != 2 {
+		t.Fatalf("first ref = %s with count %d and %d sites", ref.From.ID, ref.Edge.Count, len(ref.Sites))
+	}
+	if got := ref.Sites[1]; got.Line != 5 || got.Column != 11 || got.Preview != "if ok := Search(); ok {" {
+		t.Errorf("second site = %+v", got)
+	}
+
+	if _, err := finder.Find(RefQuery{SymbolName: "Search", Direction: "sideways"}); err == nil {
+		t.Error("Find() with an invalid direction succeeded")
+	}
+}
@@ -0,0 +1,151 @@
+package retrieval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// maxRerankDocument bounds the text sent to the reranker per candidate
+const maxRerankDocument = 2000
+
+// Reranker scores documents by their relevance to a query
+type Reranker interface {
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+	Model() string // Keys cached scores
+}
+
+// RerankCache stores relevance scores by model and key
+type RerankCache interface {
+	GetScores(model string, keys []string) (map[string]float64, error)
+	PutScores(model string, scores map[string]float64) error
+}
+
+// RerankOptions configures the rerank stage
+type RerankOptions struct {
+	TopN   int           // Number of leading candidates reranked
+	Budget time.Duration // Time allowed per query before falling back
+}
+
+// SetReranker enables the rerank stage; cache may be nil
+func (h *HybridRetriever) SetReranker(reranker Reranker, cache RerankCache, opts RerankOptions) {
+	h.reranker = reranker
+	h.rerankCache = cache
+	h.rerankOptions = opts
+}
+
+// rerank reorders the leading results by the relevance scores of the
+// reranker. Results keep their order when the reranker fails or runs out
+// of time.
+func (h *HybridRetriever) rerank(ctx context.Context, query string, results []SearchResult) []SearchResult {
+	n := h.rerankOptions.TopN
+	if n <= 0 || n > len(results) {
+		n = len(results)
+	}
+	if h.reranker == nil || n < 2 || strings.TrimSpace(query) == "" {
+		return results
+	}
+
+	model := h.reranker.Model()
+	keys := make([]string, n)
+	documents := make([]string, n)
+	for i := 0; i < n; i++ {
+		documents[i] = rerankDocument(results[i].Symbol)
+		keys[i] = rerankKey(query, documents[i])
+	}
+
+	scores := make(map[string]float64, n)
+	if h.rerankCache != nil {
+		cached, err := h.rerankCache.GetScores(model, keys)
+		if err != nil {
+			log.Printf("Warning: failed to read rerank cache: %v", err)
+		}
+		for key, score := range cached {
+			scores[key] = score
+		}
+	}
+
+	var missKeys, missDocuments []string
+	for i, key := range keys {
+		if _, ok := scores[key]; !ok {
+			missKeys = append(missKeys, key)
+			missDocuments = append(missDocuments, documents[i])
+		}
+	}
+
+	if len(missDocuments) > 0 {
+		rerankCtx := ctx
+		if h.rerankOptions.Budget > 0 {
+			var cancel context.CancelFunc
+			rerankCtx, cancel = context.WithTimeout(ctx, h.rerankOptions.Budget)
+			defer cancel()
+		}
+
+		fresh, err := h.reranker.Rerank(rerankCtx, query, missDocuments)
+		if err == nil && len(fresh) != len(missDocuments) {
+			err = fmt.Errorf("expected %d scores, got %d", len(missDocuments), len(fresh))
+		}
+		if err != nil {
+			log.Printf("Warning: rerank failed, keeping original order: %v", err)
+			return results
+		}
+
+		freshScores := make(map[string]float64, len(fresh))
+		for i, score := range fresh {
+			freshScores[missKeys[i]] = score
+			scores[missKeys[i]] = score
+		}
+		if h.rerankCache != nil {
+			if err := h.rerankCache.PutScores(model, freshScores); err != nil {
+				log.Printf("Warning: failed to write rerank cache: %v", err)
+			}
+		}
+	}
+
+	head := make([]SearchResult, n)
+	copy(head, results[:n])
+	for i := range head {
+		head[i].RerankScore = scores[keys[i]]
+		head[i].Reason = append(head[i].Reason, fmt.Sprintf("Reranked (relevance %.2f)", head[i].RerankScore))
+	}
+	sort.SliceStable(head, func(i, j int) bool {
+		return head[i].RerankScore > head[j].RerankScore
+	})
+
+	return append(head, results[n:]...)
+}
+
+// rerankDocument describes a candidate to the reranker: its signature, doc
+// comment and semantic card
+func rerankDocument(sym *store.Symbol) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s (package %s)\n", sym.Kind, sym.Name, sym.PackagePath)
+	if sym.Signature != "" {
+		fmt.Fprintf(&b, "%s\n", sym.Signature)
+	}
+	if sym.DocComment != "" {
+		fmt.Fprintf(&b, "%s\n", strings.TrimSpace(sym.DocComment))
+	}
+	if sym.SemanticText != "" {
+		fmt.Fprintf(&b, "%s\n", strings.TrimSpace(sym.SemanticText))
+	}
+
+	doc := strings.TrimSpace(b.String())
+	if len(doc) > maxRerankDocument {
+		doc = strings.ToValidUTF8(doc[:maxRerankDocument], "")
+	}
+	return doc
+}
+
+// rerankKey identifies the score of a document for a query
+func rerankKey(query, document string) string {
+	sum := sha256.Sum256([]byte(query + "\x00" + document))
+	return hex.EncodeToString(sum[:])
+}
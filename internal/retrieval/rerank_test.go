@@ -0,0 +1,137 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/rerank"
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+func newRerankClient(t *testing.T, server *httptest.Server) *rerank.Client {
+	t.Helper()
+	client, err := rerank.NewClient(&config.RerankConfig{Provider: "rerank", BaseURL: server.URL, Model: "test"})
+	if err != nil {
+		t.Fatalf("failed to create rerank client: %v", err)
+	}
+	return client
+}
+
+func rerankResults() []SearchResult {
+	var results []SearchResult
+	for _, name := range []string{"Close", "Flush", "Open", "Sync"} {
+		results = append(results, SearchResult{
+			Symbol: &store.Symbol{ID: "p:func:" + name, Kind: "func", PackagePath: "p", Name: name, Signature: "func " + name + "() error"},
+		})
+	}
+	return results
+}
+
+func resultNames(results []SearchResult) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Symbol.Name
+	}
+	return names
+}
+
+func TestHybridRetriever_Rerank(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var scored int32
+	// Documents that mention "Open" score highest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Documents []string `json:"documents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(&scored, int32(len(req.Documents)))
+
+		type result struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		}
+		var resp struct {
+			Results []result `json:"results"`
+		}
+		for i, doc := range req.Documents {
+			score := 0.1
+			if strings.Contains(doc, "Open") {
+				score = 0.9
+			}
+			resp.Results = append(resp.Results, result{Index: i, RelevanceScore: score})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	h := &HybridRetriever{}
+	h.SetReranker(newRerankClient(t, server), store.NewRerankCache(db), RerankOptions{TopN: 3, Budget: time.Second})
+
+	results := h.rerank(context.Background(), "open the database", rerankResults())
+	if got := strings.Join(resultNames(results), ","); got != "Open,Close,Flush,Sync" {
+		t.Fatalf("order = %s, want Open first and Sync (beyond top N) last", got)
+	}
+	if results[0].RerankScore != 0.9 || results[3].RerankScore != 0 {
+		t.Errorf("rerank scores = %v, %v", results[0].RerankScore, results[3].RerankScore)
+	}
+	if scored != 3 {
+		t.Errorf("scored %d documents, want 3", scored)
+	}
+
+	// Repeating the query is served from the cache
+	results = h.rerank(context.Background(), "open the database", rerankResults())
+	if got := strings.Join(resultNames(results), ","); got != "Open,Close,Flush,Sync" {
+		t.Errorf("cached order = %s", got)
+	}
+	if scored != 3 {
+		t.Errorf("scored %d documents after a cached query, want 3", scored)
+	}
+}
+
+func TestHybridRetriever_RerankFallsBack(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	t.Cleanup(slow.Close)
+	h := &HybridRetriever{}
+	h.SetReranker(newRerankClient(t, slow), nil, RerankOptions{TopN: 4, Budget: 50 * time.Millisecond})
+
+	start := time.Now()
+	results := h.rerank(context.Background(), "open", rerankResults())
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("rerank took %v, want the budget to cut it short", elapsed)
+	}
+	if got := strings.Join(resultNames(results), ","); got != "Close,Flush,Open,Sync" {
+		t.Errorf("order after timeout = %s, want original order", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	h.SetReranker(newRerankClient(t, failing), nil, RerankOptions{TopN: 4, Budget: time.Second})
+
+	results = h.rerank(context.Background(), "open", rerankResults())
+	if got := strings.Join(resultNames(results), ","); got != "Close,Flush,Open,Sync" {
+		t.Errorf("order after error = %s, want original order", got)
+	}
+	if results[0].RerankScore != 0 || len(results[0].Reason) != 0 {
+		t.Errorf("result changed after a failed rerank: %+v", results[0])
+	}
+}
@@ -0,0 +1,201 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/DreamCats/bcindex/internal/embedding"
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+// similarCandidateFactor widens the vector search when too few candidates
+// remain once the source package is excluded, since its own symbols are
+// often the nearest
+const similarCandidateFactor = 4
+
+// maxSimilarCandidates bounds the widened vector search
+const maxSimilarCandidates = 2000
+
+// SimilarQuery selects the code to find neighbors of: an indexed symbol by ID
+// or location, or a snippet to embed
+type SimilarQuery struct {
+	SymbolID string // Preferred over FilePath and Snippet
+	FilePath string // Repo-relative file of the symbol, with Line
+	Line     int
+	Snippet  string // Code or text embedded when no symbol is given
+
+	RepoPath           string   // Restrict neighbors to this repository
+	Kinds              []string // Neighbor kinds; defaults to the kinds comparable to the symbol
+	ExcludeSamePackage bool     // Skip neighbors in the symbol's own package
+	Limit              int      // Maximum number of neighbors (default 10)
+}
+
+// SimilarSymbol is a neighbor with its cosine similarity
+type SimilarSymbol struct {
+	Symbol     *store.Symbol `json:"symbol"`
+	Similarity float32       `json:"similarity"`
+}
+
+// SimilarResult holds the source symbol (nil for snippets) and its neighbors
+type SimilarResult struct {
+	Source    *store.Symbol   `json:"source,omitempty"`
+	Neighbors []SimilarSymbol `json:"neighbors"`
+}
+
+// SimilarFinder finds the symbols nearest to a symbol or snippet by embedding
+type SimilarFinder struct {
+	vectorStore  *store.VectorStore
+	symbolStore  *store.SymbolStore
+	embedService *embedding.Service
+}
+
+// NewSimilarFinder creates a finder; embedService is only used for snippets
+func NewSimilarFinder(vectorStore *store.VectorStore, symbolStore *store.SymbolStore, embedService *embedding.Service) *SimilarFinder {
+	return &SimilarFinder{
+		vectorStore:  vectorStore,
+		symbolStore:  symbolStore,
+		embedService: embedService,
+	}
+}
+
+// Find returns the nearest neighbors of the queried symbol or snippet, most
+// similar first. The symbol itself is never returned.
+func (f *SimilarFinder) Find(ctx context.Context, q SimilarQuery) (*SimilarResult, error) {
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+
+	source, err := f.resolveSource(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var vector []float32
+	switch {
+	case source != nil:
+		// Tests, unexported fields and blank identifiers are not embedded
+		embedded, err := f.vectorStore.HasVector(source.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !embedded {
+			return nil, fmt.Errorf("symbol is not embedded: %s (pass its code as a snippet instead)", source.ID)
+		}
+		vector, err = f.vectorStore.Get(source.ID)
+		if err != nil {
+			return nil, err
+		}
+	case strings.TrimSpace(q.Snippet) != "":
+		if f.embedService == nil {
+			return nil, fmt.Errorf("embedding service is required for snippets")
+		}
+		vector, err = f.embedService.Embed(ctx, q.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed snippet: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("symbol id, file and line, or snippet is required")
+	}
+
+	filters := store.SearchFilters{
+		RepoPath:     q.RepoPath,
+		Kinds:        q.Kinds,
+		ExcludeTests: true,
+	}
+	candidates := q.Limit
+	if source != nil {
+		if len(filters.Kinds) == 0 {
+			filters.Kinds = comparableKinds(source.Kind)
+		}
+		candidates++
+	}
+
+	// The candidate list grows until enough neighbors remain after the
+	// source and its package are skipped
+	result := &SimilarResult{Source: source}
+	for {
+		scored, err := f.vectorStore.SearchByFilters(vector, candidates, filters, f.symbolStore)
+		if err != nil {
+			return nil, fmt.Errorf("vector search failed: %w", err)
+		}
+
+		result.Neighbors = []SimilarSymbol{}
+		for _, r := range scored {
+			if r.Symbol == nil {
+				continue
+			}
+			if source != nil {
+				if r.SymbolID == source.ID {
+					continue
+				}
+				if q.ExcludeSamePackage && r.Symbol.PackagePath == source.PackagePath {
+					continue
+				}
+			}
+			result.Neighbors = append(result.Neighbors, SimilarSymbol{Symbol: r.Symbol, Similarity: r.Score})
+			if len(result.Neighbors) == q.Limit {
+				break
+			}
+		}
+
+		if len(result.Neighbors) == q.Limit || len(scored) < candidates || candidates >= maxSimilarCandidates {
+			break
+		}
+		candidates *= similarCandidateFactor
+	}
+
+	return result, nil
+}
+
+// resolveSource returns the symbol of the query, or nil for a snippet. A
+// member of a const group resolves to the group's first member.
+func (f *SimilarFinder) resolveSource(q SimilarQuery) (*store.Symbol, error) {
+	var sym *store.Symbol
+	var err error
+	switch {
+	case q.SymbolID != "":
+		sym, err = f.symbolStore.Get(q.SymbolID)
+		if err != nil {
+			return nil, err
+		}
+		if sym == nil {
+			return nil, fmt.Errorf("symbol not found: %s", q.SymbolID)
+		}
+	case q.FilePath != "":
+		sym, err = f.symbolStore.FindByLocation(q.RepoPath, q.FilePath, q.Line)
+		if err != nil {
+			return nil, err
+		}
+		if sym == nil {
+			return nil, fmt.Errorf("no symbol found at %s:%d", q.FilePath, q.Line)
+		}
+	default:
+		return nil, nil
+	}
+
+	// Const groups are embedded as one unit under their first member
+	if sym.TypeDetails != nil && sym.TypeDetails.Group != "" {
+		lead, err := f.symbolStore.Get(sym.TypeDetails.Group)
+		if err != nil {
+			return nil, err
+		}
+		if lead != nil {
+			return lead, nil
+		}
+	}
+	return sym, nil
+}
+
+// comparableKinds returns the kinds worth comparing a symbol of kind with:
+// functions with methods, types with types, others with their own kind
+func comparableKinds(kind string) []string {
+	switch kind {
+	case store.KindFunc, store.KindMethod:
+		return []string{store.KindFunc, store.KindMethod}
+	case store.KindStruct, store.KindInterface, store.KindType:
+		return []string{store.KindStruct, store.KindInterface, store.KindType}
+	default:
+		return []string{kind}
+	}
+}
@@ -0,0 +1,163 @@
+package retrieval
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DreamCats/bcindex/internal/config"
+	"github.com/DreamCats/bcindex/internal/embedding"
+	"github.com/DreamCats/bcindex/internal/store"
+)
+
+func TestSimilarFinder_Find(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := store.NewSymbolStore(db)
+	vectorStore := store.NewVectorStore(db)
+	symbols := []*store.Symbol{
+		{ID: "r/embedding:method:VolcEngineClient.EmbedBatch", RepoPath: "/repo", Kind: "method", PackagePath: "r/embedding", Name: "EmbedBatch", FilePath: "embedding/volcengine.go", LineStart: 10, LineEnd: 40},
+		{ID: "r/embedding:method:OpenAIClient.EmbedBatch", RepoPath: "/repo", Kind: "method", PackagePath: "r/embedding", Name: "EmbedBatch", FilePath: "embedding/openai.go", LineStart: 20, LineEnd: 50},
+		{ID: "r/gateway:func:EmbedAll", RepoPath: "/repo", Kind: "func", PackagePath: "r/gateway", Name: "EmbedAll", FilePath: "gateway/embed.go", LineStart: 5, LineEnd: 30},
+		{ID: "r/gateway:struct:Gateway", RepoPath: "/repo", Kind: "struct", PackagePath: "r/gateway", Name: "Gateway", FilePath: "gateway/gateway.go", LineStart: 1, LineEnd: 8},
+		{ID: "r/store:func:Open", RepoPath: "/repo", Kind: "func", PackagePath: "r/store", Name: "Open", FilePath: "store/db.go", LineStart: 1, LineEnd: 9},
+	}
+	if err := symbolStore.CreateBatch(symbols); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+	vectors := [][]float32{{1, 0, 0}, {0.9, 0.1, 0}, {0.8, 0.3, 0}, {0.95, 0.05, 0}, {0, 0, 1}}
+	for i, sym := range symbols {
+		if err := vectorStore.Insert(sym.ID, vectors[i], "test"); err != nil {
+			t.Fatalf("failed to insert vector: %v", err)
+		}
+	}
+
+	finder := NewSimilarFinder(vectorStore, symbolStore, nil)
+	neighbors := func(q SimilarQuery) []string {
+		t.Helper()
+		q.RepoPath = "/repo"
+		result, err := finder.Find(context.Background(), q)
+		if err != nil {
+			t.Fatalf("Find(%+v) error = %v", q, err)
+		}
+		var ids []string
+		for _, n := range result.Neighbors {
+			ids = append(ids, n.Symbol.ID)
+		}
+		return ids
+	}
+
+	// The symbol itself and the struct of a different kind are left out
+	got := neighbors(SimilarQuery{SymbolID: "r/embedding:method:VolcEngineClient.EmbedBatch", Limit: 2})
+	want := []string{"r/embedding:method:OpenAIClient.EmbedBatch", "r/gateway:func:EmbedAll"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("neighbors = %v, want %v", got, want)
+	}
+
+	// A location inside the symbol finds it too; its package can be skipped
+	got = neighbors(SimilarQuery{FilePath: "embedding/volcengine.go", Line: 25, ExcludeSamePackage: true, Limit: 2})
+	want = []string{"r/gateway:func:EmbedAll", "r/store:func:Open"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("neighbors without own package = %v, want %v", got, want)
+	}
+
+	if _, err := finder.Find(context.Background(), SimilarQuery{RepoPath: "/repo", FilePath: "embedding/volcengine.go", Line: 99}); err == nil {
+		t.Error("Find() at a line without symbol: error = nil")
+	}
+
+	// Tests are not embedded
+	if err := symbolStore.Create(&store.Symbol{ID: "r/store:func:TestOpen", RepoPath: "/repo", Kind: "test", PackagePath: "r/store", Name: "TestOpen", FilePath: "store/db_test.go", LineStart: 1, LineEnd: 9}); err != nil {
+		t.Fatalf("failed to create test symbol: %v", err)
+	}
+	if _, err := finder.Find(context.Background(), SimilarQuery{RepoPath: "/repo", SymbolID: "r/store:func:TestOpen"}); err == nil || !strings.Contains(err.Error(), "not embedded") {
+		t.Errorf("Find() for a test: error = %v, want not embedded", err)
+	}
+}
+
+func TestSimilarFinder_FindConstGroupMember(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := store.NewSymbolStore(db)
+	vectorStore := store.NewVectorStore(db)
+	lead := "r/log:const:LevelDebug"
+	if err := symbolStore.CreateBatch([]*store.Symbol{
+		{ID: lead, RepoPath: "/repo", Kind: "const", PackagePath: "r/log", Name: "LevelDebug", FilePath: "log/level.go", LineStart: 4, LineEnd: 7,
+			TypeDetails: &store.TypeDetails{Members: []string{"LevelDebug", "LevelInfo", "LevelError"}}},
+		{ID: "r/log:const:LevelInfo", RepoPath: "/repo", Kind: "const", PackagePath: "r/log", Name: "LevelInfo", FilePath: "log/level.go", LineStart: 5, LineEnd: 5,
+			TypeDetails: &store.TypeDetails{Group: lead}},
+		{ID: "r/http:const:MethodGet", RepoPath: "/repo", Kind: "const", PackagePath: "r/http", Name: "MethodGet", FilePath: "http/method.go", LineStart: 3, LineEnd: 5},
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+	// Only the group lead is embedded
+	for id, vector := range map[string][]float32{lead: {1, 0}, "r/http:const:MethodGet": {0.9, 0.1}} {
+		if err := vectorStore.Insert(id, vector, "test"); err != nil {
+			t.Fatalf("failed to insert vector: %v", err)
+		}
+	}
+
+	result, err := NewSimilarFinder(vectorStore, symbolStore, nil).Find(context.Background(), SimilarQuery{RepoPath: "/repo", FilePath: "log/level.go", Line: 5})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if result.Source == nil || result.Source.ID != lead {
+		t.Errorf("source = %+v, want the group lead", result.Source)
+	}
+	if len(result.Neighbors) != 1 || result.Neighbors[0].Symbol.ID != "r/http:const:MethodGet" {
+		t.Errorf("neighbors = %+v, want MethodGet", result.Neighbors)
+	}
+}
+
+func TestSimilarFinder_FindSnippet(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	embedService, err := embedding.NewService(&config.EmbeddingConfig{Provider: "local", Dimensions: 64, BatchSize: 10})
+	if err != nil {
+		t.Fatalf("failed to create embedding service: %v", err)
+	}
+
+	symbolStore := store.NewSymbolStore(db)
+	vectorStore := store.NewVectorStore(db)
+	texts := map[string]string{
+		"p:func:Retry": "for attempt := 0; attempt < max; attempt++ { if err = fn(); err == nil { return nil } }",
+		"p:func:Parse": "fields := strings.Split(line, \",\")",
+	}
+	for id, text := range texts {
+		if err := symbolStore.Create(&store.Symbol{ID: id, RepoPath: "/repo", Kind: "func", PackagePath: "p", Name: id[len("p:func:"):]}); err != nil {
+			t.Fatalf("failed to create symbol: %v", err)
+		}
+		vector, err := embedService.Embed(context.Background(), text)
+		if err != nil {
+			t.Fatalf("failed to embed: %v", err)
+		}
+		if err := vectorStore.Insert(id, vector, "local"); err != nil {
+			t.Fatalf("failed to insert vector: %v", err)
+		}
+	}
+
+	finder := NewSimilarFinder(vectorStore, symbolStore, embedService)
+	result, err := finder.Find(context.Background(), SimilarQuery{Snippet: texts["p:func:Retry"], RepoPath: "/repo", Limit: 5})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if result.Source != nil || len(result.Neighbors) != 2 || result.Neighbors[0].Symbol.ID != "p:func:Retry" {
+		t.Fatalf("Find(snippet) = %+v, want Retry first", result)
+	}
+	if result.Neighbors[0].Similarity < 0.99 || result.Neighbors[1].Similarity >= result.Neighbors[0].Similarity {
+		t.Errorf("similarities = %v, %v", result.Neighbors[0].Similarity, result.Neighbors[1].Similarity)
+	}
+}
@@ -102,8 +102,12 @@ func (g *Generator) GenerateSymbolCard(sym *ast.ExtractedSymbol, pkgCard string)
 	switch sym.Kind {
 	case "method", "func":
 		card.WriteString(g.generateFuncContext(sym))
-	case "struct", "interface":
+	case "struct", "interface", "type":
 		card.WriteString(g.generateTypeContext(sym))
+	case "const", "var":
+		card.WriteString(g.generateValueContext(sym))
+	case "field":
+		card.WriteString(g.generateFieldContext(sym))
 	}
 
 	return card.String()
@@ -394,11 +398,39 @@ func (g *Generator) generateTypeContext(sym *ast.ExtractedSymbol) string {
 		ctx.WriteString("Interface defining behavior contract\n")
 	} else if sym.Kind == "struct" {
 		ctx.WriteString("Data structure\n")
+	} else if strings.Contains(sym.Signature, " = ") {
+		ctx.WriteString("Type alias\n")
+	} else if sym.Kind == "type" {
+		ctx.WriteString("Defined type\n")
 	}
 
 	return ctx.String()
 }
 
+// generateValueContext generates additional context for constants and
+// variables
+func (g *Generator) generateValueContext(sym *ast.ExtractedSymbol) string {
+	var ctx strings.Builder
+
+	if len(sym.Members) > 1 {
+		ctx.WriteString(fmt.Sprintf("Enumeration of %s\n", strings.Join(sym.Members, ", ")))
+	}
+	if sym.Kind == "var" && (strings.Contains(sym.Signature, "errors.New(") || strings.Contains(sym.Signature, "fmt.Errorf(")) {
+		ctx.WriteString("Sentinel error value\n")
+	}
+
+	return ctx.String()
+}
+
+// generateFieldContext generates additional context for struct fields
+func (g *Generator) generateFieldContext(sym *ast.ExtractedSymbol) string {
+	if sym.ParentID == "" {
+		return ""
+	}
+	owner := sym.ParentID[strings.LastIndex(sym.ParentID, ":")+1:]
+	return fmt.Sprintf("Field of struct %s\n", owner)
+}
+
 // extractDirName extracts the directory name from package path
 func extractDirName(pkgPath string) string {
 	parts := strings.Split(pkgPath, "/")
@@ -0,0 +1,638 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The approximate nearest neighbor (ANN) index is an inverted file (IVF) index
+// kept inside the SQLite database. Vectors are clustered with spherical
+// k-means; every embedding is assigned to its nearest centroid, and a query
+// only scores the vectors of the few clusters closest to it.
+//
+// Assignments reference embeddings with ON DELETE CASCADE, so deleting
+// embeddings (by symbol, package or repository) keeps the index in sync.
+// InsertBatch assigns new vectors to the existing centroids.
+//
+// Body chunks share the centroids of symbol embeddings and record their
+// cluster in chunks.cluster_id, so chunk search probes the same clusters.
+
+const (
+	// annMinVectors is the size below which exact search is fast enough
+	annMinVectors = 2000
+
+	// annMaxTrainVectors caps the sample used for k-means training
+	annMaxTrainVectors = 20000
+
+	// annTrainIterations is the number of k-means iterations
+	annTrainIterations = 8
+
+	// annMinProbes is the minimum number of clusters scanned per query
+	annMinProbes = 8
+)
+
+// ANN metadata keys
+const (
+	annMetaDimension    = "dimension"
+	annMetaTrainedCount = "trained_count"
+	annMetaBuiltAt      = "built_at"
+)
+
+// centroid is a trained IVF cluster center
+type centroid struct {
+	id     int
+	vector []float32
+}
+
+// IndexStats describes the state of the ANN index
+type IndexStats struct {
+	Clusters     int
+	Dimension    int
+	TrainedCount int
+	Assigned     int
+	Unassigned   int
+}
+
+// EnsureIndex builds or rebuilds the ANN index when it is missing or stale.
+// It returns true if the index was (re)built.
+func (v *VectorStore) EnsureIndex() (bool, error) {
+	count, err := v.Count()
+	if err != nil {
+		return false, err
+	}
+
+	if count < annMinVectors {
+		// Small stores are scanned exactly; drop any leftover index
+		stats, err := v.IndexStats()
+		if err != nil {
+			return false, err
+		}
+		if stats.Clusters > 0 {
+			if err := v.DropIndex(); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	stats, err := v.IndexStats()
+	if err != nil {
+		return false, err
+	}
+
+	stale := stats.Clusters == 0 ||
+		stats.Unassigned > 0 ||
+		count > stats.TrainedCount*2 ||
+		count < stats.TrainedCount/2
+
+	if !stale {
+		return false, nil
+	}
+
+	if err := v.BuildIndex(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// BuildIndex trains the IVF centroids from the stored vectors and assigns
+// every vector to its nearest cluster. Only vectors of the most common
+// dimension are indexed; others are left to the exact-scan fallback.
+func (v *VectorStore) BuildIndex() error {
+	dimension, err := v.dominantDimension()
+	if err != nil {
+		return err
+	}
+	if dimension == 0 {
+		return v.DropIndex()
+	}
+
+	ids, vectors, err := v.loadVectors(dimension)
+	if err != nil {
+		return err
+	}
+	if len(vectors) == 0 {
+		return v.DropIndex()
+	}
+
+	for _, vec := range vectors {
+		normalize(vec)
+	}
+
+	k := int(math.Sqrt(float64(len(vectors))))
+	if k < 1 {
+		k = 1
+	}
+	centroids := trainCentroids(vectors, k, annTrainIterations)
+
+	tx, err := v.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := clearIndexTx(tx); err != nil {
+		return err
+	}
+
+	centroidStmt, err := tx.Prepare("INSERT INTO ann_centroids (id, dimension, vector) VALUES (?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare centroid statement: %w", err)
+	}
+	defer centroidStmt.Close()
+
+	for _, c := range centroids {
+		blob, err := vectorToBlob(c.vector)
+		if err != nil {
+			return fmt.Errorf("failed to convert centroid to blob: %w", err)
+		}
+		if _, err := centroidStmt.Exec(c.id, dimension, blob); err != nil {
+			return fmt.Errorf("failed to insert centroid: %w", err)
+		}
+	}
+
+	assignStmt, err := tx.Prepare("INSERT OR REPLACE INTO ann_assignments (symbol_id, cluster_id) VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare assignment statement: %w", err)
+	}
+	defer assignStmt.Close()
+
+	for i, vec := range vectors {
+		if _, err := assignStmt.Exec(ids[i], nearestCentroid(vec, centroids)); err != nil {
+			return fmt.Errorf("failed to assign vector %s: %w", ids[i], err)
+		}
+	}
+
+	if err := assignChunksTx(tx, centroids); err != nil {
+		return err
+	}
+
+	meta := map[string]string{
+		annMetaDimension:    strconv.Itoa(dimension),
+		annMetaTrainedCount: strconv.Itoa(len(vectors)),
+		annMetaBuiltAt:      time.Now().UTC().Format(time.RFC3339),
+	}
+	for key, value := range meta {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO ann_meta (key, value) VALUES (?, ?)", key, value); err != nil {
+			return fmt.Errorf("failed to write index metadata: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit index: %w", err)
+	}
+
+	return nil
+}
+
+// DropIndex removes the ANN index; searches fall back to exact scan
+func (v *VectorStore) DropIndex() error {
+	tx, err := v.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := clearIndexTx(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// IndexStats returns statistics about the ANN index
+func (v *VectorStore) IndexStats() (*IndexStats, error) {
+	stats := &IndexStats{}
+
+	if err := v.db.sqlDB.QueryRow("SELECT COUNT(*) FROM ann_centroids").Scan(&stats.Clusters); err != nil {
+		return nil, fmt.Errorf("failed to count centroids: %w", err)
+	}
+	if stats.Clusters == 0 {
+		return stats, nil
+	}
+
+	meta, err := v.loadIndexMeta()
+	if err != nil {
+		return nil, err
+	}
+	stats.Dimension, _ = strconv.Atoi(meta[annMetaDimension])
+	stats.TrainedCount, _ = strconv.Atoi(meta[annMetaTrainedCount])
+
+	if err := v.db.sqlDB.QueryRow("SELECT COUNT(*) FROM ann_assignments").Scan(&stats.Assigned); err != nil {
+		return nil, fmt.Errorf("failed to count assignments: %w", err)
+	}
+
+	query := `
+		SELECT COUNT(*)
+		FROM embeddings e
+		LEFT JOIN ann_assignments a ON a.symbol_id = e.symbol_id
+		WHERE a.symbol_id IS NULL AND e.dimension = ?
+	`
+	if err := v.db.sqlDB.QueryRow(query, stats.Dimension).Scan(&stats.Unassigned); err != nil {
+		return nil, fmt.Errorf("failed to count unassigned vectors: %w", err)
+	}
+
+	return stats, nil
+}
+
+// searchANN scores only the vectors in the clusters nearest to the query.
+// It returns ok=false when no usable index exists for the query dimension.
+func (v *VectorStore) searchANN(queryVector []float32, topK int, useSimilarity bool) ([]ScoredResult, bool, error) {
+	return v.searchANNWhere(queryVector, topK, useSimilarity, "", nil)
+}
+
+// searchANNWhere is searchANN restricted by a condition on the symbols table
+// (aliased as s). An empty condition matches every symbol.
+func (v *VectorStore) searchANNWhere(queryVector []float32, topK int, useSimilarity bool, where string, whereArgs []interface{}) ([]ScoredResult, bool, error) {
+	centroids, err := v.loadCentroids(len(queryVector))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(centroids) == 0 {
+		return nil, false, nil
+	}
+
+	clusterIDs := nearestCentroids(queryVector, centroids, v.probeCount(len(centroids)))
+	placeholders, args := clusterArgs(clusterIDs)
+
+	query := fmt.Sprintf(`
+		SELECT e.symbol_id, e.vector, e.dimension
+		FROM ann_assignments a
+		JOIN embeddings e ON e.symbol_id = a.symbol_id
+		WHERE a.cluster_id IN (%s)
+	`, placeholders)
+	if where != "" {
+		query += " AND a.symbol_id IN (SELECT s.id FROM symbols s WHERE " + where + ")"
+		args = append(args, whereArgs...)
+	}
+
+	rows, err := v.db.sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query clustered vectors: %w", err)
+	}
+	defer rows.Close()
+
+	results, err := scoreRows(rows, queryVector, useSimilarity)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Too few candidates in the probed clusters; let the caller scan exactly
+	if len(results) < topK {
+		return nil, false, nil
+	}
+
+	return results, true, nil
+}
+
+// probeCount returns the number of clusters scanned per query
+func (v *VectorStore) probeCount(clusters int) int {
+	if v.probes > 0 {
+		return v.probes
+	}
+	probes := clusters / 10
+	if probes < annMinProbes {
+		probes = annMinProbes
+	}
+	return probes
+}
+
+// clusterArgs returns the placeholders and arguments of a cluster ID list
+func clusterArgs(clusterIDs []int) (string, []interface{}) {
+	placeholders := make([]string, len(clusterIDs))
+	args := make([]interface{}, len(clusterIDs))
+	for i, id := range clusterIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+// assignVectorsTx assigns freshly inserted vectors to their nearest centroid
+func (v *VectorStore) assignVectorsTx(tx *sql.Tx, symbolIDs []string, vectors [][]float32) error {
+	centroids, err := loadCentroidsTx(tx)
+	if err != nil {
+		return err
+	}
+	if len(centroids) == 0 {
+		return nil
+	}
+	dimension := len(centroids[0].vector)
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO ann_assignments (symbol_id, cluster_id) VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare assignment statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, vector := range vectors {
+		if len(vector) != dimension {
+			continue
+		}
+		normalized := make([]float32, len(vector))
+		copy(normalized, vector)
+		normalize(normalized)
+
+		if _, err := stmt.Exec(symbolIDs[i], nearestCentroid(normalized, centroids)); err != nil {
+			return fmt.Errorf("failed to assign vector %s: %w", symbolIDs[i], err)
+		}
+	}
+
+	return nil
+}
+
+// assignChunksTx assigns every chunk to its nearest centroid
+func assignChunksTx(tx *sql.Tx, centroids []centroid) error {
+	rows, err := tx.Query("SELECT id, vector FROM chunks")
+	if err != nil {
+		return fmt.Errorf("failed to query chunks: %w", err)
+	}
+	assignments := make(map[string]sql.NullInt64)
+	for rows.Next() {
+		var id string
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		vector, err := blobToVector(blob)
+		if err != nil {
+			continue // Malformed vectors stay unassigned
+		}
+		assignments[id] = chunkCluster(vector, centroids)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating chunks: %w", err)
+	}
+
+	stmt, err := tx.Prepare("UPDATE chunks SET cluster_id = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare chunk assignment statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for id, clusterID := range assignments {
+		if _, err := stmt.Exec(clusterID, id); err != nil {
+			return fmt.Errorf("failed to assign chunk %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// chunkCluster returns the nearest centroid of a chunk vector, or NULL when
+// the index has another dimension
+func chunkCluster(vector []float32, centroids []centroid) sql.NullInt64 {
+	if len(centroids) == 0 || len(vector) != len(centroids[0].vector) {
+		return sql.NullInt64{}
+	}
+	normalized := make([]float32, len(vector))
+	copy(normalized, vector)
+	normalize(normalized)
+	return sql.NullInt64{Int64: int64(nearestCentroid(normalized, centroids)), Valid: true}
+}
+
+func (v *VectorStore) dominantDimension() (int, error) {
+	var dimension int
+	query := "SELECT dimension FROM embeddings GROUP BY dimension ORDER BY COUNT(*) DESC LIMIT 1"
+	if err := v.db.sqlDB.QueryRow(query).Scan(&dimension); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get vector dimension: %w", err)
+	}
+	return dimension, nil
+}
+
+func (v *VectorStore) loadVectors(dimension int) ([]string, [][]float32, error) {
+	rows, err := v.db.sqlDB.Query("SELECT symbol_id, vector FROM embeddings WHERE dimension = ?", dimension)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query vectors: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	var vectors [][]float32
+	for rows.Next() {
+		var symbolID string
+		var blob []byte
+		if err := rows.Scan(&symbolID, &blob); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		vector, err := blobToVector(blob)
+		if err != nil || len(vector) != dimension {
+			continue
+		}
+		ids = append(ids, symbolID)
+		vectors = append(vectors, vector)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return ids, vectors, nil
+}
+
+func (v *VectorStore) loadIndexMeta() (map[string]string, error) {
+	rows, err := v.db.sqlDB.Query("SELECT key, value FROM ann_meta")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index metadata: %w", err)
+	}
+	defer rows.Close()
+
+	meta := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan index metadata: %w", err)
+		}
+		meta[key] = value
+	}
+	return meta, rows.Err()
+}
+
+func (v *VectorStore) loadCentroids(dimension int) ([]centroid, error) {
+	rows, err := v.db.sqlDB.Query("SELECT id, vector FROM ann_centroids WHERE dimension = ? ORDER BY id", dimension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query centroids: %w", err)
+	}
+	defer rows.Close()
+	return scanCentroids(rows)
+}
+
+func loadCentroidsTx(tx *sql.Tx) ([]centroid, error) {
+	rows, err := tx.Query("SELECT id, vector FROM ann_centroids ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query centroids: %w", err)
+	}
+	defer rows.Close()
+	return scanCentroids(rows)
+}
+
+func scanCentroids(rows *sql.Rows) ([]centroid, error) {
+	var centroids []centroid
+	for rows.Next() {
+		var c centroid
+		var blob []byte
+		if err := rows.Scan(&c.id, &blob); err != nil {
+			return nil, fmt.Errorf("failed to scan centroid: %w", err)
+		}
+		vector, err := blobToVector(blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode centroid %d: %w", c.id, err)
+		}
+		c.vector = vector
+		centroids = append(centroids, c)
+	}
+	return centroids, rows.Err()
+}
+
+func clearIndexTx(tx *sql.Tx) error {
+	for _, table := range []string{"ann_assignments", "ann_centroids", "ann_meta"} {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", table)); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE chunks SET cluster_id = NULL WHERE cluster_id IS NOT NULL"); err != nil {
+		return fmt.Errorf("failed to clear chunk assignments: %w", err)
+	}
+	return nil
+}
+
+// trainCentroids runs spherical k-means over normalized vectors
+func trainCentroids(vectors [][]float32, k int, iterations int) []centroid {
+	rng := rand.New(rand.NewSource(42))
+
+	sample := vectors
+	if len(sample) > annMaxTrainVectors {
+		sample = make([][]float32, annMaxTrainVectors)
+		for i, j := range rng.Perm(len(vectors))[:annMaxTrainVectors] {
+			sample[i] = vectors[j]
+		}
+	}
+	if k > len(sample) {
+		k = len(sample)
+	}
+
+	dimension := len(sample[0])
+	centroids := make([]centroid, k)
+	for i, j := range rng.Perm(len(sample))[:k] {
+		vec := make([]float32, dimension)
+		copy(vec, sample[j])
+		centroids[i] = centroid{id: i, vector: vec}
+	}
+
+	assignments := make([]int, len(sample))
+	for iter := 0; iter < iterations; iter++ {
+		changed := false
+		for i, vec := range sample {
+			nearest := nearestCentroid(vec, centroids)
+			if nearest != assignments[i] || iter == 0 {
+				changed = true
+			}
+			assignments[i] = nearest
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float32, k)
+		counts := make([]int, k)
+		for i := range sums {
+			sums[i] = make([]float32, dimension)
+		}
+		for i, vec := range sample {
+			c := assignments[i]
+			counts[c]++
+			for d, x := range vec {
+				sums[c][d] += x
+			}
+		}
+
+		for c := range centroids {
+			if counts[c] == 0 {
+				// Re-seed empty clusters with a random sample point
+				copy(centroids[c].vector, sample[rng.Intn(len(sample))])
+				continue
+			}
+			copy(centroids[c].vector, sums[c])
+			normalize(centroids[c].vector)
+		}
+	}
+
+	return centroids
+}
+
+// nearestCentroid returns the id of the centroid with the highest dot product
+func nearestCentroid(vec []float32, centroids []centroid) int {
+	best := 0
+	bestScore := float32(math.Inf(-1))
+	for _, c := range centroids {
+		if score := dot(vec, c.vector); score > bestScore {
+			bestScore = score
+			best = c.id
+		}
+	}
+	return best
+}
+
+// nearestCentroids returns the ids of the n centroids closest to vec
+func nearestCentroids(vec []float32, centroids []centroid, n int) []int {
+	type scored struct {
+		id    int
+		score float32
+	}
+
+	scores := make([]scored, len(centroids))
+	for i, c := range centroids {
+		scores[i] = scored{id: c.id, score: dot(vec, c.vector)}
+	}
+
+	// Partial selection sort is enough for small n
+	if n > len(scores) {
+		n = len(scores)
+	}
+	ids := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		best := i
+		for j := i + 1; j < len(scores); j++ {
+			if scores[j].score > scores[best].score {
+				best = j
+			}
+		}
+		scores[i], scores[best] = scores[best], scores[i]
+		ids = append(ids, scores[i].id)
+	}
+	return ids
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func normalize(vec []float32) {
+	var norm float32
+	for _, x := range vec {
+		norm += x * x
+	}
+	if norm == 0 {
+		return
+	}
+	inv := 1 / float32(math.Sqrt(float64(norm)))
+	for i := range vec {
+		vec[i] *= inv
+	}
+}
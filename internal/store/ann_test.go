@@ -0,0 +1,182 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// newTestVectorStore opens a temporary database with n clustered vectors
+func newTestVectorStore(t *testing.T, n, dim int) (*DB, *VectorStore, [][]float32) {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rng := rand.New(rand.NewSource(1))
+
+	// Vectors are drawn around a handful of topics, like real embeddings
+	topics := make([][]float32, 32)
+	for i := range topics {
+		topics[i] = randomVector(rng, dim)
+	}
+
+	symbols := make([]*Symbol, n)
+	ids := make([]string, n)
+	vectors := make([][]float32, n)
+	for i := 0; i < n; i++ {
+		ids[i] = fmt.Sprintf("pkg%d:func:F%d", i%10, i)
+		symbols[i] = &Symbol{
+			ID:          ids[i],
+			RepoPath:    "/repo",
+			Kind:        KindFunc,
+			PackagePath: fmt.Sprintf("pkg%d", i%10),
+			PackageName: fmt.Sprintf("pkg%d", i%10),
+			Name:        fmt.Sprintf("F%d", i),
+		}
+
+		topic := topics[rng.Intn(len(topics))]
+		vec := randomVector(rng, dim)
+		for d := range vec {
+			vec[d] = topic[d] + 0.5*vec[d]
+		}
+		vectors[i] = vec
+	}
+
+	if err := NewSymbolStore(db).CreateBatch(symbols); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	vs := NewVectorStore(db)
+	if err := vs.InsertBatch(ids, vectors, "test"); err != nil {
+		t.Fatalf("failed to insert vectors: %v", err)
+	}
+
+	return db, vs, vectors
+}
+
+func randomVector(rng *rand.Rand, dim int) []float32 {
+	vec := make([]float32, dim)
+	for i := range vec {
+		vec[i] = float32(rng.NormFloat64())
+	}
+	return vec
+}
+
+func TestVectorStore_ANNRecall(t *testing.T) {
+	const (
+		n       = 4000
+		dim     = 32
+		topK    = 10
+		queries = 50
+	)
+
+	_, vs, _ := newTestVectorStore(t, n, dim)
+
+	rebuilt, err := vs.EnsureIndex()
+	if err != nil {
+		t.Fatalf("EnsureIndex() error = %v", err)
+	}
+	if !rebuilt {
+		t.Fatal("expected index to be built")
+	}
+
+	stats, err := vs.IndexStats()
+	if err != nil {
+		t.Fatalf("IndexStats() error = %v", err)
+	}
+	if stats.Clusters == 0 || stats.Assigned != n || stats.Unassigned != 0 {
+		t.Fatalf("unexpected index stats: %+v", stats)
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	hits, total := 0, 0
+	for q := 0; q < queries; q++ {
+		query := randomVector(rng, dim)
+
+		exact, err := vs.SearchExact(query, topK, nil)
+		if err != nil {
+			t.Fatalf("SearchExact() error = %v", err)
+		}
+		approx, err := vs.Search(query, topK, nil)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		found := make(map[string]bool, len(approx))
+		for _, r := range approx {
+			found[r.SymbolID] = true
+		}
+		for _, r := range exact {
+			total++
+			if found[r.SymbolID] {
+				hits++
+			}
+		}
+	}
+
+	recall := float64(hits) / float64(total)
+	if recall < 0.9 {
+		t.Errorf("ANN recall@%d = %.3f, want >= 0.9", topK, recall)
+	}
+}
+
+func TestVectorStore_ANNStaysInSync(t *testing.T) {
+	_, vs, vectors := newTestVectorStore(t, 2500, 16)
+
+	if _, err := vs.EnsureIndex(); err != nil {
+		t.Fatalf("EnsureIndex() error = %v", err)
+	}
+
+	// Deleting a package cascades to its cluster assignments
+	if err := vs.DeleteByPackage("pkg3"); err != nil {
+		t.Fatalf("DeleteByPackage() error = %v", err)
+	}
+
+	stats, err := vs.IndexStats()
+	if err != nil {
+		t.Fatalf("IndexStats() error = %v", err)
+	}
+	count, _ := vs.Count()
+	if stats.Assigned != count {
+		t.Errorf("assigned = %d, want %d after delete", stats.Assigned, count)
+	}
+
+	// Re-inserted vectors are assigned to the existing centroids
+	if err := vs.InsertBatch([]string{"pkg3:func:F3"}, [][]float32{vectors[3]}, "test"); err != nil {
+		t.Fatalf("InsertBatch() error = %v", err)
+	}
+
+	stats, err = vs.IndexStats()
+	if err != nil {
+		t.Fatalf("IndexStats() error = %v", err)
+	}
+	if stats.Unassigned != 0 {
+		t.Errorf("unassigned = %d, want 0 after insert", stats.Unassigned)
+	}
+}
+
+func TestVectorStore_ExactFallbackWithoutIndex(t *testing.T) {
+	_, vs, vectors := newTestVectorStore(t, 200, 8)
+
+	// Small stores are not indexed and are searched exactly
+	rebuilt, err := vs.EnsureIndex()
+	if err != nil {
+		t.Fatalf("EnsureIndex() error = %v", err)
+	}
+	if rebuilt {
+		t.Error("expected no index for a small store")
+	}
+
+	results, err := vs.Search(vectors[5], 1, nil)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].SymbolID != "pkg5:func:F5" {
+		t.Errorf("Search() = %+v, want pkg5:func:F5 first", results)
+	}
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DreamCats/bcindex/internal/embedding"
+)
+
+// Chunk is an embedded line range of a function body
+type Chunk struct {
+	ID        string // <symbol_id>#L<line_start>-<line_end>
+	SymbolID  string // Owning function or method
+	LineStart int
+	LineEnd   int
+	Vector    []float32
+}
+
+// ChunkID returns the ID of a chunk of a symbol
+func ChunkID(symbolID string, lineStart, lineEnd int) string {
+	return fmt.Sprintf("%s#L%d-%d", symbolID, lineStart, lineEnd)
+}
+
+// ChunkResult is a chunk matching a query
+type ChunkResult struct {
+	ChunkID   string
+	SymbolID  string
+	LineStart int
+	LineEnd   int
+	Score     float32
+}
+
+// InsertChunks inserts or replaces chunk vectors in a transaction and assigns
+// them to the existing ANN centroids
+func (v *VectorStore) InsertChunks(chunks []*Chunk, model string) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	tx, err := v.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	centroids, err := loadCentroidsTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO chunks (id, symbol_id, line_start, line_end, vector, dimension, model, created_at, cluster_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for _, chunk := range chunks {
+		blob, err := vectorToBlob(chunk.Vector)
+		if err != nil {
+			return fmt.Errorf("failed to convert chunk %s to blob: %w", chunk.ID, err)
+		}
+		if _, err := stmt.Exec(chunk.ID, chunk.SymbolID, chunk.LineStart, chunk.LineEnd, blob, len(chunk.Vector), model, now, chunkCluster(chunk.Vector, centroids)); err != nil {
+			return fmt.Errorf("failed to insert chunk %s: %w", chunk.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// DeleteChunks removes the chunks of the given symbols
+func (v *VectorStore) DeleteChunks(symbolIDs []string) error {
+	if len(symbolIDs) == 0 {
+		return nil
+	}
+
+	tx, err := v.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range symbolIDs {
+		if _, err := tx.Exec("DELETE FROM chunks WHERE symbol_id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete chunks of %s: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// SearchChunks returns the topK chunks most similar to the query whose owning
+// symbol matches the filters. When an ANN index of the query dimension exists
+// only the chunks of the nearest clusters are scored; otherwise, or when the
+// probed clusters hold too few matches, chunks are scanned exactly.
+func (v *VectorStore) SearchChunks(queryVector []float32, topK int, filters SearchFilters) ([]ChunkResult, error) {
+	if len(queryVector) == 0 {
+		return nil, fmt.Errorf("query vector is empty")
+	}
+	if topK <= 0 {
+		return nil, nil
+	}
+
+	where, args := filters.whereClause("s")
+
+	centroids, err := v.loadCentroids(len(queryVector))
+	if err != nil {
+		return nil, err
+	}
+	if len(centroids) > 0 {
+		clusterIDs := nearestCentroids(queryVector, centroids, v.probeCount(len(centroids)))
+		placeholders, clusterValues := clusterArgs(clusterIDs)
+
+		results, err := v.scoreChunks(queryVector, topK,
+			"c.cluster_id IN ("+placeholders+") AND "+where, append(clusterValues, args...))
+		if err != nil {
+			return nil, err
+		}
+		if len(results) >= topK {
+			return results, nil
+		}
+	}
+
+	return v.scoreChunks(queryVector, topK, where, args)
+}
+
+// scoreChunks scores the chunks matching a condition (chunks aliased as c,
+// owning symbols as s) and keeps the topK best in a bounded heap
+func (v *VectorStore) scoreChunks(queryVector []float32, topK int, where string, args []interface{}) ([]ChunkResult, error) {
+	query := `
+		SELECT c.id, c.symbol_id, c.line_start, c.line_end, c.vector
+		FROM chunks c
+		JOIN symbols s ON s.id = c.symbol_id
+		WHERE ` + where
+
+	rows, err := v.db.sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chunks: %w", err)
+	}
+	defer rows.Close()
+
+	best := make(chunkHeap, 0, topK)
+	for rows.Next() {
+		var result ChunkResult
+		var blob []byte
+		if err := rows.Scan(&result.ChunkID, &result.SymbolID, &result.LineStart, &result.LineEnd, &blob); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+
+		vector, err := blobToVector(blob)
+		if err != nil || len(vector) != len(queryVector) {
+			continue // Skip malformed vectors and dimension mismatches
+		}
+		result.Score = embedding.Similarity(queryVector, vector)
+
+		if len(best) < topK {
+			heap.Push(&best, result)
+		} else if result.Score > best[0].Score {
+			best[0] = result
+			heap.Fix(&best, 0)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+
+	results := []ChunkResult(best)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results, nil
+}
+
+// chunkHeap is a min-heap of chunk results ordered by score
+type chunkHeap []ChunkResult
+
+func (h chunkHeap) Len() int            { return len(h) }
+func (h chunkHeap) Less(i, j int) bool  { return h[i].Score < h[j].Score }
+func (h chunkHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x interface{}) { *h = append(*h, x.(ChunkResult)) }
+
+func (h *chunkHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// CountChunks returns the number of stored chunks
+func (v *VectorStore) CountChunks() (int, error) {
+	var count int
+	if err := v.db.sqlDB.QueryRow("SELECT COUNT(*) FROM chunks").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count chunks: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVectorStore_Chunks(t *testing.T) {
+	db, vs, vectors := newTestVectorStore(t, 10, 4)
+
+	chunks := []*Chunk{
+		{ID: ChunkID("pkg1:func:F1", 3, 10), SymbolID: "pkg1:func:F1", LineStart: 3, LineEnd: 10, Vector: vectors[5]},
+		{ID: ChunkID("pkg1:func:F1", 8, 15), SymbolID: "pkg1:func:F1", LineStart: 8, LineEnd: 15, Vector: vectors[6]},
+		{ID: ChunkID("pkg2:func:F2", 1, 5), SymbolID: "pkg2:func:F2", LineStart: 1, LineEnd: 5, Vector: vectors[7]},
+	}
+	if err := vs.InsertChunks(chunks, "test"); err != nil {
+		t.Fatalf("InsertChunks() error = %v", err)
+	}
+
+	results, err := vs.SearchChunks(vectors[6], 2, SearchFilters{})
+	if err != nil {
+		t.Fatalf("SearchChunks() error = %v", err)
+	}
+	if len(results) != 2 || results[0].ChunkID != "pkg1:func:F1#L8-15" || results[0].LineStart != 8 || results[0].Score < 0.99 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	// Filters apply to the owning symbol
+	results, err = vs.SearchChunks(vectors[6], 10, SearchFilters{PackagePath: "pkg2"})
+	if err != nil {
+		t.Fatalf("SearchChunks() error = %v", err)
+	}
+	if len(results) != 1 || results[0].SymbolID != "pkg2:func:F2" {
+		t.Errorf("unexpected filtered results: %+v", results)
+	}
+
+	// Chunks are removed with their symbol
+	if err := NewSymbolStore(db).DeleteByPackage("pkg1"); err != nil {
+		t.Fatalf("DeleteByPackage() error = %v", err)
+	}
+	if count, _ := vs.CountChunks(); count != 1 {
+		t.Errorf("CountChunks() = %d after deleting pkg1, want 1", count)
+	}
+
+	if err := vs.DeleteChunks([]string{"pkg2:func:F2"}); err != nil {
+		t.Fatalf("DeleteChunks() error = %v", err)
+	}
+	if count, _ := vs.CountChunks(); count != 0 {
+		t.Errorf("CountChunks() = %d, want 0", count)
+	}
+}
+
+func TestVectorStore_ChunksUseANNIndex(t *testing.T) {
+	const n = 2500
+	db, vs, vectors := newTestVectorStore(t, n, 16)
+
+	chunksOf := func(from, to int) []*Chunk {
+		var chunks []*Chunk
+		for i := from; i < to; i++ {
+			id := fmt.Sprintf("pkg%d:func:F%d", i%10, i)
+			chunks = append(chunks, &Chunk{ID: ChunkID(id, 1, 5), SymbolID: id, LineStart: 1, LineEnd: 5, Vector: vectors[i]})
+		}
+		return chunks
+	}
+
+	// Chunks stored before the index is built are assigned by BuildIndex,
+	// later ones on insert
+	if err := vs.InsertChunks(chunksOf(0, n/2), "test"); err != nil {
+		t.Fatalf("InsertChunks() error = %v", err)
+	}
+	if err := vs.BuildIndex(); err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+	if err := vs.InsertChunks(chunksOf(n/2, n), "test"); err != nil {
+		t.Fatalf("InsertChunks() error = %v", err)
+	}
+
+	var unassigned int
+	if err := db.sqlDB.QueryRow("SELECT COUNT(*) FROM chunks WHERE cluster_id IS NULL").Scan(&unassigned); err != nil {
+		t.Fatalf("failed to count unassigned chunks: %v", err)
+	}
+	if unassigned != 0 {
+		t.Errorf("unassigned chunks = %d, want 0", unassigned)
+	}
+
+	for _, i := range []int{7, 1800} {
+		results, err := vs.SearchChunks(vectors[i], 5, SearchFilters{})
+		if err != nil {
+			t.Fatalf("SearchChunks() error = %v", err)
+		}
+		want := fmt.Sprintf("pkg%d:func:F%d", i%10, i)
+		if len(results) != 5 || results[0].SymbolID != want || results[0].Score < 0.99 {
+			t.Errorf("SearchChunks(%d) = %+v, want %s first", i, results, want)
+		}
+	}
+
+	// Dropping the index clears the assignments and falls back to exact scan
+	if err := vs.DropIndex(); err != nil {
+		t.Fatalf("DropIndex() error = %v", err)
+	}
+	if err := db.sqlDB.QueryRow("SELECT COUNT(*) FROM chunks WHERE cluster_id IS NULL").Scan(&unassigned); err != nil {
+		t.Fatalf("failed to count unassigned chunks: %v", err)
+	}
+	if unassigned != n {
+		t.Errorf("unassigned chunks = %d after DropIndex, want %d", unassigned, n)
+	}
+	results, err := vs.SearchChunks(vectors[7], 3, SearchFilters{})
+	if err != nil || len(results) != 3 || results[0].SymbolID != "pkg7:func:F7" {
+		t.Errorf("SearchChunks() without index = %+v, %v", results, err)
+	}
+}
@@ -2,21 +2,17 @@ package store
 
 import (
 	"database/sql"
-	"embed"
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	_ "modernc.org/sqlite"
 )
 
-//go:embed schema.sql
-var schemaFS embed.FS
-
 const (
-	// CurrentSchemaVersion is the version of the database schema
-	CurrentSchemaVersion = 1
+	// CurrentSchemaVersion is the version of the database schema.
+	// It must equal the highest migration in migrations/.
+	CurrentSchemaVersion = 15
 )
 
 // DB manages the SQLite database connection and schema migrations
@@ -25,8 +21,29 @@ type DB struct {
 	path  string
 }
 
-// Open opens or creates a database at the given path
+// Open opens or creates a database at the given path and applies any
+// pending schema migrations
 func Open(path string) (*DB, error) {
+	db, err := OpenNoMigrate(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		if IsSchemaTooNew(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return db, nil
+}
+
+// OpenNoMigrate opens or creates a database without applying migrations.
+// Use it to inspect pending migrations (e.g. for a dry run).
+func OpenNoMigrate(path string) (*DB, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -50,12 +67,6 @@ func Open(path string) (*DB, error) {
 		path:  path,
 	}
 
-	// Run migrations
-	if err := db.migrate(); err != nil {
-		sqlDB.Close()
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
-	}
-
 	return db, nil
 }
 
@@ -69,61 +80,6 @@ func (db *DB) SQLDB() *sql.DB {
 	return db.sqlDB
 }
 
-// migrate runs schema migrations
-func (db *DB) migrate() error {
-	// Get current schema version
-	version, err := db.getSchemaVersion()
-	if err != nil {
-		return fmt.Errorf("failed to get schema version: %w", err)
-	}
-
-	// If already at current version, we're done
-	if version >= CurrentSchemaVersion {
-		return nil
-	}
-
-	// Begin transaction
-	tx, err := db.sqlDB.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	// For now, we just apply the full schema for fresh installs
-	// In the future, we'd have incremental migrations
-	if version == 0 {
-		// Fresh install - apply full schema
-		schema, err := schemaFS.ReadFile("schema.sql")
-		if err != nil {
-			return fmt.Errorf("failed to read schema: %w", err)
-		}
-
-		if _, err := tx.Exec(string(schema)); err != nil {
-			return fmt.Errorf("failed to apply schema: %w", err)
-		}
-
-		// Set schema version
-		if _, err := tx.Exec(
-			"INSERT INTO schema_version (version, applied_at) VALUES (?, ?)",
-			CurrentSchemaVersion,
-			time.Now().UTC().Format(time.RFC3339),
-		); err != nil {
-			return fmt.Errorf("failed to set schema version: %w", err)
-		}
-	} else {
-		// TODO: Implement incremental migrations
-		// For now, we'd need to handle version 0 -> 1, etc.
-		return fmt.Errorf("incremental migrations not yet implemented (current version: %d, target: %d)", version, CurrentSchemaVersion)
-	}
-
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit migration: %w", err)
-	}
-
-	return nil
-}
-
 // getSchemaVersion returns the current schema version
 func (db *DB) getSchemaVersion() (int, error) {
 	var version int
@@ -159,17 +115,21 @@ func (db *DB) Clear() error {
 	}
 	defer tx.Rollback()
 
-	// Clear all tables (preserve schema)
+	// Clear all tables (preserve schema, migration history and the embedding cache)
 	tables := []string{
 		"indexing_jobs",
 		"repositories",
+		"ann_assignments",
+		"ann_centroids",
+		"ann_meta",
+		"chunks",
 		"embeddings",
+		"files",
 		"packages_fts",
 		"packages",
+		"edge_sites",
 		"edges",
-		"symbols_fts",
-		"symbols",
-		"schema_version",
+		"symbols", // symbols_fts follows through its triggers
 	}
 
 	for _, table := range tables {
@@ -179,15 +139,6 @@ func (db *DB) Clear() error {
 		}
 	}
 
-	// Reset schema version
-	if _, err := tx.Exec(
-		"INSERT INTO schema_version (version, applied_at) VALUES (?, ?)",
-		CurrentSchemaVersion,
-		time.Now().UTC().Format(time.RFC3339),
-	); err != nil {
-		return fmt.Errorf("failed to reset schema version: %w", err)
-	}
-
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit clear: %w", err)
 	}
@@ -18,29 +18,11 @@ func NewEdgeStore(db *DB) *EdgeStore {
 
 // Create inserts a new edge
 func (e *EdgeStore) Create(edge *Edge) error {
-	now := time.Now().UTC()
-	edge.CreatedAt = now
-
-	query := `
-		INSERT INTO edges (from_id, to_id, edge_type, weight, import_path, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(from_id, to_id, edge_type) DO UPDATE SET
-			weight = MAX(edges.weight, excluded.weight),
-			import_path = COALESCE(edges.import_path, excluded.import_path)
-	`
-
-	_, err := e.db.sqlDB.Exec(query,
-		edge.FromID, edge.ToID, edge.EdgeType, edge.Weight, edge.ImportPath, edge.CreatedAt,
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to insert edge: %w", err)
-	}
-
-	return nil
+	return e.CreateBatch([]*Edge{edge})
 }
 
-// CreateBatch inserts multiple edges in a transaction
+// CreateBatch inserts multiple edges in a transaction. Edges already stored
+// are merged, and their sites added to the ones recorded before.
 func (e *EdgeStore) CreateBatch(edges []*Edge) error {
 	if len(edges) == 0 {
 		return nil
@@ -53,11 +35,13 @@ func (e *EdgeStore) CreateBatch(edges []*Edge) error {
 	defer tx.Rollback()
 
 	query := `
-		INSERT INTO edges (from_id, to_id, edge_type, weight, import_path, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO edges (from_id, to_id, edge_type, weight, import_path, provenance, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(from_id, to_id, edge_type) DO UPDATE SET
 			weight = MAX(edges.weight, excluded.weight),
-			import_path = COALESCE(edges.import_path, excluded.import_path)
+			import_path = COALESCE(edges.import_path, excluded.import_path),
+			provenance = CASE WHEN excluded.provenance = 'static' THEN 'static' ELSE edges.provenance END
+		RETURNING id
 	`
 
 	stmt, err := tx.Prepare(query)
@@ -66,18 +50,45 @@ func (e *EdgeStore) CreateBatch(edges []*Edge) error {
 	}
 	defer stmt.Close()
 
+	siteStmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO edge_sites (edge_id, file_path, line, col)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer siteStmt.Close()
+
 	now := time.Now().UTC()
+	withSites := make(map[int64]bool)
 
 	for _, edge := range edges {
 		edge.CreatedAt = now
 
-		_, err := stmt.Exec(
-			edge.FromID, edge.ToID, edge.EdgeType, edge.Weight, edge.ImportPath, edge.CreatedAt,
-		)
+		err := stmt.QueryRow(
+			edge.FromID, edge.ToID, edge.EdgeType, edge.Weight, edge.ImportPath, edgeProvenance(edge), edge.CreatedAt,
+		).Scan(&edge.ID)
 
 		if err != nil {
 			return fmt.Errorf("failed to insert edge (%s -> %s): %w", edge.FromID, edge.ToID, err)
 		}
+
+		for _, site := range edge.Sites {
+			if _, err := siteStmt.Exec(edge.ID, site.FilePath, site.Line, site.Column); err != nil {
+				return fmt.Errorf("failed to insert edge site (%s -> %s): %w", edge.FromID, edge.ToID, err)
+			}
+			withSites[edge.ID] = true
+		}
+	}
+
+	for id := range withSites {
+		_, err := tx.Exec(`
+			UPDATE edges SET site_count = (SELECT COUNT(*) FROM edge_sites WHERE edge_id = ?)
+			WHERE id = ?
+		`, id, id)
+		if err != nil {
+			return fmt.Errorf("failed to update edge site count: %w", err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -87,10 +98,33 @@ func (e *EdgeStore) CreateBatch(edges []*Edge) error {
 	return nil
 }
 
+// GetSites returns the sites of an edge ordered by position
+func (e *EdgeStore) GetSites(edgeID int64) ([]EdgeSite, error) {
+	rows, err := e.db.sqlDB.Query(`
+		SELECT file_path, line, col FROM edge_sites
+		WHERE edge_id = ?
+		ORDER BY file_path, line, col
+	`, edgeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query edge sites: %w", err)
+	}
+	defer rows.Close()
+
+	var sites []EdgeSite
+	for rows.Next() {
+		var site EdgeSite
+		if err := rows.Scan(&site.FilePath, &site.Line, &site.Column); err != nil {
+			return nil, fmt.Errorf("failed to scan edge site: %w", err)
+		}
+		sites = append(sites, site)
+	}
+	return sites, rows.Err()
+}
+
 // GetOutgoing returns all edges from a symbol
 func (e *EdgeStore) GetOutgoing(fromID string, edgeType string) ([]*Edge, error) {
 	query := `
-		SELECT id, from_id, to_id, edge_type, weight, import_path, created_at
+		SELECT id, from_id, to_id, edge_type, weight, import_path, provenance, site_count, created_at
 		FROM edges WHERE from_id = ?
 	`
 
@@ -121,7 +155,7 @@ func (e *EdgeStore) GetOutgoing(fromID string, edgeType string) ([]*Edge, error)
 // GetIncoming returns all edges to a symbol
 func (e *EdgeStore) GetIncoming(toID string, edgeType string) ([]*Edge, error) {
 	query := `
-		SELECT id, from_id, to_id, edge_type, weight, import_path, created_at
+		SELECT id, from_id, to_id, edge_type, weight, import_path, provenance, site_count, created_at
 		FROM edges WHERE to_id = ?
 	`
 
@@ -149,6 +183,40 @@ func (e *EdgeStore) GetIncoming(toID string, edgeType string) ([]*Edge, error) {
 	return edges, nil
 }
 
+// GetByPackage returns the edges from or to the symbols of a package,
+// optionally of one type
+func (e *EdgeStore) GetByPackage(pkgPath string, edgeType string) ([]*Edge, error) {
+	query := `
+		SELECT id, from_id, to_id, edge_type, weight, import_path, provenance, site_count, created_at
+		FROM edges
+		WHERE (from_id IN (SELECT id FROM symbols WHERE package_path = ?)
+		OR to_id IN (SELECT id FROM symbols WHERE package_path = ?))
+	`
+
+	args := []interface{}{pkgPath, pkgPath}
+	if edgeType != "" {
+		query += " AND edge_type = ?"
+		args = append(args, edgeType)
+	}
+
+	rows, err := e.db.sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query edges by package: %w", err)
+	}
+	defer rows.Close()
+
+	var edges []*Edge
+	for rows.Next() {
+		edge, err := e.scanEdgeRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+
+	return edges, rows.Err()
+}
+
 // DeleteBySymbol removes all edges related to a symbol
 func (e *EdgeStore) DeleteBySymbol(symbolID string) error {
 	_, err := e.db.sqlDB.Exec("DELETE FROM edges WHERE from_id = ? OR to_id = ?", symbolID, symbolID)
@@ -253,16 +321,23 @@ func (e *EdgeStore) GetConnectedComponents(symbolID string, maxDepth int) (map[s
 	return adjacency, nil
 }
 
+// edgeProvenance defaults the provenance of an edge to static
+func edgeProvenance(edge *Edge) string {
+	if edge.Provenance == "" {
+		return ProvenanceStatic
+	}
+	return edge.Provenance
+}
+
 // scanEdgeRow scans a row into an Edge
 func (e *EdgeStore) scanEdgeRow(scanner rowScanner) (*Edge, error) {
 	edge := &Edge{}
-	var id int64
 	var importPath sql.NullString
 	var createdAtValue any
 
 	err := scanner.Scan(
-		&id, &edge.FromID, &edge.ToID, &edge.EdgeType,
-		&edge.Weight, &importPath, &createdAtValue,
+		&edge.ID, &edge.FromID, &edge.ToID, &edge.EdgeType,
+		&edge.Weight, &importPath, &edge.Provenance, &edge.Count, &createdAtValue,
 	)
 
 	if err != nil {
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestEdgeStore_Sites(t *testing.T) {
+	db, _, _ := newTestVectorStore(t, 10, 4)
+	edgeStore := NewEdgeStore(db)
+
+	site := func(line int) []EdgeSite {
+		return []EdgeSite{{FilePath: "a.go", Line: line, Column: 2}}
+	}
+	edges := []*Edge{
+		{FromID: "pkg1:func:F1", ToID: "pkg2:func:F2", EdgeType: EdgeTypeCalls, Weight: 5, Sites: site(10)},
+		{FromID: "pkg1:func:F1", ToID: "pkg2:func:F2", EdgeType: EdgeTypeCalls, Weight: 5, Sites: site(12)},
+		// Sites already recorded are not counted twice
+		{FromID: "pkg1:func:F1", ToID: "pkg2:func:F2", EdgeType: EdgeTypeCalls, Weight: 5, Sites: site(10)},
+		{FromID: "pkg3:func:F3", ToID: "pkg2:func:F2", EdgeType: EdgeTypeImplements, Weight: 10},
+	}
+	if err := edgeStore.CreateBatch(edges); err != nil {
+		t.Fatalf("CreateBatch() error = %v", err)
+	}
+
+	incoming, err := edgeStore.GetIncoming("pkg2:func:F2", "")
+	if err != nil {
+		t.Fatalf("GetIncoming() error = %v", err)
+	}
+	if len(incoming) != 2 {
+		t.Fatalf("GetIncoming() returned %d edges, want 2", len(incoming))
+	}
+
+	for _, edge := range incoming {
+		sites, err := edgeStore.GetSites(edge.ID)
+		if err != nil {
+			t.Fatalf("GetSites() error = %v", err)
+		}
+
+		switch edge.EdgeType {
+		case EdgeTypeCalls:
+			if edge.Count != 2 || len(sites) != 2 {
+				t.Errorf("calls edge: count = %d with %d sites, want 2", edge.Count, len(sites))
+			}
+			if len(sites) > 0 && (sites[0] != EdgeSite{FilePath: "a.go", Line: 10, Column: 2}) {
+				t.Errorf("first site = %+v", sites[0])
+			}
+		case EdgeTypeImplements:
+			if edge.Count != 1 || len(sites) != 0 {
+				t.Errorf("implements edge: count = %d with %d sites, want 1 without sites", edge.Count, len(sites))
+			}
+		}
+	}
+
+	// Deleting an edge drops its sites
+	if err := edgeStore.DeleteBySymbol("pkg1:func:F1"); err != nil {
+		t.Fatalf("DeleteBySymbol() error = %v", err)
+	}
+	var sites int
+	if err := db.sqlDB.QueryRow("SELECT COUNT(*) FROM edge_sites").Scan(&sites); err != nil {
+		t.Fatalf("failed to count sites: %v", err)
+	}
+	if sites != 0 {
+		t.Errorf("%d sites left after deleting their edge", sites)
+	}
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// embeddingCacheChunk bounds the number of hashes per lookup query
+const embeddingCacheChunk = 500
+
+// EmbeddingCache implements embedding.Cache on top of the SQLite store
+type EmbeddingCache struct {
+	db *DB
+}
+
+// EmbeddingCacheStats summarizes the embedding cache
+type EmbeddingCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// HitRatio returns the fraction of lookups served from the cache
+func (s EmbeddingCacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewEmbeddingCache creates a new embedding cache
+func NewEmbeddingCache(db *DB) *EmbeddingCache {
+	return &EmbeddingCache{db: db}
+}
+
+// GetBatch returns the cached vectors for the given text hashes and records
+// the hits and misses
+func (c *EmbeddingCache) GetBatch(model string, dimensions int, hashes []string) (map[string][]float32, error) {
+	found := make(map[string][]float32, len(hashes))
+	if len(hashes) == 0 {
+		return found, nil
+	}
+
+	for start := 0; start < len(hashes); start += embeddingCacheChunk {
+		end := start + embeddingCacheChunk
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		chunk := hashes[start:end]
+
+		args := make([]interface{}, 0, len(chunk)+2)
+		args = append(args, model, dimensions)
+		for _, hash := range chunk {
+			args = append(args, hash)
+		}
+
+		query := fmt.Sprintf(`
+			SELECT text_hash, vector FROM embedding_cache
+			WHERE model = ? AND dimensions = ? AND text_hash IN (%s)
+		`, strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ","))
+
+		rows, err := c.db.sqlDB.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query embedding cache: %w", err)
+		}
+		for rows.Next() {
+			var hash string
+			var blob []byte
+			if err := rows.Scan(&hash, &blob); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan cached embedding: %w", err)
+			}
+			vector, err := blobToVector(blob)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to decode cached embedding: %w", err)
+			}
+			found[hash] = vector
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+		}
+	}
+
+	if err := c.recordLookups(model, dimensions, hashes, found); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// recordLookups updates the hit/miss counters and last_used_at of hits
+func (c *EmbeddingCache) recordLookups(model string, dimensions int, hashes []string, found map[string][]float32) error {
+	tx, err := c.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hits := int64(0)
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, hash := range hashes {
+		if _, ok := found[hash]; !ok {
+			continue
+		}
+		hits++
+		if _, err := tx.Exec(
+			"UPDATE embedding_cache SET last_used_at = ? WHERE model = ? AND dimensions = ? AND text_hash = ?",
+			now, model, dimensions, hash,
+		); err != nil {
+			return fmt.Errorf("failed to touch cached embedding: %w", err)
+		}
+	}
+
+	counters := map[string]int64{"hits": hits, "misses": int64(len(hashes)) - hits}
+	for name, delta := range counters {
+		if _, err := tx.Exec(`
+			INSERT INTO embedding_cache_stats (name, value) VALUES (?, ?)
+			ON CONFLICT(name) DO UPDATE SET value = value + excluded.value
+		`, name, delta); err != nil {
+			return fmt.Errorf("failed to update embedding cache stats: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// PutBatch stores vectors for the given text hashes
+func (c *EmbeddingCache) PutBatch(model string, dimensions int, hashes []string, vectors [][]float32) error {
+	if len(hashes) != len(vectors) {
+		return fmt.Errorf("hashes and vectors length mismatch")
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO embedding_cache (model, dimensions, text_hash, vector, created_at, last_used_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			continue
+		}
+		blob, err := vectorToBlob(vector)
+		if err != nil {
+			return fmt.Errorf("failed to convert vector %d to blob: %w", i, err)
+		}
+		if _, err := stmt.Exec(model, dimensions, hashes[i], blob, now, now); err != nil {
+			return fmt.Errorf("failed to cache embedding %d: %w", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the number of cached vectors and the lookup counters
+func (c *EmbeddingCache) Stats() (EmbeddingCacheStats, error) {
+	var stats EmbeddingCacheStats
+	if err := c.db.sqlDB.QueryRow("SELECT COUNT(*) FROM embedding_cache").Scan(&stats.Entries); err != nil {
+		return stats, fmt.Errorf("failed to count cached embeddings: %w", err)
+	}
+
+	rows, err := c.db.sqlDB.Query("SELECT name, value FROM embedding_cache_stats")
+	if err != nil {
+		return stats, fmt.Errorf("failed to query embedding cache stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var value int64
+		if err := rows.Scan(&name, &value); err != nil {
+			return stats, fmt.Errorf("failed to scan embedding cache stats: %w", err)
+		}
+		switch name {
+		case "hits":
+			stats.Hits = value
+		case "misses":
+			stats.Misses = value
+		}
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddingCache(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	cache := NewEmbeddingCache(db)
+	if err := cache.PutBatch("p/m", 4, []string{"a", "b"}, [][]float32{{1, 2, 3, 4}, {5, 6, 7, 8}}); err != nil {
+		t.Fatalf("PutBatch() error = %v", err)
+	}
+
+	found, err := cache.GetBatch("p/m", 4, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("GetBatch() error = %v", err)
+	}
+	if len(found) != 2 || found["b"][3] != 8 {
+		t.Errorf("GetBatch() = %v, want a and b", found)
+	}
+
+	// Other models and dimensions do not share entries
+	found, err = cache.GetBatch("p/other", 4, []string{"a"})
+	if err != nil {
+		t.Fatalf("GetBatch() error = %v", err)
+	}
+	if len(found) != 0 {
+		t.Errorf("GetBatch() for other model = %v, want none", found)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Entries != 2 || stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.HitRatio() != 0.5 {
+		t.Errorf("HitRatio() = %v, want 0.5", stats.HitRatio())
+	}
+
+	// The cache survives clearing the index
+	if err := db.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 2 {
+		t.Errorf("cache has %d entries after Clear(), want 2", stats.Entries)
+	}
+}
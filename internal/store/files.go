@@ -0,0 +1,103 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FileStore tracks content hashes of indexed source files
+type FileStore struct {
+	db *DB
+}
+
+// NewFileStore creates a new file store
+func NewFileStore(db *DB) *FileStore {
+	return &FileStore{db: db}
+}
+
+// ListByRepo returns the recorded file hashes of a repository keyed by file path
+func (f *FileStore) ListByRepo(repoPath string) (map[string]*FileHash, error) {
+	query := `
+		SELECT repo_path, file_path, package_path, content_hash, size, indexed_at
+		FROM files
+		WHERE repo_path = ?
+	`
+
+	rows, err := f.db.sqlDB.Query(query, repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query files: %w", err)
+	}
+	defer rows.Close()
+
+	files := make(map[string]*FileHash)
+	for rows.Next() {
+		var file FileHash
+		var pkgPath sql.NullString
+		var indexedAtValue any
+		if err := rows.Scan(&file.RepoPath, &file.FilePath, &pkgPath, &file.ContentHash, &file.Size, &indexedAtValue); err != nil {
+			return nil, fmt.Errorf("failed to scan file: %w", err)
+		}
+		file.PackagePath = pkgPath.String
+
+		indexedAt, err := parseTimeValue(indexedAtValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse indexed_at: %w", err)
+		}
+		file.IndexedAt = indexedAt
+
+		files[file.FilePath] = &file
+	}
+
+	return files, rows.Err()
+}
+
+// ReplaceByRepo replaces all file hashes of a repository in one transaction
+func (f *FileStore) ReplaceByRepo(repoPath string, files []*FileHash) error {
+	tx, err := f.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM files WHERE repo_path = ?", repoPath); err != nil {
+		return fmt.Errorf("failed to clear files: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO files (repo_path, file_path, package_path, content_hash, size, indexed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, file := range files {
+		indexedAt := file.IndexedAt
+		if indexedAt.IsZero() {
+			indexedAt = now
+		}
+		if _, err := stmt.Exec(
+			repoPath, file.FilePath, file.PackagePath, file.ContentHash, file.Size,
+			indexedAt.UTC().Format(time.RFC3339Nano),
+		); err != nil {
+			return fmt.Errorf("failed to insert file %s: %w", file.FilePath, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByRepo removes all file hashes of a repository
+func (f *FileStore) DeleteByRepo(repoPath string) error {
+	if _, err := f.db.sqlDB.Exec("DELETE FROM files WHERE repo_path = ?", repoPath); err != nil {
+		return fmt.Errorf("failed to delete files by repo: %w", err)
+	}
+	return nil
+}
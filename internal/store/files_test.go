@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestFileStore_ReplaceByRepo(t *testing.T) {
+	db, _, _ := newTestVectorStore(t, 10, 4)
+	fileStore := NewFileStore(db)
+
+	first := []*FileHash{
+		{FilePath: "a.go", PackagePath: "pkg0", ContentHash: "h1", Size: 10},
+		{FilePath: "b.go", PackagePath: "pkg1", ContentHash: "h2", Size: 20},
+	}
+	if err := fileStore.ReplaceByRepo("/repo", first); err != nil {
+		t.Fatalf("ReplaceByRepo() error = %v", err)
+	}
+	if err := fileStore.ReplaceByRepo("/other", first[:1]); err != nil {
+		t.Fatalf("ReplaceByRepo() error = %v", err)
+	}
+
+	// Replacing drops files that no longer exist
+	if err := fileStore.ReplaceByRepo("/repo", []*FileHash{
+		{FilePath: "a.go", PackagePath: "pkg0", ContentHash: "h3", Size: 11},
+	}); err != nil {
+		t.Fatalf("ReplaceByRepo() error = %v", err)
+	}
+
+	files, err := fileStore.ListByRepo("/repo")
+	if err != nil {
+		t.Fatalf("ListByRepo() error = %v", err)
+	}
+	if len(files) != 1 || files["a.go"] == nil {
+		t.Fatalf("ListByRepo() = %v, want only a.go", files)
+	}
+	if got := files["a.go"]; got.ContentHash != "h3" || got.PackagePath != "pkg0" || got.IndexedAt.IsZero() {
+		t.Errorf("unexpected file record: %+v", got)
+	}
+
+	other, err := fileStore.ListByRepo("/other")
+	if err != nil {
+		t.Fatalf("ListByRepo() error = %v", err)
+	}
+	if len(other) != 1 {
+		t.Errorf("other repository has %d files, want 1", len(other))
+	}
+}
+
+func TestVectorStore_GetByPackageKeepsContentHash(t *testing.T) {
+	_, vs, vectors := newTestVectorStore(t, 20, 4)
+
+	ids := []string{"pkg3:func:F3", "pkg3:func:F13"}
+	if err := vs.InsertBatchWithHashes(ids, [][]float32{vectors[3], vectors[13]}, []string{"abc", ""}, "model-a"); err != nil {
+		t.Fatalf("InsertBatchWithHashes() error = %v", err)
+	}
+
+	stored, err := vs.GetByPackage("pkg3")
+	if err != nil {
+		t.Fatalf("GetByPackage() error = %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("GetByPackage() returned %d vectors, want 2", len(stored))
+	}
+	if got := stored["pkg3:func:F3"]; got.ContentHash != "abc" || got.Model != "model-a" || len(got.Vector) != 4 {
+		t.Errorf("unexpected stored vector: %+v", got)
+	}
+	if got := stored["pkg3:func:F13"]; got.ContentHash != "" {
+		t.Errorf("ContentHash = %q, want empty", got.ContentHash)
+	}
+}
@@ -0,0 +1,455 @@
+package store
+
+import (
+	"path"
+	"strings"
+)
+
+// SearchFilters restricts candidate selection for vector and keyword search
+type SearchFilters struct {
+	RepoPath     string   // Only symbols of this repository
+	Kinds        []string // Filter by symbol kinds
+	ExportedOnly bool     // Only exported symbols
+	PackagePath  string   // Package path prefix (see PackagePathMatches)
+
+	// Layers keeps symbols whose package path is in any of these
+	// architectural layers (see PathLayer)
+	Layers []string
+
+	// ExcludeTests skips the test layer (see IsTestSymbol)
+	ExcludeTests bool
+
+	// BuildContexts keeps symbols compiled in any of these build contexts
+	BuildContexts []string
+
+	ExcludeKinds    []string // Skip symbols of these kinds
+	ExcludePackages []string // Skip these packages and their sub-packages
+
+	// FilePatterns keeps symbols whose file matches any of these patterns
+	// (see FilePathMatches); ExcludeFilePatterns skips matching files
+	FilePatterns        []string
+	ExcludeFilePatterns []string
+
+	// Receiver keeps methods of this receiver type; "*T" requires a pointer
+	// receiver, "T" matches both
+	Receiver string
+
+	// SymbolIDs keeps only these symbols (resolved graph constraints)
+	SymbolIDs []string
+}
+
+// IsEmpty reports whether no filter is set
+func (f SearchFilters) IsEmpty() bool {
+	return f.RepoPath == "" && len(f.Kinds) == 0 && !f.ExportedOnly &&
+		f.PackagePath == "" && len(f.Layers) == 0 && !f.ExcludeTests &&
+		len(f.BuildContexts) == 0 && len(f.ExcludeKinds) == 0 && len(f.ExcludePackages) == 0 &&
+		len(f.FilePatterns) == 0 && len(f.ExcludeFilePatterns) == 0 && f.Receiver == "" &&
+		len(f.SymbolIDs) == 0
+}
+
+// Matches reports whether a symbol passes the filters
+func (f SearchFilters) Matches(sym *Symbol) bool {
+	if sym == nil {
+		return false
+	}
+	if f.RepoPath != "" && sym.RepoPath != f.RepoPath {
+		return false
+	}
+	if f.ExportedOnly && !sym.Exported {
+		return false
+	}
+	if f.ExcludeTests && IsTestSymbol(sym) {
+		return false
+	}
+	if len(f.Kinds) > 0 {
+		kindMatch := false
+		for _, kind := range f.Kinds {
+			if sym.Kind == kind {
+				kindMatch = true
+				break
+			}
+		}
+		if !kindMatch {
+			return false
+		}
+	}
+	if f.PackagePath != "" && !PackagePathMatches(sym.PackagePath, f.PackagePath) {
+		return false
+	}
+	if len(f.Layers) > 0 {
+		layer := PathLayer(sym.PackagePath)
+		found := false
+		for _, l := range f.Layers {
+			if layer == l {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.BuildContexts) > 0 && !InBuildContexts(sym, f.BuildContexts) {
+		return false
+	}
+	for _, kind := range f.ExcludeKinds {
+		if sym.Kind == kind {
+			return false
+		}
+	}
+	for _, pkg := range f.ExcludePackages {
+		if PackagePathMatches(sym.PackagePath, pkg) {
+			return false
+		}
+	}
+	if len(f.FilePatterns) > 0 && !anyFilePathMatches(sym.FilePath, f.FilePatterns) {
+		return false
+	}
+	if anyFilePathMatches(sym.FilePath, f.ExcludeFilePatterns) {
+		return false
+	}
+	if f.Receiver != "" && !ReceiverMatches(sym, f.Receiver) {
+		return false
+	}
+	if len(f.SymbolIDs) > 0 {
+		found := false
+		for _, id := range f.SymbolIDs {
+			if sym.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// UnknownLayer is the layer of package paths without a layer marker
+const UnknownLayer = "unknown"
+
+// pathLayers lists package path markers per architectural layer, in
+// detection order
+var pathLayers = []struct {
+	layer   string
+	markers []string
+}{
+	{"handler", []string{"/handler/", "/controller/", "/api/", "/http/"}},
+	{"service", []string{"/service/", "/usecase/", "/business/"}},
+	{"repository", []string{"/repository/", "/repo/", "/dao/", "/storage/"}},
+	{"domain", []string{"/domain/", "/entity/", "/model/"}},
+	{"middleware", []string{"/middleware/", "/filter/"}},
+	{"util", []string{"/util/", "/helper/", "/common/"}},
+}
+
+// PathLayer returns the architectural layer of a package path: the first
+// layer with a marker in the path, or UnknownLayer
+func PathLayer(pkgPath string) string {
+	path := strings.ToLower(pkgPath)
+	for _, entry := range pathLayers {
+		for _, marker := range entry.markers {
+			if strings.Contains(path, marker) {
+				return entry.layer
+			}
+		}
+	}
+	return UnknownLayer
+}
+
+// layerCondition returns the SQL condition on a package path column that
+// PathLayer classes in any of layers: a marker of the layer matches and no
+// marker of an earlier layer does. UnknownLayer matches no marker at all.
+func layerCondition(column string, layers []string) (string, []interface{}) {
+	var args []interface{}
+	anyMarker := func(markers []string) string {
+		parts := make([]string, len(markers))
+		for i, marker := range markers {
+			// LIKE is case-insensitive for ASCII in SQLite
+			parts[i] = column + " LIKE ? ESCAPE '\\'"
+			args = append(args, "%"+escapeLike(marker)+"%")
+		}
+		return "(" + strings.Join(parts, " OR ") + ")"
+	}
+
+	var alternatives []string
+	for _, layer := range layers {
+		if !isLayer(layer) {
+			continue // PathLayer never returns it
+		}
+		var conds []string
+		for _, entry := range pathLayers {
+			if entry.layer == layer {
+				conds = append(conds, anyMarker(entry.markers))
+				break
+			}
+			conds = append(conds, "NOT "+anyMarker(entry.markers))
+		}
+		alternatives = append(alternatives, "("+strings.Join(conds, " AND ")+")")
+	}
+
+	if len(alternatives) == 0 {
+		return "1 = 0", nil
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// isLayer reports whether PathLayer can return layer
+func isLayer(layer string) bool {
+	if layer == UnknownLayer {
+		return true
+	}
+	for _, entry := range pathLayers {
+		if entry.layer == layer {
+			return true
+		}
+	}
+	return false
+}
+
+// FilePathMatches reports whether a repo-relative file path matches a glob
+// pattern. A pattern without a slash matches the base name ("*_test.go"),
+// one with a slash the whole path ("internal/*/db.go"); "dir/..." matches
+// every file below dir.
+func FilePathMatches(filePath, pattern string) bool {
+	if filePath == "" || pattern == "" {
+		return false
+	}
+	if dir := strings.TrimSuffix(pattern, "/..."); dir != pattern {
+		dir = strings.Trim(strings.TrimPrefix(dir, "./"), "/")
+		return strings.HasPrefix(filePath, dir+"/")
+	}
+	if !strings.Contains(pattern, "/") {
+		filePath = path.Base(filePath)
+	}
+	matched, err := path.Match(strings.TrimPrefix(pattern, "./"), filePath)
+	return err == nil && matched
+}
+
+func anyFilePathMatches(filePath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if FilePathMatches(filePath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReceiverOf returns the receiver type name of a method signature such as
+// "func (v *VectorStore) Get(id string)", without type parameters, and
+// whether it is a pointer receiver
+func ReceiverOf(signature string) (string, bool) {
+	if !strings.HasPrefix(signature, "func (") {
+		return "", false
+	}
+	end := strings.Index(signature, ")")
+	if end < 0 {
+		return "", false
+	}
+	fields := strings.Fields(signature[len("func ("):end])
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	typ := fields[len(fields)-1]
+	pointer := strings.HasPrefix(typ, "*")
+	typ = strings.TrimPrefix(typ, "*")
+	if i := strings.Index(typ, "["); i >= 0 {
+		typ = typ[:i]
+	}
+	return typ, pointer
+}
+
+// ReceiverMatches reports whether sym is a method of the receiver type; a
+// leading "*" requires a pointer receiver
+func ReceiverMatches(sym *Symbol, receiver string) bool {
+	if sym.Kind != KindMethod {
+		return false
+	}
+	typ, pointer := ReceiverOf(sym.Signature)
+	want := strings.TrimPrefix(receiver, "*")
+	return typ == want && (pointer || want == receiver)
+}
+
+// InBuildContexts reports whether a symbol is compiled in any of contexts
+func InBuildContexts(sym *Symbol, contexts []string) bool {
+	for _, have := range sym.BuildContexts {
+		for _, want := range contexts {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Suffixes of the source files of tests and of external test packages
+const (
+	testFileSuffix    = "_test.go"
+	testPackageSuffix = "_test"
+)
+
+// IsTestSymbol reports whether a symbol belongs to the test layer: a test,
+// a declaration of a _test.go file or an external test package
+func IsTestSymbol(sym *Symbol) bool {
+	return sym.Kind == KindTest || strings.HasSuffix(sym.FilePath, testFileSuffix) ||
+		strings.HasSuffix(sym.PackageName, testPackageSuffix)
+}
+
+// PackagePathMatches reports whether pkgPath is the filter package or one of
+// its sub-packages. The filter may be a full import path
+// ("github.com/org/repo/internal/store") or a repo-relative suffix
+// ("internal/store"); a trailing "/..." is accepted and ignored.
+func PackagePathMatches(pkgPath, filter string) bool {
+	filter = normalizePackageFilter(filter)
+	if filter == "" {
+		return true
+	}
+
+	if pkgPath == filter || strings.HasPrefix(pkgPath, filter+"/") {
+		return true
+	}
+	return strings.HasSuffix(pkgPath, "/"+filter) || strings.Contains(pkgPath, "/"+filter+"/")
+}
+
+func normalizePackageFilter(filter string) string {
+	filter = strings.TrimSpace(filter)
+	filter = strings.TrimSuffix(filter, "/...")
+	filter = strings.TrimPrefix(filter, "./")
+	return strings.Trim(filter, "/")
+}
+
+// whereClause builds an SQL condition over the symbols table aliased as alias.
+// It returns "1 = 1" when no filter is set.
+func (f SearchFilters) whereClause(alias string) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	col := func(name string) string {
+		return alias + "." + name
+	}
+
+	if f.RepoPath != "" {
+		conds = append(conds, col("repo_path")+" = ?")
+		args = append(args, f.RepoPath)
+	}
+
+	if f.ExportedOnly {
+		conds = append(conds, col("exported")+" = 1")
+	}
+
+	if f.ExcludeTests {
+		conds = append(conds, col("kind")+" != ?",
+			"("+col("file_path")+" IS NULL OR "+col("file_path")+" NOT LIKE ? ESCAPE '\\')",
+			col("package_name")+" NOT LIKE ? ESCAPE '\\'")
+		args = append(args, KindTest, "%"+escapeLike(testFileSuffix), "%"+escapeLike(testPackageSuffix))
+	}
+
+	if len(f.Kinds) > 0 {
+		placeholders := make([]string, len(f.Kinds))
+		for i, kind := range f.Kinds {
+			placeholders[i] = "?"
+			args = append(args, kind)
+		}
+		conds = append(conds, col("kind")+" IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if cond, condArgs := packageCondition(col("package_path"), f.PackagePath); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+
+	if len(f.Layers) > 0 {
+		cond, condArgs := layerCondition(col("package_path"), f.Layers)
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+
+	if len(f.BuildContexts) > 0 {
+		placeholders := make([]string, len(f.BuildContexts))
+		for i, name := range f.BuildContexts {
+			placeholders[i] = "?"
+			args = append(args, name)
+		}
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each("+col("build_contexts")+") WHERE json_each.value IN ("+
+			strings.Join(placeholders, ", ")+"))")
+	}
+
+	if len(f.ExcludeKinds) > 0 {
+		placeholders := make([]string, len(f.ExcludeKinds))
+		for i, kind := range f.ExcludeKinds {
+			placeholders[i] = "?"
+			args = append(args, kind)
+		}
+		conds = append(conds, col("kind")+" NOT IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	for _, pkg := range f.ExcludePackages {
+		if cond, condArgs := packageCondition(col("package_path"), pkg); cond != "" {
+			conds = append(conds, "NOT "+cond)
+			args = append(args, condArgs...)
+		}
+	}
+
+	// GLOB selects a superset of the file patterns (its * also matches
+	// slashes); Matches applies them exactly. Excluded files are only
+	// checked by Matches.
+	if len(f.FilePatterns) > 0 {
+		var parts []string
+		for _, pattern := range f.FilePatterns {
+			pattern = strings.TrimPrefix(pattern, "./")
+			switch {
+			case strings.HasSuffix(pattern, "/..."):
+				parts = append(parts, col("file_path")+" LIKE ? ESCAPE '\\'")
+				args = append(args, escapeLike(strings.Trim(strings.TrimSuffix(pattern, "/..."), "/"))+"/%")
+			case strings.Contains(pattern, "/"):
+				parts = append(parts, col("file_path")+" GLOB ?")
+				args = append(args, pattern)
+			default:
+				parts = append(parts, col("file_path")+" GLOB ?", col("file_path")+" GLOB ?")
+				args = append(args, pattern, "*/"+pattern)
+			}
+		}
+		conds = append(conds, "("+strings.Join(parts, " OR ")+")")
+	}
+
+	if f.Receiver != "" {
+		conds = append(conds, col("kind")+" = ?", col("signature")+" LIKE ? ESCAPE '\\'")
+		args = append(args, KindMethod, "func (%"+escapeLike(strings.TrimPrefix(f.Receiver, "*"))+"%)%")
+	}
+
+	if len(f.SymbolIDs) > 0 {
+		placeholders := make([]string, len(f.SymbolIDs))
+		for i, id := range f.SymbolIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		conds = append(conds, col("id")+" IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if len(conds) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// packageCondition builds the SQL condition of PackagePathMatches on
+// column; it returns "" for an empty filter
+func packageCondition(column, filter string) (string, []interface{}) {
+	pkg := normalizePackageFilter(filter)
+	if pkg == "" {
+		return "", nil
+	}
+	escaped := escapeLike(pkg)
+	cond := "(" +
+		column + " = ? OR " +
+		column + " LIKE ? ESCAPE '\\' OR " +
+		column + " LIKE ? ESCAPE '\\' OR " +
+		column + " LIKE ? ESCAPE '\\')"
+	return cond, []interface{}{pkg, escaped + "/%", "%/" + escaped, "%/" + escaped + "/%"}
+}
+
+// escapeLike escapes LIKE wildcards using backslash
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,326 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPackagePathMatches(t *testing.T) {
+	tests := []struct {
+		pkgPath string
+		filter  string
+		want    bool
+	}{
+		{"github.com/org/repo/internal/store", "github.com/org/repo/internal/store", true},
+		{"github.com/org/repo/internal/store/sub", "github.com/org/repo/internal/store", true},
+		{"github.com/org/repo/internal/store", "internal/store", true},
+		{"github.com/org/repo/internal/store/sub", "internal/store/...", true},
+		{"github.com/org/repo/internal/storex", "internal/store", false},
+		{"github.com/org/repo/cmd/store", "internal/store", false},
+		{"github.com/org/repo/internal/store", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkgPath+"|"+tt.filter, func(t *testing.T) {
+			if got := PackagePathMatches(tt.pkgPath, tt.filter); got != tt.want {
+				t.Errorf("PackagePathMatches(%q, %q) = %v, want %v", tt.pkgPath, tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVectorStore_SearchByFiltersFillsTopK(t *testing.T) {
+	db, vs, vectors := newTestVectorStore(t, 2500, 16)
+	if _, err := vs.EnsureIndex(); err != nil {
+		t.Fatalf("EnsureIndex() error = %v", err)
+	}
+
+	symbolStore := NewSymbolStore(db)
+	filters := SearchFilters{
+		RepoPath:    "/repo",
+		Kinds:       []string{KindFunc},
+		PackagePath: "pkg3",
+	}
+
+	// Query with a vector from another package so post-filtering would starve
+	results, err := vs.SearchByFilters(vectors[0], 10, filters, symbolStore)
+	if err != nil {
+		t.Fatalf("SearchByFilters() error = %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("SearchByFilters() returned %d results, want 10", len(results))
+	}
+	for _, r := range results {
+		if r.Symbol == nil || r.Symbol.PackagePath != "pkg3" {
+			t.Errorf("result %s is outside the package filter", r.SymbolID)
+		}
+	}
+
+	// A different repository matches nothing
+	results, err = vs.SearchByFilters(vectors[0], 10, SearchFilters{RepoPath: "/other"}, symbolStore)
+	if err != nil {
+		t.Fatalf("SearchByFilters() error = %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("SearchByFilters() for other repo returned %d results, want 0", len(results))
+	}
+}
+
+func TestSymbolStore_SearchFTSWithFilters(t *testing.T) {
+	db, _, _ := newTestVectorStore(t, 50, 4)
+	symbolStore := NewSymbolStore(db)
+
+	symbols, err := symbolStore.SearchFTSWithFilters("F3*", 20, SearchFilters{PackagePath: "pkg3"})
+	if err != nil {
+		t.Fatalf("SearchFTSWithFilters() error = %v", err)
+	}
+	if len(symbols) == 0 {
+		t.Fatal("expected keyword matches in pkg3")
+	}
+	for _, sym := range symbols {
+		if sym.PackagePath != "pkg3" {
+			t.Errorf("symbol %s is outside the package filter", sym.ID)
+		}
+	}
+}
+
+func TestSymbolStore_SearchFTSExcludesTests(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tests.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := NewSymbolStore(db)
+	if err := symbolStore.CreateBatch([]*Symbol{
+		{ID: "p:func:Sum", RepoPath: "/repo", Kind: KindFunc, PackagePath: "p", Name: "Sum", FilePath: "p/sum.go", SemanticText: "adds numbers"},
+		{ID: "p:func:TestSum", RepoPath: "/repo", Kind: KindTest, PackagePath: "p", Name: "TestSum", FilePath: "p/sum_test.go", SemanticText: "adds numbers"},
+		{ID: "p:func:numbers", RepoPath: "/repo", Kind: KindFunc, PackagePath: "p", Name: "numbers", FilePath: "p/sum_test.go", SemanticText: "adds numbers"},
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	symbols, err := symbolStore.SearchFTSWithFilters("numbers", 10, SearchFilters{ExcludeTests: true})
+	if err != nil {
+		t.Fatalf("SearchFTSWithFilters() error = %v", err)
+	}
+	if len(symbols) != 1 || symbols[0].ID != "p:func:Sum" {
+		t.Errorf("SearchFTSWithFilters() = %v, want only p:func:Sum", symbols)
+	}
+
+	symbols, err = symbolStore.SearchFTSWithFilters("numbers", 10, SearchFilters{Kinds: []string{KindTest}})
+	if err != nil {
+		t.Fatalf("SearchFTSWithFilters() error = %v", err)
+	}
+	if len(symbols) != 1 || symbols[0].ID != "p:func:TestSum" {
+		t.Errorf("SearchFTSWithFilters(kind test) = %v, want only p:func:TestSum", symbols)
+	}
+}
+
+func TestSymbolStore_SearchFTSByBuildContext(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "contexts.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := NewSymbolStore(db)
+	if err := symbolStore.CreateBatch([]*Symbol{
+		{ID: "p:func:Open", RepoPath: "/repo", Kind: KindFunc, PackagePath: "p", Name: "Open", SemanticText: "opens files", BuildContexts: []string{"linux", "windows"}},
+		{ID: "p:func:openUnix", RepoPath: "/repo", Kind: KindFunc, PackagePath: "p", Name: "openUnix", SemanticText: "opens files", BuildContexts: []string{"linux"}},
+		{ID: "p:func:openHost", RepoPath: "/repo", Kind: KindFunc, PackagePath: "p", Name: "openHost", SemanticText: "opens files"},
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	sym, err := symbolStore.Get("p:func:Open")
+	if err != nil || sym == nil || len(sym.BuildContexts) != 2 || sym.BuildContexts[1] != "windows" {
+		t.Fatalf("Get() = %+v, %v, want linux and windows contexts", sym, err)
+	}
+	if sym, err := symbolStore.Get("p:func:openHost"); err != nil || sym == nil || sym.BuildContexts != nil {
+		t.Fatalf("Get() = %+v, %v, want no contexts", sym, err)
+	}
+
+	filters := SearchFilters{BuildContexts: []string{"windows"}}
+	symbols, err := symbolStore.SearchFTSWithFilters("files", 10, filters)
+	if err != nil {
+		t.Fatalf("SearchFTSWithFilters() error = %v", err)
+	}
+	if len(symbols) != 1 || symbols[0].ID != "p:func:Open" {
+		t.Errorf("SearchFTSWithFilters(windows) = %v, want only p:func:Open", symbols)
+	}
+	for _, sym := range symbols {
+		if !filters.Matches(sym) {
+			t.Errorf("Matches(%s) = false, want true", sym.ID)
+		}
+	}
+
+	symbols, err = symbolStore.SearchFTSWithFilters("files", 10, SearchFilters{BuildContexts: []string{"linux", "darwin"}})
+	if err != nil {
+		t.Fatalf("SearchFTSWithFilters() error = %v", err)
+	}
+	if len(symbols) != 2 {
+		t.Errorf("SearchFTSWithFilters(linux, darwin) = %v, want Open and openUnix", symbols)
+	}
+}
+
+func TestSymbolStore_SearchFTSScoredWeighsColumns(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "bm25.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Unrelated symbols give the query terms a meaningful inverse document
+	// frequency; bm25() scores nearly zero when every row matches
+	symbols := []*Symbol{
+		{ID: "p:func:Retry", RepoPath: "/repo", Kind: KindFunc, PackagePath: "p", Name: "Retry", SemanticText: "calls a function again"},
+		{ID: "p:func:Send", RepoPath: "/repo", Kind: KindFunc, PackagePath: "p", Name: "Send", SemanticText: "sends a request and will retry it with backoff when the server is busy"},
+	}
+	for i := 0; i < 50; i++ {
+		symbols = append(symbols, &Symbol{ID: fmt.Sprintf("q:func:Other%d", i), RepoPath: "/repo", Kind: KindFunc, PackagePath: "q", Name: fmt.Sprintf("Other%d", i), SemanticText: "does unrelated work"})
+	}
+	symbolStore := NewSymbolStore(db)
+	if err := symbolStore.CreateBatch(symbols); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	results, err := symbolStore.SearchFTSScored("retry", 10, SearchFilters{}, DefaultBM25Weights())
+	if err != nil {
+		t.Fatalf("SearchFTSScored() error = %v", err)
+	}
+	if len(results) != 2 || results[0].SymbolID != "p:func:Retry" {
+		t.Fatalf("SearchFTSScored() = %+v, want the name match first", results)
+	}
+	nameScore := results[0].Score
+	if nameScore <= 0.5 || nameScore >= 1 {
+		t.Errorf("name match score = %v, want a strong score in (0.5, 1)", nameScore)
+	}
+	if results[1].Score <= 0 || results[1].Score >= 0.5 {
+		t.Errorf("description match score = %v, want a weak score in (0, 0.5)", results[1].Score)
+	}
+
+	// The best match of a query that only hits a description stays weak
+	results, err = symbolStore.SearchFTSScored("server", 10, SearchFilters{}, DefaultBM25Weights())
+	if err != nil {
+		t.Fatalf("SearchFTSScored() error = %v", err)
+	}
+	if len(results) != 1 || results[0].SymbolID != "p:func:Send" {
+		t.Fatalf("SearchFTSScored(server) = %+v, want only the description match", results)
+	}
+	if results[0].Score <= 0 || results[0].Score >= nameScore {
+		t.Errorf("weak-only best score = %v, want below the name match score %v", results[0].Score, nameScore)
+	}
+
+	// Description-heavy weights reverse the order
+	results, err = symbolStore.SearchFTSScored("retry", 10, SearchFilters{}, BM25Weights{Name: 0, SemanticText: 10})
+	if err != nil {
+		t.Fatalf("SearchFTSScored() error = %v", err)
+	}
+	if len(results) != 2 || results[0].SymbolID != "p:func:Send" {
+		t.Errorf("SearchFTSScored(text weights) = %+v, want the description match first", results)
+	}
+}
+
+func TestSymbolStore_ListByQualifierFilters(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "qualifiers.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := NewSymbolStore(db)
+	if err := symbolStore.CreateBatch([]*Symbol{
+		{ID: "r/store:method:VectorStore.Get", RepoPath: "/repo", Kind: KindMethod, PackagePath: "r/store", Name: "Get", Signature: "func (v *VectorStore) Get(id string) ([]float32, error)", FilePath: "store/vectors.go", Exported: true},
+		{ID: "r/store:method:VectorStoreStats.String", RepoPath: "/repo", Kind: KindMethod, PackagePath: "r/store", Name: "String", Signature: "func (s VectorStoreStats) String() string", FilePath: "store/vectors.go", Exported: true},
+		{ID: "r/store:method:List.Len", RepoPath: "/repo", Kind: KindMethod, PackagePath: "r/store", Name: "Len", Signature: "func (l List[T]) Len() int", FilePath: "store/list.go", Exported: true},
+		{ID: "r/store:func:newVectorStore", RepoPath: "/repo", Kind: KindFunc, PackagePath: "r/store", Name: "newVectorStore", Signature: "func newVectorStore() *VectorStore", FilePath: "store/vectors_helper.go"},
+		{ID: "r/cmd/tool:func:main", RepoPath: "/repo", Kind: KindFunc, PackagePath: "r/cmd/tool", Name: "main", Signature: "func main()", FilePath: "cmd/tool/main.go"},
+	}); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		filters SearchFilters
+		want    []string
+	}{
+		{"pointer receiver", SearchFilters{Receiver: "*VectorStore"}, []string{"r/store:method:VectorStore.Get"}},
+		{"value receiver", SearchFilters{Receiver: "VectorStoreStats"}, []string{"r/store:method:VectorStoreStats.String"}},
+		{"pointer receiver required", SearchFilters{Receiver: "*VectorStoreStats"}, nil},
+		{"generic receiver", SearchFilters{Receiver: "List"}, []string{"r/store:method:List.Len"}},
+		{"base name pattern", SearchFilters{FilePatterns: []string{"*_helper.go"}}, []string{"r/store:func:newVectorStore"}},
+		{"path pattern", SearchFilters{FilePatterns: []string{"store/*.go"}, ExcludeKinds: []string{KindMethod}}, []string{"r/store:func:newVectorStore"}},
+		{"directory pattern", SearchFilters{FilePatterns: []string{"cmd/..."}}, []string{"r/cmd/tool:func:main"}},
+		{"excluded package", SearchFilters{ExcludePackages: []string{"store"}}, []string{"r/cmd/tool:func:main"}},
+		{"excluded file", SearchFilters{ExcludeFilePatterns: []string{"vectors*.go"}, Kinds: []string{KindMethod}}, []string{"r/store:method:List.Len"}},
+		{"symbol ids", SearchFilters{SymbolIDs: []string{"r/cmd/tool:func:main", "missing"}}, []string{"r/cmd/tool:func:main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbols, err := symbolStore.ListByFilters(tt.filters, 10)
+			if err != nil {
+				t.Fatalf("ListByFilters() error = %v", err)
+			}
+			var got []string
+			for _, sym := range symbols {
+				got = append(got, sym.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ListByFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolStore_ListByLayers(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "layers.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// The first layer with a marker in the path decides: /api/service/ is
+	// a handler package
+	var symbols []*Symbol
+	for _, pkg := range []string{"r/internal/api/service/orders", "r/internal/service/orders", "r/internal/Service/billing", "r/internal/store"} {
+		symbols = append(symbols, &Symbol{ID: pkg + ":func:F", RepoPath: "/repo", Kind: KindFunc, PackagePath: pkg, Name: "F", Exported: true})
+	}
+	symbolStore := NewSymbolStore(db)
+	if err := symbolStore.CreateBatch(symbols); err != nil {
+		t.Fatalf("failed to create symbols: %v", err)
+	}
+
+	tests := []struct {
+		layers []string
+		want   []string
+	}{
+		{[]string{"service"}, []string{"r/internal/Service/billing:func:F", "r/internal/service/orders:func:F"}},
+		{[]string{"handler"}, []string{"r/internal/api/service/orders:func:F"}},
+		{[]string{UnknownLayer}, []string{"r/internal/store:func:F"}},
+		{[]string{"handler", UnknownLayer}, []string{"r/internal/api/service/orders:func:F", "r/internal/store:func:F"}},
+		{[]string{"no-such-layer"}, nil},
+	}
+	for _, tt := range tests {
+		filters := SearchFilters{Layers: tt.layers}
+		found, err := symbolStore.ListByFilters(filters, 10)
+		if err != nil {
+			t.Fatalf("ListByFilters(%v) error = %v", tt.layers, err)
+		}
+		var got []string
+		for _, sym := range found {
+			got = append(got, sym.ID)
+			if !filters.Matches(sym) {
+				t.Errorf("Matches(%s) = false for layers %v", sym.ID, tt.layers)
+			}
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ListByFilters(%v) = %v, want %v", tt.layers, got, tt.want)
+		}
+	}
+}
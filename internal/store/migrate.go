@@ -0,0 +1,272 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations live in migrations/NNNN_name.sql. Versions start at 1 and must
+// be contiguous; each file is applied in its own transaction and recorded in
+// schema_version. Never edit a released migration; add a new file instead.
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Migration is a single embedded schema migration
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+// MigrationRecord is an applied migration from schema_version
+type MigrationRecord struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// SchemaTooNewError is returned when a database was written by a newer binary
+type SchemaTooNewError struct {
+	Path         string
+	Version      int
+	KnownVersion int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("database %s has schema version %d, but this binary only supports up to %d; upgrade bcindex",
+		e.Path, e.Version, e.KnownVersion)
+}
+
+// IsSchemaTooNew checks if error is a schema too new error
+func IsSchemaTooNew(err error) bool {
+	_, ok := err.(*SchemaTooNewError)
+	return ok
+}
+
+// Migrations returns the embedded migrations ordered by version
+func Migrations() ([]Migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q (want NNNN_name.sql)", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", name)
+		}
+
+		content, err := migrationsFS.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		sum := sha256.Sum256(content)
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     label,
+			SQL:      string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous from 1: found %d at position %d", m.Version, i+1)
+		}
+	}
+
+	if len(migrations) != CurrentSchemaVersion {
+		return nil, fmt.Errorf("embedded migrations end at version %d, but CurrentSchemaVersion is %d",
+			len(migrations), CurrentSchemaVersion)
+	}
+
+	return migrations, nil
+}
+
+// SchemaVersion returns the highest applied schema version (0 for a new database)
+func (db *DB) SchemaVersion() (int, error) {
+	return db.getSchemaVersion()
+}
+
+// PendingMigrations returns the migrations not yet applied to the database.
+// It fails with *SchemaTooNewError if the database is ahead of this binary.
+func (db *DB) PendingMigrations() ([]Migration, error) {
+	version, err := db.getSchemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if version > CurrentSchemaVersion {
+		return nil, &SchemaTooNewError{Path: db.path, Version: version, KnownVersion: CurrentSchemaVersion}
+	}
+
+	return migrations[version:], nil
+}
+
+// Migrate applies all pending migrations, each in its own transaction, and
+// returns the migrations that were applied.
+func (db *DB) Migrate() ([]Migration, error) {
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	if err := db.ensureHistoryTable(); err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0, len(pending))
+	for _, m := range pending {
+		if err := db.applyMigration(m); err != nil {
+			return applied, fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+// MigrationHistory returns the applied migrations recorded in schema_version
+func (db *DB) MigrationHistory() ([]MigrationRecord, error) {
+	version, err := db.getSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, nil
+	}
+	if err := db.ensureHistoryTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.sqlDB.Query("SELECT version, name, checksum, applied_at FROM schema_version ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_version: %w", err)
+	}
+	defer rows.Close()
+
+	var records []MigrationRecord
+	for rows.Next() {
+		var record MigrationRecord
+		var name, checksum sql.NullString
+		var appliedAtValue any
+		if err := rows.Scan(&record.Version, &name, &checksum, &appliedAtValue); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_version: %w", err)
+		}
+		record.Name = name.String
+		record.Checksum = checksum.String
+		appliedAt, err := parseTimeValue(appliedAtValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse applied_at: %w", err)
+		}
+		record.AppliedAt = appliedAt
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+func (db *DB) applyMigration(m Migration) error {
+	tx, err := db.sqlDB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("failed to apply schema: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		"INSERT OR REPLACE INTO schema_version (version, applied_at, name, checksum) VALUES (?, ?, ?, ?)",
+		m.Version,
+		time.Now().UTC().Format(time.RFC3339),
+		m.Name,
+		m.Checksum,
+	); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// ensureHistoryTable creates schema_version, upgrading the version 1 layout
+// (version, applied_at) with the name and checksum columns.
+func (db *DB) ensureHistoryTable() error {
+	if _, err := db.sqlDB.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			applied_at TEXT NOT NULL,
+			name TEXT,
+			checksum TEXT
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_version: %w", err)
+	}
+
+	rows, err := db.sqlDB.Query("PRAGMA table_info(schema_version)")
+	if err != nil {
+		return fmt.Errorf("failed to inspect schema_version: %w", err)
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue any
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan schema_version columns: %w", err)
+		}
+		columns[name] = true
+	}
+	rows.Close()
+
+	for _, column := range []string{"name", "checksum"} {
+		if columns[column] {
+			continue
+		}
+		if _, err := db.sqlDB.Exec(fmt.Sprintf("ALTER TABLE schema_version ADD COLUMN %s TEXT", column)); err != nil {
+			return fmt.Errorf("failed to add schema_version.%s: %w", column, err)
+		}
+	}
+
+	return nil
+}
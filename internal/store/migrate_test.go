@@ -0,0 +1,366 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if len(migrations) != CurrentSchemaVersion {
+		t.Fatalf("got %d migrations, want %d", len(migrations), CurrentSchemaVersion)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 || m.Name == "" || m.Checksum == "" {
+			t.Errorf("unexpected migration %d: %+v", i, m)
+		}
+	}
+}
+
+func TestOpen_FreshDatabase(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "fresh.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
+	}
+	if version != CurrentSchemaVersion {
+		t.Errorf("version = %d, want %d", version, CurrentSchemaVersion)
+	}
+
+	history, err := db.MigrationHistory()
+	if err != nil {
+		t.Fatalf("MigrationHistory() error = %v", err)
+	}
+	if len(history) != CurrentSchemaVersion {
+		t.Fatalf("history has %d entries, want %d", len(history), CurrentSchemaVersion)
+	}
+	if history[0].Name != "initial" || history[0].Checksum == "" {
+		t.Errorf("unexpected first history entry: %+v", history[0])
+	}
+
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		t.Fatalf("PendingMigrations() error = %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending = %d, want 0", len(pending))
+	}
+}
+
+func TestOpen_UpgradesVersionOneDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Recreate the layout written by schema version 1
+	legacy, err := OpenNoMigrate(path)
+	if err != nil {
+		t.Fatalf("OpenNoMigrate() error = %v", err)
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if _, err := legacy.sqlDB.Exec(`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		t.Fatalf("failed to create legacy schema_version: %v", err)
+	}
+	if _, err := legacy.sqlDB.Exec(migrations[0].SQL); err != nil {
+		t.Fatalf("failed to apply legacy schema: %v", err)
+	}
+	if _, err := legacy.sqlDB.Exec(`INSERT INTO schema_version (version, applied_at) VALUES (1, ?)`, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		t.Fatalf("failed to record legacy version: %v", err)
+	}
+
+	pending, err := legacy.PendingMigrations()
+	if err != nil {
+		t.Fatalf("PendingMigrations() error = %v", err)
+	}
+	if len(pending) != CurrentSchemaVersion-1 || pending[0].Version != 2 {
+		t.Fatalf("unexpected pending migrations: %+v", pending)
+	}
+	legacy.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	version, _ := db.SchemaVersion()
+	if version != CurrentSchemaVersion {
+		t.Errorf("version = %d, want %d", version, CurrentSchemaVersion)
+	}
+
+	history, err := db.MigrationHistory()
+	if err != nil {
+		t.Fatalf("MigrationHistory() error = %v", err)
+	}
+	if history[0].Name != "" || history[len(history)-1].Name == "" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestOpen_RefusesNewerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := db.sqlDB.Exec(
+		`INSERT INTO schema_version (version, applied_at, name) VALUES (?, ?, 'future')`,
+		CurrentSchemaVersion+1, time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		t.Fatalf("failed to insert future version: %v", err)
+	}
+	db.Close()
+
+	_, err = Open(path)
+	if err == nil {
+		t.Fatal("Open() succeeded for a newer schema")
+	}
+	if !IsSchemaTooNew(err) {
+		t.Errorf("Open() error = %v, want SchemaTooNewError", err)
+	}
+}
+
+func TestMigrate_PositionsBlankSymbolIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blank.db")
+
+	db, err := OpenNoMigrate(path)
+	if err != nil {
+		t.Fatalf("OpenNoMigrate() error = %v", err)
+	}
+	defer db.Close()
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if err := db.ensureHistoryTable(); err != nil {
+		t.Fatalf("ensureHistoryTable() error = %v", err)
+	}
+	for _, m := range migrations[:8] {
+		if err := db.applyMigration(m); err != nil {
+			t.Fatalf("applyMigration(%d) error = %v", m.Version, err)
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, stmt := range []string{
+		`INSERT INTO symbols (id, repo_path, kind, package_path, package_name, name, file_path, line_start, line_end, created_at, updated_at)
+		 VALUES ('p:var:_', '/repo', 'var', 'p', 'p', '_', 'a.go', 7, 7, '` + now + `', '` + now + `')`,
+		`INSERT INTO symbols (id, repo_path, kind, package_path, package_name, name, file_path, line_start, line_end, created_at, updated_at)
+		 VALUES ('p:struct:T', '/repo', 'struct', 'p', 'p', 'T', 'a.go', 3, 5, '` + now + `', '` + now + `')`,
+		`INSERT INTO edges (from_id, to_id, edge_type, created_at) VALUES ('p:var:_', 'p:struct:T', 'references', '` + now + `')`,
+		`INSERT INTO embeddings (symbol_id, vector, dimension, model, created_at) VALUES ('p:var:_', x'00000000', 1, 'm', '` + now + `')`,
+		`INSERT INTO ann_assignments (symbol_id, cluster_id) VALUES ('p:var:_', 0)`,
+	} {
+		if _, err := db.sqlDB.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert fixture: %v", err)
+		}
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	const want = "p:var:_@a.go:7"
+	var count int
+	if err := db.sqlDB.QueryRow(`SELECT COUNT(*) FROM symbols WHERE id = ?`, want).Scan(&count); err != nil || count != 1 {
+		t.Errorf("symbol %s count = %d (err %v), want 1", want, count, err)
+	}
+	var fromID string
+	if err := db.sqlDB.QueryRow(`SELECT from_id FROM edges WHERE to_id = 'p:struct:T'`).Scan(&fromID); err != nil || fromID != want {
+		t.Errorf("edge from_id = %q (err %v), want %q", fromID, err, want)
+	}
+	if err := db.sqlDB.QueryRow(`SELECT COUNT(*) FROM ann_assignments WHERE symbol_id = ?`, want).Scan(&count); err != nil || count != 1 {
+		t.Errorf("ann assignment count = %d (err %v), want 1", count, err)
+	}
+	if err := db.sqlDB.QueryRow(`SELECT COUNT(*) FROM symbols WHERE id = 'p:struct:T'`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("named symbol was renamed")
+	}
+}
+
+func TestMigrate_ConstraintEdgesKeepSites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sites.db")
+
+	db, err := OpenNoMigrate(path)
+	if err != nil {
+		t.Fatalf("OpenNoMigrate() error = %v", err)
+	}
+	defer db.Close()
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if err := db.ensureHistoryTable(); err != nil {
+		t.Fatalf("ensureHistoryTable() error = %v", err)
+	}
+	for _, m := range migrations[:9] {
+		if err := db.applyMigration(m); err != nil {
+			t.Fatalf("applyMigration(%d) error = %v", m.Version, err)
+		}
+	}
+
+	for _, name := range []string{"Sum", "Number"} {
+		insertLegacySymbol(t, db, name, "")
+	}
+	edges := NewEdgeStore(db)
+	if err := edges.Create(&Edge{
+		FromID: "p:func:Sum", ToID: "p:func:Number", EdgeType: EdgeTypeCalls,
+		Sites: []EdgeSite{{FilePath: "a.go", Line: 3, Column: 2}},
+	}); err != nil {
+		t.Fatalf("failed to create edge: %v", err)
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	outgoing, err := edges.GetOutgoing("p:func:Sum", EdgeTypeCalls)
+	if err != nil || len(outgoing) != 1 {
+		t.Fatalf("GetOutgoing() = %v, %v", outgoing, err)
+	}
+	sites, err := edges.GetSites(outgoing[0].ID)
+	if err != nil || len(sites) != 1 || sites[0].Line != 3 {
+		t.Errorf("sites after migration = %+v, %v", sites, err)
+	}
+
+	if err := edges.Create(&Edge{FromID: "p:func:Sum", ToID: "p:func:Number", EdgeType: EdgeTypeConstrains}); err != nil {
+		t.Errorf("failed to create constrains edge: %v", err)
+	}
+}
+
+func TestMigrate_TestSymbolsKeepDependents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tests.db")
+
+	db, err := OpenNoMigrate(path)
+	if err != nil {
+		t.Fatalf("OpenNoMigrate() error = %v", err)
+	}
+	defer db.Close()
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if err := db.ensureHistoryTable(); err != nil {
+		t.Fatalf("ensureHistoryTable() error = %v", err)
+	}
+	for _, m := range migrations[:10] {
+		if err := db.applyMigration(m); err != nil {
+			t.Fatalf("applyMigration(%d) error = %v", m.Version, err)
+		}
+	}
+
+	for _, name := range []string{"Sum", "Number"} {
+		insertLegacySymbol(t, db, name, "adds numbers")
+	}
+	edges := NewEdgeStore(db)
+	if err := edges.Create(&Edge{
+		FromID: "p:func:Sum", ToID: "p:func:Number", EdgeType: EdgeTypeCalls,
+		Sites: []EdgeSite{{FilePath: "a.go", Line: 3, Column: 2}},
+	}); err != nil {
+		t.Fatalf("failed to create edge: %v", err)
+	}
+	vectors := NewVectorStore(db)
+	if err := vectors.Insert("p:func:Sum", []float32{1, 0}, "test"); err != nil {
+		t.Fatalf("failed to insert vector: %v", err)
+	}
+	blob, err := vectorToBlob([]float32{0, 1})
+	if err != nil {
+		t.Fatalf("failed to convert chunk vector: %v", err)
+	}
+	if _, err := db.sqlDB.Exec(`INSERT INTO chunks (id, symbol_id, line_start, line_end, vector, dimension, model, created_at)
+		VALUES (?, 'p:func:Sum', 1, 3, ?, 2, 'test', ?)`, ChunkID("p:func:Sum", 1, 3), blob, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		t.Fatalf("failed to insert chunk: %v", err)
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	outgoing, err := edges.GetOutgoing("p:func:Sum", EdgeTypeCalls)
+	if err != nil || len(outgoing) != 1 {
+		t.Fatalf("GetOutgoing() = %v, %v", outgoing, err)
+	}
+	sites, err := edges.GetSites(outgoing[0].ID)
+	if err != nil || len(sites) != 1 || sites[0].Line != 3 {
+		t.Errorf("sites after migration = %+v, %v", sites, err)
+	}
+	if ok, err := vectors.HasVector("p:func:Sum"); err != nil || !ok {
+		t.Errorf("HasVector() after migration = %v, %v", ok, err)
+	}
+	if count, err := vectors.CountChunks(); err != nil || count != 1 {
+		t.Errorf("CountChunks() after migration = %d, %v", count, err)
+	}
+	symbols := NewSymbolStore(db)
+	if found, err := symbols.SearchFTS("numbers", 10); err != nil || len(found) != 2 {
+		t.Errorf("SearchFTS() after migration = %d symbols, %v", len(found), err)
+	}
+
+	if err := symbols.Create(&Symbol{ID: "p:func:TestSum", RepoPath: "/repo", Kind: KindTest, PackagePath: "p", PackageName: "p", Name: "TestSum"}); err != nil {
+		t.Fatalf("failed to create test symbol: %v", err)
+	}
+	if err := edges.Create(&Edge{FromID: "p:func:TestSum", ToID: "p:func:Sum", EdgeType: EdgeTypeTests}); err != nil {
+		t.Errorf("failed to create tests edge: %v", err)
+	}
+}
+
+func TestMigrate_TestSymbolsKeepContentHashes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes.db")
+
+	db, err := OpenNoMigrate(path)
+	if err != nil {
+		t.Fatalf("OpenNoMigrate() error = %v", err)
+	}
+	defer db.Close()
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if err := db.ensureHistoryTable(); err != nil {
+		t.Fatalf("ensureHistoryTable() error = %v", err)
+	}
+	for _, m := range migrations[:10] {
+		if err := db.applyMigration(m); err != nil {
+			t.Fatalf("applyMigration(%d) error = %v", m.Version, err)
+		}
+	}
+
+	insertLegacySymbol(t, db, "Sum", "")
+	vectors := NewVectorStore(db)
+	if err := vectors.InsertBatchWithHashes([]string{"p:func:Sum"}, [][]float32{{1, 0}}, []string{"abc"}, "test"); err != nil {
+		t.Fatalf("failed to insert vector: %v", err)
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	stored, err := vectors.GetByPackage("p")
+	if err != nil {
+		t.Fatalf("GetByPackage() error = %v", err)
+	}
+	if got := stored["p:func:Sum"]; got == nil || got.ContentHash != "abc" {
+		t.Errorf("stored vector after migration = %+v, want content hash abc", got)
+	}
+}
+
+// insertLegacySymbol inserts a function symbol with the columns every schema
+// version has, as SymbolStore writes the columns of the latest one
+func insertLegacySymbol(t *testing.T, db *DB, name, semanticText string) {
+	t.Helper()
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := db.sqlDB.Exec(`INSERT INTO symbols (id, repo_path, kind, package_path, package_name, name, signature, file_path, line_start, line_end, doc_comment, semantic_text, tokens, type_details, created_at, updated_at)
+		VALUES (?, '/repo', 'func', 'p', 'p', ?, '', '', 0, 0, '', ?, '[]', 'null', ?, ?)`, "p:func:"+name, name, semanticText, now, now)
+	if err != nil {
+		t.Fatalf("failed to insert symbol: %v", err)
+	}
+}
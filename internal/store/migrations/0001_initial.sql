@@ -1,7 +1,4 @@
-CREATE TABLE IF NOT EXISTS schema_version (
-    version INTEGER PRIMARY KEY,
-    applied_at TEXT NOT NULL
-);
+-- Initial schema: symbols, edges, packages, embeddings and repositories
 
 -- Symbols table: stores all semantic units
 CREATE TABLE IF NOT EXISTS symbols (
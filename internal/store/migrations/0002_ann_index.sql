@@ -0,0 +1,23 @@
+-- Approximate nearest-neighbor (IVF) index over embeddings
+
+-- ANN (IVF) index: cluster centroids trained from stored embeddings
+CREATE TABLE IF NOT EXISTS ann_centroids (
+    id INTEGER PRIMARY KEY,
+    dimension INTEGER NOT NULL,
+    vector BLOB NOT NULL -- Normalized float32 array
+);
+
+-- ANN (IVF) index: cluster assignment per embedding
+CREATE TABLE IF NOT EXISTS ann_assignments (
+    symbol_id TEXT PRIMARY KEY,
+    cluster_id INTEGER NOT NULL,
+    FOREIGN KEY (symbol_id) REFERENCES embeddings(symbol_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ann_assignments_cluster ON ann_assignments(cluster_id);
+
+-- ANN (IVF) index metadata (dimension, trained_count, built_at)
+CREATE TABLE IF NOT EXISTS ann_meta (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
@@ -0,0 +1,17 @@
+-- Content hashes for change detection
+
+-- Files table: content hash of every indexed Go source file
+CREATE TABLE IF NOT EXISTS files (
+    repo_path TEXT NOT NULL,
+    file_path TEXT NOT NULL, -- Relative to repo root
+    package_path TEXT,
+    content_hash TEXT NOT NULL, -- SHA-256 of file contents
+    size INTEGER NOT NULL DEFAULT 0,
+    indexed_at TEXT NOT NULL,
+    PRIMARY KEY (repo_path, file_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_files_package ON files(package_path);
+
+-- Hash of the symbol signature, doc and body an embedding was generated from
+ALTER TABLE embeddings ADD COLUMN content_hash TEXT;
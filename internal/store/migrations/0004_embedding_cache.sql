@@ -0,0 +1,18 @@
+-- Embedding cache keyed by model, dimensions and embedded text hash
+
+-- Cached vectors, reused across incremental runs, rebuilds and worktrees
+CREATE TABLE IF NOT EXISTS embedding_cache (
+    model TEXT NOT NULL, -- provider/model
+    dimensions INTEGER NOT NULL, -- Configured dimensions
+    text_hash TEXT NOT NULL, -- SHA-256 of the embedded text
+    vector BLOB NOT NULL, -- Stored as binary (float32 array)
+    created_at TEXT NOT NULL,
+    last_used_at TEXT NOT NULL,
+    PRIMARY KEY (model, dimensions, text_hash)
+);
+
+-- Cumulative cache lookup counters (hits, misses)
+CREATE TABLE IF NOT EXISTS embedding_cache_stats (
+    name TEXT PRIMARY KEY,
+    value INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,16 @@
+-- Chunked function body embeddings
+
+-- Chunks table: embeddings of overlapping line ranges of a function body
+CREATE TABLE IF NOT EXISTS chunks (
+    id TEXT PRIMARY KEY, -- <symbol_id>#L<line_start>-<line_end>
+    symbol_id TEXT NOT NULL, -- Owning function or method
+    line_start INTEGER NOT NULL,
+    line_end INTEGER NOT NULL,
+    vector BLOB NOT NULL, -- Stored as binary (float32 array)
+    dimension INTEGER NOT NULL,
+    model TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chunks_symbol ON chunks(symbol_id);
@@ -0,0 +1,25 @@
+-- Dispatch edges: interface method -> concrete method run by the call
+
+-- SQLite cannot alter a CHECK constraint, so the edges table is rebuilt
+CREATE TABLE edges_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_id TEXT NOT NULL,
+    to_id TEXT NOT NULL,
+    edge_type TEXT NOT NULL CHECK(edge_type IN ('calls', 'dispatches', 'implements', 'imports', 'references', 'embeds')),
+    weight INTEGER NOT NULL DEFAULT 1,
+    import_path TEXT, -- For import edges
+    created_at TEXT NOT NULL,
+    FOREIGN KEY (from_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    UNIQUE(from_id, to_id, edge_type)
+);
+
+INSERT INTO edges_new (id, from_id, to_id, edge_type, weight, import_path, created_at)
+SELECT id, from_id, to_id, edge_type, weight, import_path, created_at FROM edges;
+
+DROP TABLE edges;
+ALTER TABLE edges_new RENAME TO edges;
+
+CREATE INDEX IF NOT EXISTS idx_edges_from ON edges(from_id);
+CREATE INDEX IF NOT EXISTS idx_edges_to ON edges(to_id);
+CREATE INDEX IF NOT EXISTS idx_edges_type ON edges(edge_type);
@@ -0,0 +1,5 @@
+-- Edge provenance: how an edge was found
+
+-- static: from the source or the type checker (all edges so far)
+-- cha | vta: call edges inferred by whole-program call graph analysis
+ALTER TABLE edges ADD COLUMN provenance TEXT NOT NULL DEFAULT 'static';
@@ -0,0 +1,14 @@
+-- Call and reference sites of edges
+
+-- Edge sites table: where in the source an edge occurs (one row per site)
+CREATE TABLE IF NOT EXISTS edge_sites (
+    edge_id INTEGER NOT NULL,
+    file_path TEXT NOT NULL, -- Relative to the repository root
+    line INTEGER NOT NULL,
+    col INTEGER NOT NULL,
+    FOREIGN KEY (edge_id) REFERENCES edges(id) ON DELETE CASCADE,
+    UNIQUE(edge_id, file_path, line, col)
+);
+
+-- Number of sites of an edge (1 for edges without sites, e.g. implements)
+ALTER TABLE edges ADD COLUMN site_count INTEGER NOT NULL DEFAULT 1;
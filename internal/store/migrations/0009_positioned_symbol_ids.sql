@@ -0,0 +1,43 @@
+-- Positioned IDs for blank declarations
+
+-- Blank identifiers (var _ Iface = (*T)(nil), const _ = iota, func _())
+-- shared the ID <pkg>:<kind>:_ and overwrote each other. Like init
+-- functions, they now carry the file and line of their declaration:
+-- <pkg>:<kind>:_@<file>:<line>. The rows left are renamed in place.
+
+-- References are checked when the transaction commits, after every table
+-- has been renamed
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TEMP TABLE symbol_renames AS
+SELECT id AS old_id, id || '@' || file_path || ':' || line_start AS new_id
+FROM symbols
+WHERE name = '_' AND instr(id, '@') = 0 AND file_path IS NOT NULL AND line_start > 0;
+
+UPDATE symbols
+SET id = (SELECT new_id FROM symbol_renames WHERE old_id = symbols.id)
+WHERE id IN (SELECT old_id FROM symbol_renames);
+
+UPDATE edges
+SET from_id = (SELECT new_id FROM symbol_renames WHERE old_id = edges.from_id)
+WHERE from_id IN (SELECT old_id FROM symbol_renames);
+
+UPDATE edges
+SET to_id = (SELECT new_id FROM symbol_renames WHERE old_id = edges.to_id)
+WHERE to_id IN (SELECT old_id FROM symbol_renames);
+
+UPDATE embeddings
+SET symbol_id = (SELECT new_id FROM symbol_renames WHERE old_id = embeddings.symbol_id)
+WHERE symbol_id IN (SELECT old_id FROM symbol_renames);
+
+UPDATE ann_assignments
+SET symbol_id = (SELECT new_id FROM symbol_renames WHERE old_id = ann_assignments.symbol_id)
+WHERE symbol_id IN (SELECT old_id FROM symbol_renames);
+
+-- Chunk IDs are <symbol_id>#L<line_start>-<line_end>
+UPDATE chunks
+SET id = (SELECT new_id FROM symbol_renames WHERE old_id = chunks.symbol_id) || substr(id, length(symbol_id) + 1),
+    symbol_id = (SELECT new_id FROM symbol_renames WHERE old_id = chunks.symbol_id)
+WHERE symbol_id IN (SELECT old_id FROM symbol_renames);
+
+DROP TABLE symbol_renames;
@@ -0,0 +1,35 @@
+-- Constraint edges: generic function or type -> constraint interface
+
+-- SQLite cannot alter a CHECK constraint, so the edges table is rebuilt.
+-- Dropping it deletes the sites through ON DELETE CASCADE, so they are kept
+-- aside and restored.
+CREATE TEMP TABLE edge_sites_backup AS SELECT * FROM edge_sites;
+
+CREATE TABLE edges_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_id TEXT NOT NULL,
+    to_id TEXT NOT NULL,
+    edge_type TEXT NOT NULL CHECK(edge_type IN ('calls', 'dispatches', 'implements', 'imports', 'references', 'embeds', 'constrains')),
+    weight INTEGER NOT NULL DEFAULT 1,
+    import_path TEXT, -- For import edges
+    created_at TEXT NOT NULL,
+    provenance TEXT NOT NULL DEFAULT 'static',
+    site_count INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (from_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    UNIQUE(from_id, to_id, edge_type)
+);
+
+INSERT INTO edges_new (id, from_id, to_id, edge_type, weight, import_path, created_at, provenance, site_count)
+SELECT id, from_id, to_id, edge_type, weight, import_path, created_at, provenance, site_count FROM edges;
+
+DROP TABLE edges;
+ALTER TABLE edges_new RENAME TO edges;
+
+CREATE INDEX IF NOT EXISTS idx_edges_from ON edges(from_id);
+CREATE INDEX IF NOT EXISTS idx_edges_to ON edges(to_id);
+CREATE INDEX IF NOT EXISTS idx_edges_type ON edges(edge_type);
+
+INSERT INTO edge_sites (edge_id, file_path, line, col)
+SELECT edge_id, file_path, line, col FROM edge_sites_backup;
+DROP TABLE edge_sites_backup;
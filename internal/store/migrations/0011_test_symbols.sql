@@ -0,0 +1,98 @@
+-- Test symbols (Test, Benchmark, Fuzz and Example functions) and tests edges:
+-- test -> production symbol it exercises
+
+-- SQLite cannot alter a CHECK constraint, so the symbols and edges tables are
+-- rebuilt. Dropping symbols deletes the rows of every table referencing it
+-- through ON DELETE CASCADE, so they are kept aside and restored.
+CREATE TEMP TABLE edges_backup AS SELECT * FROM edges;
+CREATE TEMP TABLE edge_sites_backup AS SELECT * FROM edge_sites;
+CREATE TEMP TABLE embeddings_backup AS SELECT * FROM embeddings;
+CREATE TEMP TABLE ann_assignments_backup AS SELECT * FROM ann_assignments;
+CREATE TEMP TABLE chunks_backup AS SELECT * FROM chunks;
+
+CREATE TABLE symbols_new (
+    id TEXT PRIMARY KEY,
+    repo_path TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK(kind IN ('package', 'file', 'interface', 'struct', 'type', 'func', 'method', 'const', 'var', 'field', 'test')),
+    package_path TEXT NOT NULL,
+    package_name TEXT NOT NULL,
+    name TEXT NOT NULL,
+    signature TEXT,
+    file_path TEXT,
+    line_start INTEGER,
+    line_end INTEGER,
+    doc_comment TEXT,
+    exported INTEGER NOT NULL DEFAULT 0,
+    semantic_text TEXT,
+    tokens TEXT, -- JSON array of keywords
+    type_details TEXT, -- JSON blob for TypeDetails
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- Rowids are kept so the full-text index still points at the right rows
+INSERT INTO symbols_new (rowid, id, repo_path, kind, package_path, package_name, name, signature, file_path, line_start, line_end, doc_comment, exported, semantic_text, tokens, type_details, created_at, updated_at)
+SELECT rowid, id, repo_path, kind, package_path, package_name, name, signature, file_path, line_start, line_end, doc_comment, exported, semantic_text, tokens, type_details, created_at, updated_at FROM symbols;
+
+DROP TABLE edges;
+DROP TABLE symbols;
+ALTER TABLE symbols_new RENAME TO symbols;
+
+CREATE INDEX IF NOT EXISTS idx_symbols_repo ON symbols(repo_path);
+CREATE INDEX IF NOT EXISTS idx_symbols_kind ON symbols(kind);
+CREATE INDEX IF NOT EXISTS idx_symbols_package ON symbols(package_path);
+CREATE INDEX IF NOT EXISTS idx_symbols_name ON symbols(name);
+CREATE INDEX IF NOT EXISTS idx_symbols_exported ON symbols(exported);
+
+CREATE TRIGGER IF NOT EXISTS symbols_fts_insert AFTER INSERT ON symbols BEGIN
+    INSERT INTO symbols_fts(rowid, id, name, semantic_text)
+    VALUES (new.rowid, new.id, new.name, new.semantic_text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS symbols_fts_delete AFTER DELETE ON symbols BEGIN
+    DELETE FROM symbols_fts WHERE rowid = old.rowid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS symbols_fts_update AFTER UPDATE ON symbols BEGIN
+    DELETE FROM symbols_fts WHERE rowid = old.rowid;
+    INSERT INTO symbols_fts(rowid, id, name, semantic_text)
+    VALUES (new.rowid, new.id, new.name, new.semantic_text);
+END;
+
+INSERT INTO symbols_fts(symbols_fts) VALUES('rebuild');
+
+CREATE TABLE edges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_id TEXT NOT NULL,
+    to_id TEXT NOT NULL,
+    edge_type TEXT NOT NULL CHECK(edge_type IN ('calls', 'dispatches', 'implements', 'imports', 'references', 'embeds', 'constrains', 'tests')),
+    weight INTEGER NOT NULL DEFAULT 1,
+    import_path TEXT, -- For import edges
+    created_at TEXT NOT NULL,
+    provenance TEXT NOT NULL DEFAULT 'static',
+    site_count INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (from_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    UNIQUE(from_id, to_id, edge_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_edges_from ON edges(from_id);
+CREATE INDEX IF NOT EXISTS idx_edges_to ON edges(to_id);
+CREATE INDEX IF NOT EXISTS idx_edges_type ON edges(edge_type);
+
+INSERT INTO edges (id, from_id, to_id, edge_type, weight, import_path, created_at, provenance, site_count)
+SELECT id, from_id, to_id, edge_type, weight, import_path, created_at, provenance, site_count FROM edges_backup;
+INSERT INTO edge_sites (edge_id, file_path, line, col)
+SELECT edge_id, file_path, line, col FROM edge_sites_backup;
+INSERT INTO embeddings (symbol_id, vector, dimension, model, content_hash, created_at)
+SELECT symbol_id, vector, dimension, model, content_hash, created_at FROM embeddings_backup;
+INSERT INTO ann_assignments (symbol_id, cluster_id)
+SELECT symbol_id, cluster_id FROM ann_assignments_backup;
+INSERT INTO chunks (id, symbol_id, line_start, line_end, vector, dimension, model, created_at)
+SELECT id, symbol_id, line_start, line_end, vector, dimension, model, created_at FROM chunks_backup;
+
+DROP TABLE edges_backup;
+DROP TABLE edge_sites_backup;
+DROP TABLE embeddings_backup;
+DROP TABLE ann_assignments_backup;
+DROP TABLE chunks_backup;
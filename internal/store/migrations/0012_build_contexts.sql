@@ -0,0 +1,4 @@
+-- Build contexts (tag and GOOS/GOARCH combinations) symbols exist in
+
+-- JSON array of context names; NULL when indexed under the host's context only
+ALTER TABLE symbols ADD COLUMN build_contexts TEXT;
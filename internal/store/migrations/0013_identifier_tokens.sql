@@ -0,0 +1,40 @@
+-- Index the identifier tokens of symbols (names, signatures, receivers and
+-- package paths split at camelCase, acronyms, underscores and digits)
+
+-- FTS5 cannot add a column, so the full-text index is recreated. Its
+-- triggers now pass the old values of a row to the 'delete' command, as an
+-- external-content table requires; deleting by rowid left stale entries.
+DROP TRIGGER IF EXISTS symbols_fts_insert;
+DROP TRIGGER IF EXISTS symbols_fts_delete;
+DROP TRIGGER IF EXISTS symbols_fts_update;
+DROP TABLE IF EXISTS symbols_fts;
+
+CREATE VIRTUAL TABLE symbols_fts USING fts5(
+    id,
+    name,
+    semantic_text,
+    tokens,
+    content=symbols,
+    content_rowid=rowid
+);
+
+CREATE TRIGGER symbols_fts_insert AFTER INSERT ON symbols BEGIN
+    INSERT INTO symbols_fts(rowid, id, name, semantic_text, tokens)
+    VALUES (new.rowid, new.id, new.name, new.semantic_text, new.tokens);
+END;
+
+CREATE TRIGGER symbols_fts_delete AFTER DELETE ON symbols BEGIN
+    INSERT INTO symbols_fts(symbols_fts, rowid, id, name, semantic_text, tokens)
+    VALUES ('delete', old.rowid, old.id, old.name, old.semantic_text, old.tokens);
+END;
+
+CREATE TRIGGER symbols_fts_update AFTER UPDATE ON symbols BEGIN
+    INSERT INTO symbols_fts(symbols_fts, rowid, id, name, semantic_text, tokens)
+    VALUES ('delete', old.rowid, old.id, old.name, old.semantic_text, old.tokens);
+    INSERT INTO symbols_fts(rowid, id, name, semantic_text, tokens)
+    VALUES (new.rowid, new.id, new.name, new.semantic_text, new.tokens);
+END;
+
+-- Tokens written before this version are not split; they are replaced as
+-- files change or on bcindex index -force
+INSERT INTO symbols_fts(symbols_fts) VALUES('rebuild');
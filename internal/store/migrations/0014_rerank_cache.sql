@@ -0,0 +1,10 @@
+-- Rerank cache keyed by model and query/candidate hash
+
+-- Relevance scores of the rerank stage, reused by repeated queries
+CREATE TABLE IF NOT EXISTS rerank_cache (
+    model TEXT NOT NULL, -- provider/model
+    key TEXT NOT NULL, -- SHA-256 of the query and candidate text
+    score REAL NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (model, key)
+);
@@ -0,0 +1,6 @@
+-- Assign body chunks to the ANN index clusters
+
+-- Nearest IVF centroid of the chunk vector; NULL when no index of its dimension exists
+ALTER TABLE chunks ADD COLUMN cluster_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_chunks_cluster ON chunks(cluster_id);
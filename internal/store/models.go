@@ -12,7 +12,7 @@ type Symbol struct {
 	RepoPath string `json:"repo_path"`
 
 	// Symbol classification
-	Kind string `json:"kind"` // package | file | interface | struct | type | func | method | const | var | field
+	Kind string `json:"kind"` // package | file | interface | struct | type | func | method | const | var | field | test
 
 	// Package information
 	PackagePath string `json:"package_path"` // Full package path (e.g., github.com/user/repo/pkg)
@@ -47,6 +47,10 @@ type Symbol struct {
 	// Type-specific fields
 	TypeDetails *TypeDetails `json:"type_details,omitempty"`
 
+	// Build contexts the symbol exists in (empty when indexed under the
+	// host's context only)
+	BuildContexts []string `json:"build_contexts,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -69,6 +73,19 @@ type TypeDetails struct {
 	ReceiverType string   `json:"receiver_type,omitempty"` // For methods
 	Params       []Param  `json:"params,omitempty"`
 	Returns      []string `json:"returns,omitempty"`
+
+	// For generic functions and types
+	TypeParams []TypeParam `json:"type_params,omitempty"`
+
+	// For enum-like const blocks, which are embedded as one unit
+	Group   string   `json:"group,omitempty"`   // ID of the first member, set on the other members
+	Members []string `json:"members,omitempty"` // Names of all members, set on the first member
+}
+
+// TypeParam represents a type parameter and its constraint
+type TypeParam struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"`
 }
 
 // Field represents a struct field
@@ -86,18 +103,35 @@ type Param struct {
 
 // Edge represents a relationship between two symbols
 type Edge struct {
+	ID       int64  `json:"id,omitempty"`
 	FromID   string `json:"from_id"`   // Source symbol ID
 	ToID     string `json:"to_id"`     // Target symbol ID
-	EdgeType string `json:"edge_type"` // calls | implements | imports | references | embeds
+	EdgeType string `json:"edge_type"` // calls | dispatches | implements | imports | references | embeds | constrains
 	Weight   int    `json:"weight"`    // Relationship weight (for ranking)
 
 	// For import edges: specific import path
 	ImportPath string `json:"import_path,omitempty"`
 
+	// How the edge was found: static | cha | vta (empty means static)
+	Provenance string `json:"provenance,omitempty"`
+
+	// Number of call or reference sites (at least 1)
+	Count int `json:"count"`
+
+	// Sites to record on insert; not loaded by queries (see GetSites)
+	Sites []EdgeSite `json:"sites,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// EdgeSite is a source position where an edge occurs, e.g. a call expression
+type EdgeSite struct {
+	FilePath string `json:"file_path"` // Relative to the repository root
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
 // Package represents a Go package with aggregated information
 type Package struct {
 	// Identification
@@ -197,10 +231,20 @@ type PackMetadata struct {
 // Edge types constants
 const (
 	EdgeTypeCalls      = "calls"
+	EdgeTypeDispatches = "dispatches" // interface method -> implementing method
 	EdgeTypeImplements = "implements"
 	EdgeTypeImports    = "imports"
 	EdgeTypeReferences = "references"
 	EdgeTypeEmbeds     = "embeds"
+	EdgeTypeConstrains = "constrains" // generic declaration -> constraint interface
+	EdgeTypeTests      = "tests"      // test -> production symbol it exercises
+)
+
+// Edge provenance constants
+const (
+	ProvenanceStatic = "static" // from the source or the type checker
+	ProvenanceCHA    = "cha"    // inferred by class hierarchy analysis
+	ProvenanceVTA    = "vta"    // inferred by variable type analysis
 )
 
 // Package role constants (inferred from directory name, imports, etc.)
@@ -225,4 +269,16 @@ const (
 	KindMethod    = "method"
 	KindConst     = "const"
 	KindVar       = "var"
+	KindField     = "field"
+	KindTest      = "test" // Test, Benchmark, Fuzz and Example functions
 )
+
+// FileHash records the content hash of an indexed source file
+type FileHash struct {
+	RepoPath    string    `json:"repo_path"`
+	FilePath    string    `json:"file_path"` // Relative to repo root
+	PackagePath string    `json:"package_path"`
+	ContentHash string    `json:"content_hash"` // SHA-256 of file contents
+	Size        int64     `json:"size"`
+	IndexedAt   time.Time `json:"indexed_at"`
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -97,7 +98,7 @@ func (p *PackageStore) Get(path string) (*Package, error) {
 	row := p.db.sqlDB.QueryRow(query, path)
 	pkg, err := p.scanPackageRow(row)
 
-	if err == sql.ErrNoRows {
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RerankCache stores the relevance scores of the rerank stage
+type RerankCache struct {
+	db *DB
+}
+
+// NewRerankCache creates a new rerank cache
+func NewRerankCache(db *DB) *RerankCache {
+	return &RerankCache{db: db}
+}
+
+// GetScores returns the cached scores for the given keys
+func (c *RerankCache) GetScores(model string, keys []string) (map[string]float64, error) {
+	found := make(map[string]float64, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, model)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT key, score FROM rerank_cache WHERE model = ? AND key IN (%s)",
+		strings.TrimSuffix(strings.Repeat("?,", len(keys)), ","),
+	)
+	rows, err := c.db.sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rerank cache: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var score float64
+		if err := rows.Scan(&key, &score); err != nil {
+			return nil, fmt.Errorf("failed to scan cached rerank score: %w", err)
+		}
+		found[key] = score
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rerank cache: %w", err)
+	}
+	return found, nil
+}
+
+// PutScores stores scores by key
+func (c *RerankCache) PutScores(model string, scores map[string]float64) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO rerank_cache (model, key, score, created_at) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for key, score := range scores {
+		if _, err := stmt.Exec(model, key, score, now); err != nil {
+			return fmt.Errorf("failed to cache rerank score: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -36,19 +37,24 @@ func (s *SymbolStore) Create(sym *Symbol) error {
 		return fmt.Errorf("failed to marshal type_details: %w", err)
 	}
 
+	contextsJSON, err := marshalContexts(sym.BuildContexts)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO symbols (
 			id, repo_path, kind, package_path, package_name, name, signature,
 			file_path, line_start, line_end, doc_comment, exported, semantic_text,
-			tokens, type_details, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			tokens, type_details, build_contexts, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = s.db.sqlDB.Exec(query,
 		sym.ID, sym.RepoPath, sym.Kind, sym.PackagePath, sym.PackageName,
 		sym.Name, sym.Signature, sym.FilePath, sym.LineStart, sym.LineEnd,
 		sym.DocComment, boolToInt(sym.Exported), sym.SemanticText,
-		string(tokensJSON), string(typeDetailsJSON), sym.CreatedAt, sym.UpdatedAt,
+		string(tokensJSON), string(typeDetailsJSON), contextsJSON, sym.CreatedAt, sym.UpdatedAt,
 	)
 
 	if err != nil {
@@ -74,8 +80,8 @@ func (s *SymbolStore) CreateBatch(syms []*Symbol) error {
 		INSERT INTO symbols (
 			id, repo_path, kind, package_path, package_name, name, signature,
 			file_path, line_start, line_end, doc_comment, exported, semantic_text,
-			tokens, type_details, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			tokens, type_details, build_contexts, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now().UTC()
@@ -100,11 +106,16 @@ func (s *SymbolStore) CreateBatch(syms []*Symbol) error {
 			return fmt.Errorf("failed to marshal type_details: %w", err)
 		}
 
+		contextsJSON, err := marshalContexts(sym.BuildContexts)
+		if err != nil {
+			return err
+		}
+
 		_, err = stmt.Exec(
 			sym.ID, sym.RepoPath, sym.Kind, sym.PackagePath, sym.PackageName,
 			sym.Name, sym.Signature, sym.FilePath, sym.LineStart, sym.LineEnd,
 			sym.DocComment, boolToInt(sym.Exported), sym.SemanticText,
-			string(tokensJSON), string(typeDetailsJSON), sym.CreatedAt, sym.UpdatedAt,
+			string(tokensJSON), string(typeDetailsJSON), contextsJSON, sym.CreatedAt, sym.UpdatedAt,
 		)
 
 		if err != nil {
@@ -124,14 +135,14 @@ func (s *SymbolStore) Get(id string) (*Symbol, error) {
 	query := `
 		SELECT id, repo_path, kind, package_path, package_name, name, signature,
 			file_path, line_start, line_end, doc_comment, exported, semantic_text,
-			tokens, type_details, created_at, updated_at
+			tokens, type_details, build_contexts, created_at, updated_at
 		FROM symbols WHERE id = ?
 	`
 
 	row := s.db.sqlDB.QueryRow(query, id)
 	sym, err := s.scanSymbolRow(row)
 
-	if err == sql.ErrNoRows {
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
@@ -151,7 +162,7 @@ func (s *SymbolStore) GetByPackage(pkgPath string) ([]*Symbol, error) {
 	query := `
 		SELECT id, repo_path, kind, package_path, package_name, name, signature,
 			file_path, line_start, line_end, doc_comment, exported, semantic_text,
-			tokens, type_details, created_at, updated_at
+			tokens, type_details, build_contexts, created_at, updated_at
 		FROM symbols WHERE package_path = ?
 		ORDER BY kind, name
 	`
@@ -179,7 +190,7 @@ func (s *SymbolStore) GetByRepo(repoPath string) ([]*Symbol, error) {
 	query := `
 		SELECT id, repo_path, kind, package_path, package_name, name, signature,
 			file_path, line_start, line_end, doc_comment, exported, semantic_text,
-			tokens, type_details, created_at, updated_at
+			tokens, type_details, build_contexts, created_at, updated_at
 		FROM symbols WHERE repo_path = ?
 		ORDER BY package_path, kind, name
 	`
@@ -214,7 +225,7 @@ func (s *SymbolStore) FindByName(name string, repoPath string, packagePath strin
 	query := `
 		SELECT id, repo_path, kind, package_path, package_name, name, signature,
 			file_path, line_start, line_end, doc_comment, exported, semantic_text,
-			tokens, type_details, created_at, updated_at
+			tokens, type_details, build_contexts, created_at, updated_at
 		FROM symbols WHERE name = ?
 	`
 	args := []interface{}{name}
@@ -255,6 +266,76 @@ type FileSymbol struct {
 	FilePath    string
 }
 
+// FindByLocation returns the innermost declaration of a repository whose
+// lines include line of a file, or nil. Package and file symbols are not
+// considered.
+func (s *SymbolStore) FindByLocation(repoPath string, filePath string, line int) (*Symbol, error) {
+	query := `
+		SELECT id, repo_path, kind, package_path, package_name, name, signature,
+			file_path, line_start, line_end, doc_comment, exported, semantic_text,
+			tokens, type_details, build_contexts, created_at, updated_at
+		FROM symbols
+		WHERE repo_path = ? AND file_path = ? AND line_start <= ? AND line_end >= ?
+			AND kind NOT IN (?, ?)
+		ORDER BY line_end - line_start, line_start DESC
+		LIMIT 1
+	`
+
+	row := s.db.sqlDB.QueryRow(query, repoPath, filePath, line, line, KindPackage, KindFile)
+	sym, err := s.scanSymbolRow(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find symbol at %s:%d: %w", filePath, line, err)
+	}
+
+	return sym, nil
+}
+
+// ListByFilters returns up to limit symbols matching the filters, exported
+// symbols first, ordered by package path and name
+func (s *SymbolStore) ListByFilters(filters SearchFilters, limit int) ([]*Symbol, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	where, args := filters.whereClause("s")
+	query := `
+		SELECT s.id, s.repo_path, s.kind, s.package_path, s.package_name, s.name, s.signature,
+			s.file_path, s.line_start, s.line_end, s.doc_comment, s.exported, s.semantic_text,
+			s.tokens, s.type_details, s.build_contexts, s.created_at, s.updated_at
+		FROM symbols s
+		WHERE ` + where + `
+		ORDER BY s.exported DESC, s.package_path, s.name
+	`
+
+	rows, err := s.db.sqlDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbols: %w", err)
+	}
+	defer rows.Close()
+
+	// SQL selects a superset for some filters, so the limit applies after
+	// the exact check
+	var symbols []*Symbol
+	for rows.Next() && len(symbols) < limit {
+		sym, err := s.scanSymbolRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		if filters.Matches(sym) {
+			symbols = append(symbols, sym)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read symbols: %w", err)
+	}
+
+	return symbols, nil
+}
+
 // ListFilesByRepo returns file symbols for a repository.
 func (s *SymbolStore) ListFilesByRepo(repoPath string) ([]FileSymbol, error) {
 	query := `
@@ -347,13 +428,52 @@ func (s *SymbolStore) CountByRepo(repoPath string) (int, error) {
 
 // SearchFTS performs full-text search on symbols
 func (s *SymbolStore) SearchFTS(query string, limit int) ([]*Symbol, error) {
+	return s.SearchFTSWithFilters(query, limit, SearchFilters{})
+}
+
+// SearchFTSWithFilters performs full-text search restricted to symbols
+// matching the filters. Filters are applied before the limit.
+func (s *SymbolStore) SearchFTSWithFilters(query string, limit int, filters SearchFilters) ([]*Symbol, error) {
+	results, err := s.SearchFTSScored(query, limit, filters, DefaultBM25Weights())
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]*Symbol, len(results))
+	for i, result := range results {
+		symbols[i] = result.Symbol
+	}
+	return symbols, nil
+}
+
+// BM25Weights weighs matches per column of the full-text index. The symbol
+// ID column (package path, kind and name) always has weight 1.
+type BM25Weights struct {
+	Name         float64 // Symbol name
+	SemanticText float64 // Generated description
+	Tokens       float64 // Identifier tokens (see IdentifierTokens)
+}
+
+// DefaultBM25Weights ranks name matches well above identifier token matches
+// and those above description matches
+func DefaultBM25Weights() BM25Weights {
+	return BM25Weights{Name: 10, SemanticText: 1, Tokens: 5}
+}
+
+// bm25HalfRelevance is the bm25() relevance per query term that scores 0.5
+const bm25HalfRelevance = 5.0
+
+// SearchFTSScored performs full-text search like SearchFTSWithFilters and
+// scores each match by its bm25() relevance mapped to [0, 1), so a weak best
+// match still scores low. Identifiers of the query also match their words.
+func (s *SymbolStore) SearchFTSScored(query string, limit int, filters SearchFilters, weights BM25Weights) ([]ScoredResult, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 
-	symbols, err := s.searchFTS(query, limit)
+	results, err := s.searchFTS(identifierQuery(query), limit, filters, weights)
 	if err == nil {
-		return symbols, nil
+		return normalizeBM25(results, queryTermCount(query)), nil
 	}
 	if !isFTSSyntaxError(err) {
 		return nil, err
@@ -361,49 +481,90 @@ func (s *SymbolStore) SearchFTS(query string, limit int) ([]*Symbol, error) {
 
 	cleaned := sanitizeFTSQuery(query)
 	if cleaned == "" {
-		return []*Symbol{}, nil
+		return []ScoredResult{}, nil
 	}
 	if cleaned == query {
 		return nil, err
 	}
 
-	symbols, retryErr := s.searchFTS(cleaned, limit)
+	results, retryErr := s.searchFTS(identifierQuery(cleaned), limit, filters, weights)
 	if retryErr != nil {
 		return nil, err
 	}
-	return symbols, nil
+	return normalizeBM25(results, queryTermCount(cleaned)), nil
 }
 
-func (s *SymbolStore) searchFTS(query string, limit int) ([]*Symbol, error) {
-	// Use FTS5 to search
+// searchFTS returns the matches of a query, best first, with their raw
+// relevance (the negated bm25() score) in Score
+func (s *SymbolStore) searchFTS(query string, limit int, filters SearchFilters, weights BM25Weights) ([]ScoredResult, error) {
+	where, filterArgs := filters.whereClause("s")
+
+	// bm25() is lower for better matches; its arguments weigh the id, name,
+	// semantic_text and tokens columns
 	sqlQuery := `
 		SELECT s.id, s.repo_path, s.kind, s.package_path, s.package_name,
 		       s.name, s.signature, s.file_path, s.line_start, s.line_end,
-		       s.doc_comment, s.exported, s.semantic_text, s.tokens, s.type_details,
-		       s.created_at, s.updated_at
+		       s.doc_comment, s.exported, s.semantic_text, s.tokens, s.type_details, s.build_contexts,
+		       s.created_at, s.updated_at, bm25(symbols_fts, 1.0, ?, ?, ?) AS score
 		FROM symbols_fts fts
 		JOIN symbols s ON s.id = fts.id
-		WHERE symbols_fts MATCH ?
-		ORDER BY rank
+		WHERE symbols_fts MATCH ? AND ` + where + `
+		ORDER BY score
 		LIMIT ?
 	`
 
-	rows, err := s.db.sqlDB.Query(sqlQuery, query, limit)
+	args := []interface{}{weights.Name, weights.SemanticText, weights.Tokens, query}
+	args = append(args, filterArgs...)
+	args = append(args, limit)
+
+	rows, err := s.db.sqlDB.Query(sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query symbols_fts: %w", err)
 	}
 	defer rows.Close()
 
-	var symbols []*Symbol
+	var results []ScoredResult
 	for rows.Next() {
-		sym, err := s.scanSymbolRow(rows)
+		var bm25 float64
+		sym, err := s.scanSymbolRow(rows, &bm25)
 		if err != nil {
 			return nil, err
 		}
-		symbols = append(symbols, sym)
+		results = append(results, ScoredResult{SymbolID: sym.ID, Score: float32(-bm25), Symbol: sym})
 	}
 
-	return symbols, nil
+	return results, nil
+}
+
+// normalizeBM25 maps the relevance s of each match to s/(s+k), where k grows
+// with the number of query terms as bm25() sums over them
+func normalizeBM25(results []ScoredResult, terms int) []ScoredResult {
+	if terms < 1 {
+		terms = 1
+	}
+	k := float32(bm25HalfRelevance * float64(terms))
+	for i := range results {
+		if results[i].Score > 0 {
+			results[i].Score /= results[i].Score + k
+		} else {
+			results[i].Score = 0
+		}
+	}
+	return results
+}
+
+// queryTermCount returns the number of terms of a full-text query, not
+// counting its operators
+func queryTermCount(query string) int {
+	count := 0
+	for _, field := range strings.Fields(query) {
+		switch field {
+		case "AND", "OR", "NOT":
+		default:
+			count++
+		}
+	}
+	return count
 }
 
 func isFTSSyntaxError(err error) bool {
@@ -435,20 +596,23 @@ func sanitizeFTSQuery(query string) string {
 	return strings.TrimSpace(b.String())
 }
 
-// scanSymbolRow scans a row into a Symbol
-func (s *SymbolStore) scanSymbolRow(scanner rowScanner) (*Symbol, error) {
+// scanSymbolRow scans a row into a Symbol; extra receives the columns
+// selected after the symbol columns
+func (s *SymbolStore) scanSymbolRow(scanner rowScanner, extra ...interface{}) (*Symbol, error) {
 	sym := &Symbol{}
 	var tokensJSON, typeDetailsJSON string
+	var contextsJSON sql.NullString
 	var exported int
 	var createdAtValue any
 	var updatedAtValue any
 
-	err := scanner.Scan(
+	dest := []interface{}{
 		&sym.ID, &sym.RepoPath, &sym.Kind, &sym.PackagePath, &sym.PackageName,
 		&sym.Name, &sym.Signature, &sym.FilePath, &sym.LineStart, &sym.LineEnd,
 		&sym.DocComment, &exported, &sym.SemanticText,
-		&tokensJSON, &typeDetailsJSON, &createdAtValue, &updatedAtValue,
-	)
+		&tokensJSON, &typeDetailsJSON, &contextsJSON, &createdAtValue, &updatedAtValue,
+	}
+	err := scanner.Scan(append(dest, extra...)...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan symbol: %w", err)
@@ -478,9 +642,28 @@ func (s *SymbolStore) scanSymbolRow(scanner rowScanner) (*Symbol, error) {
 		}
 	}
 
+	if contextsJSON.Valid && contextsJSON.String != "" {
+		if err := json.Unmarshal([]byte(contextsJSON.String), &sym.BuildContexts); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal build_contexts: %w", err)
+		}
+	}
+
 	return sym, nil
 }
 
+// marshalContexts encodes build contexts as a JSON array, or NULL when there
+// are none
+func marshalContexts(contexts []string) (interface{}, error) {
+	if len(contexts) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(contexts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal build_contexts: %w", err)
+	}
+	return string(data), nil
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// SplitIdentifier splits an identifier into its lower case words at
+// camelCase and acronym boundaries, underscores and digits:
+// "HTTPServer" -> [http server], "embed_batch" -> [embed batch],
+// "utf8Reader" -> [utf 8 reader]. A plural acronym stays one word ("IDs").
+func SplitIdentifier(name string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(field)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			split := false
+			switch {
+			case unicode.IsDigit(prev) != unicode.IsDigit(cur):
+				split = true
+			case unicode.IsLower(prev) && unicode.IsUpper(cur):
+				split = true
+			case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				// The last capital of an acronym starts the next word,
+				// unless it is followed by a plural s only
+				split = !(runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2])))
+			}
+			if split {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+// predeclared lists the predeclared identifiers of Go, which appear in most
+// signatures and carry no meaning for search
+var predeclared = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true,
+	"complex128": true, "error": true, "float32": true, "float64": true, "int": true,
+	"int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true,
+	"uint64": true, "uintptr": true, "nil": true, "true": true, "false": true, "iota": true,
+}
+
+// IdentifierTokens returns the search tokens of the identifiers in text: each
+// identifier in lower case and, if it has several, its words. Go keywords,
+// predeclared identifiers and single characters are left out.
+func IdentifierTokens(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(word string) {
+		if len([]rune(word)) < 2 || seen[word] {
+			return
+		}
+		seen[word] = true
+		tokens = append(tokens, word)
+	}
+
+	for _, ident := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if token.IsKeyword(ident) || predeclared[ident] {
+			continue
+		}
+		words := SplitIdentifier(ident)
+		if len(words) > 1 {
+			add(strings.ToLower(strings.Trim(ident, "_")))
+		}
+		for _, word := range words {
+			add(word)
+		}
+	}
+	return tokens
+}
+
+// maxCompoundWords is the most words of a query also matched as the single
+// identifier they spell
+const maxCompoundWords = 4
+
+// identifierQuery rewrites the identifiers of a full-text query to also
+// match their words: "vectorStore" -> (vectorStore OR (vector AND store)).
+// Quoted phrases, prefix queries, column filters and operators are kept.
+// A query of a few plain words also matches the identifier they spell:
+// "vector store" -> (vector AND store) OR vectorstore.
+func identifierQuery(query string) string {
+	if words := strings.Fields(query); len(words) > 1 && len(words) <= maxCompoundWords && plainWords(words) {
+		expanded := make([]string, len(words))
+		for i, word := range words {
+			expanded[i] = expandIdentifier(word)
+		}
+		return "(" + strings.Join(expanded, " AND ") + ") OR " + strings.ToLower(strings.Join(words, ""))
+	}
+
+	var b strings.Builder
+	runes := []rune(query)
+	inPhrase := false
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if r == '"' {
+			inPhrase = !inPhrase
+		}
+		if inPhrase || !isBareword(r) {
+			b.WriteRune(r)
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(runes) && isBareword(runes[end]) {
+			end++
+		}
+		word := string(runes[i:end])
+		if end < len(runes) && (runes[end] == '*' || runes[end] == ':') {
+			b.WriteString(word)
+		} else {
+			b.WriteString(expandIdentifier(word))
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// expandIdentifier returns a query term matching an identifier or all of
+// its words
+func expandIdentifier(word string) string {
+	switch word {
+	case "AND", "OR", "NOT", "NEAR":
+		return word
+	}
+
+	var parts []string
+	for _, part := range SplitIdentifier(word) {
+		if len([]rune(part)) > 1 {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) < 2 {
+		return word
+	}
+	return "(" + word + " OR (" + strings.Join(parts, " AND ") + "))"
+}
+
+// plainWords reports whether words are identifiers without query syntax
+func plainWords(words []string) bool {
+	for _, word := range words {
+		switch word {
+		case "AND", "OR", "NOT", "NEAR":
+			return false
+		}
+		for _, r := range word {
+			if !isBareword(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isBareword reports whether r may appear in an FTS5 bareword
+func isBareword(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r > unicode.MaxASCII
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"VectorStore", []string{"vector", "store"}},
+		{"embedBatch", []string{"embed", "batch"}},
+		{"HTTPServer", []string{"http", "server"}},
+		{"parseURL", []string{"parse", "url"}},
+		{"symbolIDs", []string{"symbol", "ids"}},
+		{"URLsFor", []string{"urls", "for"}},
+		{"embed_batch", []string{"embed", "batch"}},
+		{"utf8Reader", []string{"utf", "8", "reader"}},
+		{"BM25Weights", []string{"bm", "25", "weights"}},
+		{"Search", []string{"search"}},
+		{"_", nil},
+	}
+	for _, tt := range tests {
+		if got := SplitIdentifier(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitIdentifier(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifierTokens(t *testing.T) {
+	got := IdentifierTokens("func (s *VectorStore) EmbedBatch(ctx context.Context, texts []string) error")
+	want := []string{"vectorstore", "vector", "store", "embedbatch", "embed", "batch", "ctx", "context", "texts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IdentifierTokens() = %v, want %v", got, want)
+	}
+
+	got = IdentifierTokens("DreamCats/bcindex/internal/store")
+	want = []string{"dreamcats", "dream", "cats", "bcindex", "internal", "store"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IdentifierTokens(package path) = %v, want %v", got, want)
+	}
+}
+
+func TestIdentifierQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"vector store", "(vector AND store) OR vectorstore"},
+		{"vectorStore", "(vectorStore OR (vector AND store))"},
+		{"find HTTPServer", "(find AND (HTTPServer OR (http AND server))) OR findhttpserver"},
+		{"find HTTPServer by name now", "find (HTTPServer OR (http AND server)) by name now"},
+		{"(EmbedBatch OR embed)", "((EmbedBatch OR (embed AND batch)) OR embed)"},
+		{`"VectorStore search"`, `"VectorStore search"`},
+		{"VectorSto*", "VectorSto*"},
+		{"name:VectorStore", "name:(VectorStore OR (vector AND store))"},
+		{"a OR b", "a OR b"},
+		{"处理订单", "处理订单"},
+	}
+	for _, tt := range tests {
+		if got := identifierQuery(tt.query); got != tt.want {
+			t.Errorf("identifierQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSymbolStore_SearchFTSMatchesIdentifierWords(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tokens.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	symbolStore := NewSymbolStore(db)
+	sym := &Symbol{
+		ID: "p:method:SearchVectorStore.EmbedBatch", RepoPath: "/repo", Kind: KindMethod, PackagePath: "p",
+		Name: "EmbedBatch", Tokens: IdentifierTokens("EmbedBatch SearchVectorStore"),
+	}
+	if err := symbolStore.Create(sym); err != nil {
+		t.Fatalf("failed to create symbol: %v", err)
+	}
+
+	for _, query := range []string{"vector store", "embed batch", "embedBatch", "vectorStore"} {
+		results, err := symbolStore.SearchFTS(query, 10)
+		if err != nil {
+			t.Fatalf("SearchFTS(%q) error = %v", query, err)
+		}
+		if len(results) != 1 {
+			t.Errorf("SearchFTS(%q) = %d results, want 1", query, len(results))
+		}
+	}
+
+	// Replacing and deleting rows keeps the external-content index exact
+	if err := symbolStore.Update(sym.ID, "batches embeddings", []string{"embed"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if results, err := symbolStore.SearchFTS("vector", 10); err != nil || len(results) != 0 {
+		t.Errorf("SearchFTS(vector) after update = %v, %v, want no stale match", results, err)
+	}
+	if _, err := db.sqlDB.Exec(`DELETE FROM symbols WHERE id = ?`, sym.ID); err != nil {
+		t.Fatalf("failed to delete symbol: %v", err)
+	}
+	if _, err := db.sqlDB.Exec(`INSERT INTO symbols_fts(symbols_fts, rank) VALUES('integrity-check', 1)`); err != nil {
+		t.Errorf("full-text index integrity check failed: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/DreamCats/bcindex/internal/embedding"
@@ -12,7 +13,8 @@ import (
 
 // VectorStore provides vector storage and similarity search operations
 type VectorStore struct {
-	db *DB
+	db     *DB
+	probes int // ANN clusters scanned per query (0 = automatic)
 }
 
 // NewVectorStore creates a new vector store
@@ -20,6 +22,12 @@ func NewVectorStore(db *DB) *VectorStore {
 	return &VectorStore{db: db}
 }
 
+// SetProbes sets the number of ANN clusters scanned per query.
+// Higher values trade speed for recall; 0 selects a value automatically.
+func (v *VectorStore) SetProbes(probes int) {
+	v.probes = probes
+}
+
 // ScoredResult represents a search result with similarity score
 type ScoredResult struct {
 	SymbolID string
@@ -55,9 +63,18 @@ func (v *VectorStore) Insert(symbolID string, vector []float32, model string) er
 
 // InsertBatch inserts multiple vectors in a transaction
 func (v *VectorStore) InsertBatch(symbolIDs []string, vectors [][]float32, model string) error {
+	return v.InsertBatchWithHashes(symbolIDs, vectors, nil, model)
+}
+
+// InsertBatchWithHashes inserts multiple vectors in a transaction, recording
+// the content hash each vector was generated from (hashes may be nil)
+func (v *VectorStore) InsertBatchWithHashes(symbolIDs []string, vectors [][]float32, hashes []string, model string) error {
 	if len(symbolIDs) != len(vectors) {
 		return fmt.Errorf("symbolIDs and vectors length mismatch")
 	}
+	if hashes != nil && len(hashes) != len(symbolIDs) {
+		return fmt.Errorf("symbolIDs and hashes length mismatch")
+	}
 
 	if len(symbolIDs) == 0 {
 		return nil
@@ -70,8 +87,8 @@ func (v *VectorStore) InsertBatch(symbolIDs []string, vectors [][]float32, model
 	defer tx.Rollback()
 
 	query := `
-		INSERT OR REPLACE INTO embeddings (symbol_id, vector, dimension, model, created_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT OR REPLACE INTO embeddings (symbol_id, vector, dimension, model, content_hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
 	stmt, err := tx.Prepare(query)
@@ -92,11 +109,21 @@ func (v *VectorStore) InsertBatch(symbolIDs []string, vectors [][]float32, model
 			return fmt.Errorf("failed to convert vector %d to blob: %w", i, err)
 		}
 
-		if _, err := stmt.Exec(symbolIDs[i], blob, len(vector), model, now); err != nil {
+		var hash sql.NullString
+		if hashes != nil && hashes[i] != "" {
+			hash = sql.NullString{String: hashes[i], Valid: true}
+		}
+
+		if _, err := stmt.Exec(symbolIDs[i], blob, len(vector), model, hash, now); err != nil {
 			return fmt.Errorf("failed to insert vector %d: %w", i, err)
 		}
 	}
 
+	// Keep the ANN index in sync (INSERT OR REPLACE drops old assignments)
+	if err := v.assignVectorsTx(tx, symbolIDs, vectors); err != nil {
+		return fmt.Errorf("failed to update ANN index: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit: %w", err)
 	}
@@ -130,6 +157,49 @@ func (v *VectorStore) Get(symbolID string) ([]float32, error) {
 	return vector, nil
 }
 
+// StoredVector is an embedding together with the content it was generated from
+type StoredVector struct {
+	Vector      []float32
+	Model       string
+	ContentHash string
+}
+
+// GetByPackage returns the stored vectors of a package keyed by symbol ID
+func (v *VectorStore) GetByPackage(pkgPath string) (map[string]*StoredVector, error) {
+	query := `
+		SELECT e.symbol_id, e.vector, e.model, e.content_hash
+		FROM embeddings e
+		JOIN symbols s ON s.id = e.symbol_id
+		WHERE s.package_path = ?
+	`
+
+	rows, err := v.db.sqlDB.Query(query, pkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vectors by package: %w", err)
+	}
+	defer rows.Close()
+
+	vectors := make(map[string]*StoredVector)
+	for rows.Next() {
+		var symbolID string
+		var blob []byte
+		var stored StoredVector
+		var hash sql.NullString
+		if err := rows.Scan(&symbolID, &blob, &stored.Model, &hash); err != nil {
+			return nil, fmt.Errorf("failed to scan vector: %w", err)
+		}
+
+		stored.Vector, err = blobToVector(blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode vector for %s: %w", symbolID, err)
+		}
+		stored.ContentHash = hash.String
+		vectors[symbolID] = &stored
+	}
+
+	return vectors, rows.Err()
+}
+
 // Search performs similarity search using cosine similarity
 func (v *VectorStore) Search(queryVector []float32, topK int, symbolStore *SymbolStore) ([]ScoredResult, error) {
 	return v.search(queryVector, topK, symbolStore, true)
@@ -140,14 +210,43 @@ func (v *VectorStore) SearchByDistance(queryVector []float32, topK int, symbolSt
 	return v.search(queryVector, topK, symbolStore, false)
 }
 
+// SearchExact performs exact cosine similarity search over all vectors.
+// It bypasses the ANN index and serves as the reference for recall checks.
+func (v *VectorStore) SearchExact(queryVector []float32, topK int, symbolStore *SymbolStore) ([]ScoredResult, error) {
+	if len(queryVector) == 0 {
+		return nil, fmt.Errorf("query vector is empty")
+	}
+
+	results, err := v.scanAll(queryVector, true)
+	if err != nil {
+		return nil, err
+	}
+	return v.finishResults(results, topK, symbolStore), nil
+}
+
 // search is the internal search implementation
 func (v *VectorStore) search(queryVector []float32, topK int, symbolStore *SymbolStore, useSimilarity bool) ([]ScoredResult, error) {
 	if len(queryVector) == 0 {
 		return nil, fmt.Errorf("query vector is empty")
 	}
 
-	// Retrieve all vectors (for small datasets)
-	// TODO: For larger datasets, implement approximate nearest neighbor (ANN) indexing
+	// Use the ANN index when available, otherwise fall back to a full scan
+	results, ok, err := v.searchANN(queryVector, topK, useSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		results, err = v.scanAll(queryVector, useSimilarity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v.finishResults(results, topK, symbolStore), nil
+}
+
+// scanAll scores every stored vector against the query
+func (v *VectorStore) scanAll(queryVector []float32, useSimilarity bool) ([]ScoredResult, error) {
 	query := "SELECT symbol_id, vector, dimension FROM embeddings"
 	rows, err := v.db.sqlDB.Query(query)
 	if err != nil {
@@ -155,7 +254,12 @@ func (v *VectorStore) search(queryVector []float32, topK int, symbolStore *Symbo
 	}
 	defer rows.Close()
 
-	results := make([]ScoredResult, 0, topK)
+	return scoreRows(rows, queryVector, useSimilarity)
+}
+
+// scoreRows scores (symbol_id, vector, dimension) rows against the query
+func scoreRows(rows *sql.Rows, queryVector []float32, useSimilarity bool) ([]ScoredResult, error) {
+	var results []ScoredResult
 
 	for rows.Next() {
 		var symbolID string
@@ -199,6 +303,11 @@ func (v *VectorStore) search(queryVector []float32, topK int, symbolStore *Symbo
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	return results, nil
+}
+
+// finishResults sorts, truncates to top K and optionally loads symbols
+func (v *VectorStore) finishResults(results []ScoredResult, topK int, symbolStore *SymbolStore) []ScoredResult {
 	// Sort by score (descending)
 	sortResults(results)
 
@@ -224,56 +333,46 @@ func (v *VectorStore) search(queryVector []float32, topK int, symbolStore *Symbo
 		}
 	}
 
-	return results, nil
+	return results
 }
 
-// SearchByFilters performs similarity search with additional filters
+// SearchByFilters performs similarity search restricted to symbols matching
+// the filters. Filters are applied while selecting candidates, so a narrow
+// filter still yields up to topK results.
 func (v *VectorStore) SearchByFilters(queryVector []float32, topK int, filters SearchFilters, symbolStore *SymbolStore) ([]ScoredResult, error) {
-	// First get all results
-	results, err := v.Search(queryVector, topK*2, symbolStore) // Get more to filter
-	if err != nil {
-		return nil, err
+	if len(queryVector) == 0 {
+		return nil, fmt.Errorf("query vector is empty")
+	}
+	if filters.IsEmpty() {
+		return v.Search(queryVector, topK, symbolStore)
 	}
 
-	// Apply filters
-	filtered := make([]ScoredResult, 0, topK)
-	for _, result := range results {
-		if result.Symbol == nil {
-			continue
-		}
-
-		// Apply kind filter
-		if len(filters.Kinds) > 0 {
-			kindMatch := false
-			for _, kind := range filters.Kinds {
-				if result.Symbol.Kind == kind {
-					kindMatch = true
-					break
-				}
-			}
-			if !kindMatch {
-				continue
-			}
-		}
-
-		// Apply exported filter
-		if filters.ExportedyOnly && !result.Symbol.Exported {
-			continue
-		}
+	where, args := filters.whereClause("s")
 
-		// Apply package path filter
-		if filters.PackagePath != "" && result.Symbol.PackagePath != filters.PackagePath {
-			continue
+	results, ok, err := v.searchANNWhere(queryVector, topK, true, where, args)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		query := `
+			SELECT e.symbol_id, e.vector, e.dimension
+			FROM embeddings e
+			JOIN symbols s ON s.id = e.symbol_id
+			WHERE ` + where
+
+		rows, err := v.db.sqlDB.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query filtered vectors: %w", err)
 		}
+		defer rows.Close()
 
-		filtered = append(filtered, result)
-
-		if len(filtered) >= topK {
-			break
+		results, err = scoreRows(rows, queryVector, true)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return filtered, nil
+	return v.finishResults(results, topK, symbolStore), nil
 }
 
 // Delete removes a vector
@@ -356,13 +455,6 @@ func (v *VectorStore) HasVector(symbolID string) (bool, error) {
 	return count > 0, nil
 }
 
-// SearchFilters provides filtering options for vector search
-type SearchFilters struct {
-	Kinds         []string // Filter by symbol kinds
-	ExportedyOnly bool     // Only exported symbols
-	PackagePath   string   // Filter by package path
-}
-
 // Helper functions for vector serialization
 
 // vectorToBlob converts a float32 slice to a binary blob
@@ -390,15 +482,9 @@ func blobToVector(blob []byte) ([]float32, error) {
 	return vector, nil
 }
 
-// sortResults sorts results by score (descending) using insertion sort
+// sortResults sorts results by score (descending)
 func sortResults(results []ScoredResult) {
-	for i := 1; i < len(results); i++ {
-		key := results[i]
-		j := i - 1
-		for j >= 0 && results[j].Score < key.Score {
-			results[j+1] = results[j]
-			j--
-		}
-		results[j+1] = key
-	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
 }